@@ -19,6 +19,7 @@
 package schwift
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -36,6 +37,10 @@ type Account struct {
 	//cache
 	headers *AccountHeaders
 	caps    *Capabilities
+	//set when /info could not be queried, see capabilitiesIfAvailable()
+	capsUnavailable bool
+	//settings
+	uploadVerification ChecksumVerification
 }
 
 func (a *Account) isEqualTo(other *Account) bool {
@@ -69,9 +74,10 @@ func InitializeAccount(backend Backend) (*Account, error) {
 func (a *Account) SwitchAccount(accountName string) *Account {
 	newEndpointURL := a.baseURL + "v1/" + accountName + "/"
 	return &Account{
-		backend: a.backend.Clone(newEndpointURL),
-		baseURL: a.baseURL,
-		name:    accountName,
+		backend:            a.backend.Clone(newEndpointURL),
+		baseURL:            a.baseURL,
+		name:               accountName,
+		uploadVerification: a.uploadVerification,
 	}
 }
 
@@ -96,8 +102,22 @@ func (a *Account) Headers() (AccountHeaders, error) {
 		return *a.headers, nil
 	}
 
+	headers, err := a.FetchHeaders(nil)
+	if err != nil {
+		return headers, err
+	}
+	a.headers = &headers
+	return *a.headers, nil
+}
+
+//FetchHeaders is like Headers(), but always issues a HEAD request, using the
+//given RequestOptions. This can be used to add query parameters or headers
+//that Schwift does not model, or to pass a Context. The result is not stored
+//in the cache used by Headers().
+func (a *Account) FetchHeaders(opts *RequestOptions) (AccountHeaders, error) {
 	resp, err := Request{
 		Method:            "HEAD",
+		Options:           opts,
 		ExpectStatusCodes: []int{204},
 	}.Do(a.backend)
 	if err != nil {
@@ -105,12 +125,33 @@ func (a *Account) Headers() (AccountHeaders, error) {
 	}
 
 	headers := AccountHeaders{headersFromHTTP(resp.Header)}
-	err = headers.Validate()
-	if err != nil {
-		return headers, err
+	return headers, headers.Validate()
+}
+
+//Ping checks whether the account is reachable by issuing a HEAD request on
+//it, and returns nil on success. This is intended for health checks and
+//readiness probes, where it communicates intent better than calling
+//FetchHeaders() and discarding the result:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+//	defer cancel()
+//	err := account.Ping(ctx)
+//
+//The request is aborted when ctx is cancelled or its deadline expires, in
+//which case ctx.Err() is returned. The request is not retried, even if the
+//Backend was wrapped with a RetryPolicy, so that a health check reports
+//failures promptly. The header cache used by Headers() is not touched. If
+//ctx is nil, context.Background() is used.
+func (a *Account) Ping(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	a.headers = &headers
-	return *a.headers, nil
+	_, err := Request{
+		Method:            "HEAD",
+		Options:           &RequestOptions{Context: context.WithValue(ctx, noRetryKey{}, true)},
+		ExpectStatusCodes: []int{204},
+	}.Do(a.backend)
+	return err
 }
 
 //Invalidate clears the internal cache of this Account instance. The next call
@@ -174,6 +215,79 @@ func (a *Account) Containers() *ContainerIterator {
 	return &ContainerIterator{Account: a}
 }
 
+//AllContainers lists all containers in this account, and returns them in a
+//single slice. The given RequestOptions are sent with each GET request. This
+//is a shorthand for:
+//
+//	iter := account.Containers()
+//	iter.Options = opts
+//	containers, err := iter.Collect()
+//
+//All containers are held in memory at once, so this is only advisable for
+//accounts with a small to medium number of containers. To process large
+//accounts, use Containers() and Foreach() instead, which fetch one page of
+//container names at a time.
+func (a *Account) AllContainers(opts *RequestOptions) ([]*Container, error) {
+	iter := ContainerIterator{Account: a, Options: opts}
+	return iter.Collect()
+}
+
+//ChecksumVerification is a setting for Account.SetUploadChecksumVerification().
+type ChecksumVerification int
+
+const (
+	//VerifyChecksumAlways is the default behavior: When an object is uploaded
+	//and its Etag is not known in advance, Object.Upload() computes the MD5
+	//checksum of the content on the fly and compares it to the Etag reported by
+	//Swift, returning ErrChecksumMismatch on mismatch.
+	VerifyChecksumAlways ChecksumVerification = iota
+	//VerifyChecksumNever disables the verification described above.
+	VerifyChecksumNever
+	//VerifyChecksumAuto disables the verification described above if
+	//Account.Capabilities() reports that the encryption middleware is enabled.
+	//If the capabilities cannot be queried, checksums are verified, and the
+	//query is not repeated for later uploads.
+	VerifyChecksumAuto
+)
+
+//SetUploadChecksumVerification controls whether Object.Upload() verifies the
+//Etag reported by Swift against the MD5 checksum of the uploaded content
+//when the Etag is not known in advance (see documentation on Object.Upload()
+//for details). The setting applies to all uploads into this account, and is
+//inherited by accounts obtained through SwitchAccount().
+//
+//The verification assumes that the Etag of an object is the MD5 checksum of
+//its content. Middlewares that transform the content on its way into
+//storage can break this assumption. For example, an encryption middleware
+//may report a checksum of the stored ciphertext, which results in false
+//ErrChecksumMismatch errors. In this case, disable the verification with
+//VerifyChecksumNever, or use VerifyChecksumAuto to disable it only if the
+//server reports the encryption middleware in its capabilities. (Stock Swift
+//only reports the encryption middleware to admin users, so the latter may not
+//detect it.) Static large objects also have an Etag that is not the MD5
+//checksum of their content, but Upload() already skips the verification for
+//large object manifests, so no setting is required for them.
+//
+//When the Etag is known in advance (e.g. because it was given in the request
+//headers), Swift verifies it during the upload, and this setting has no
+//effect.
+func (a *Account) SetUploadChecksumVerification(v ChecksumVerification) {
+	a.uploadVerification = v
+}
+
+//shouldVerifyUploadChecksum implements the ChecksumVerification setting.
+func (a *Account) shouldVerifyUploadChecksum() bool {
+	switch a.uploadVerification {
+	case VerifyChecksumNever:
+		return false
+	case VerifyChecksumAuto:
+		caps, ok, _ := a.capabilitiesIfAvailable()
+		return !ok || caps.Encryption == nil || !caps.Encryption.Enabled
+	default:
+		return true
+	}
+}
+
 //Capabilities queries the GET /info endpoint of the Swift server providing
 //this account. Capabilities are cached, so the GET request will only be sent
 //once during the first call to this method.
@@ -197,6 +311,25 @@ func (a *Account) Capabilities() (Capabilities, error) {
 	return caps, nil
 }
 
+//capabilitiesIfAvailable is like Capabilities(), but when /info cannot be
+//queried (e.g. because the operator has disabled it), it returns ok = false
+//and remembers this to avoid asking /info again. Only errors caused by the
+//request context are returned, and not remembered.
+func (a *Account) capabilitiesIfAvailable() (caps Capabilities, ok bool, err error) {
+	if a.capsUnavailable {
+		return Capabilities{}, false, nil
+	}
+	caps, err = a.Capabilities()
+	if err != nil {
+		if isContextError(err) {
+			return Capabilities{}, false, err
+		}
+		a.capsUnavailable = true
+		return Capabilities{}, false, nil
+	}
+	return caps, true, nil
+}
+
 //RawCapabilities queries the GET /info endpoint of the Swift server providing
 //this account, and returns the response body. Unlike Account.Capabilities,
 //this method does not employ any caching.
@@ -211,5 +344,18 @@ func (a *Account) RawCapabilities() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return collectResponseBody(resp)
+	buf, err := collectResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		//e.g. 404 when the /info endpoint is disabled by the operator
+		return nil, UnexpectedStatusCodeError{
+			ExpectedStatusCodes: []int{http.StatusOK},
+			ActualResponse:      resp,
+			ResponseBody:        buf,
+			TransID:             resp.Header.Get("X-Trans-Id"),
+		}
+	}
+	return buf, nil
 }
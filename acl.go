@@ -0,0 +1,116 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "strings"
+
+//ACL is a container ACL, as found in the X-Container-Read and
+//X-Container-Write headers, parsed into its individual grants. Each grant is a
+//string in the ACL syntax of Swift, e.g.
+//
+//	".r:*"            //allow anonymous downloads from any referrer
+//	".r:example.com"  //allow anonymous downloads from this referrer
+//	".rlistings"      //allow anonymous object listings
+//	"AUTH_foo"        //allow all users in this account
+//	"AUTH_foo:alice"  //allow this user
+//
+//Use ParseACL() to read an existing ACL, and String() to serialize it into a
+//header value. For example, to make a container publicly readable while
+//retaining existing grants:
+//
+//	hdr, err := container.Headers()
+//	acl := schwift.ParseACL(hdr.ReadACL().Get())
+//	acl = acl.Add(schwift.ACLPublicRead...)
+//
+//	newHdr := schwift.NewContainerHeaders()
+//	newHdr.ReadACL().Set(acl.String())
+//	err = container.Update(newHdr, nil)
+//
+//The grants in an ACL are not validated by Schwift. Refer to the Swift
+//documentation for their exact semantics.
+type ACL []string
+
+//ACLPublicRead contains the grants that make a container publicly readable,
+//including object listings. This only makes sense in a read ACL.
+var ACLPublicRead = ACL{".r:*", ".rlistings"}
+
+//ReferrerGrant returns an ACL grant allowing access for requests with the
+//given HTTP Referer. The referrer may be "*" to allow all requests, or a host
+//name like "example.com" or ".example.com" (to include subdomains).
+func ReferrerGrant(referrer string) string {
+	return ".r:" + referrer
+}
+
+//UserGrant returns an ACL grant allowing access for the given user in the
+//given account. The user may be "*" to allow all users in the account.
+func UserGrant(account, user string) string {
+	return account + ":" + user
+}
+
+//ParseACL parses the value of an ACL header. Grants are separated by commas,
+//and surrounding whitespace is ignored.
+func ParseACL(value string) ACL {
+	var result ACL
+	for _, grant := range strings.Split(value, ",") {
+		grant = strings.TrimSpace(grant)
+		if grant != "" {
+			result = append(result, grant)
+		}
+	}
+	return result
+}
+
+//String serializes this ACL into a header value.
+func (acl ACL) String() string {
+	return strings.Join(acl, ",")
+}
+
+//Contains returns whether this ACL contains the given grant.
+func (acl ACL) Contains(grant string) bool {
+	for _, g := range acl {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+//Add returns a copy of this ACL with the given grants appended. Grants that
+//are already contained in the ACL are not added again.
+func (acl ACL) Add(grants ...string) ACL {
+	result := append(ACL(nil), acl...)
+	for _, grant := range grants {
+		if !result.Contains(grant) {
+			result = append(result, grant)
+		}
+	}
+	return result
+}
+
+//Remove returns a copy of this ACL without the given grants.
+func (acl ACL) Remove(grants ...string) ACL {
+	toRemove := ACL(grants)
+	var result ACL
+	for _, grant := range acl {
+		if !toRemove.Contains(grant) {
+			result = append(result, grant)
+		}
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "testing"
+
+func TestParseACL(t *testing.T) {
+	testCases := []struct {
+		input      string
+		grants     ACL
+		serialized string
+	}{
+		{"", nil, ""},
+		{".r:*,.rlistings", ACL{".r:*", ".rlistings"}, ".r:*,.rlistings"},
+		{" .r:*, .rlistings ,AUTH_foo:alice,,", ACL{".r:*", ".rlistings", "AUTH_foo:alice"}, ".r:*,.rlistings,AUTH_foo:alice"},
+	}
+
+	for _, tc := range testCases {
+		acl := ParseACL(tc.input)
+		if len(acl) != len(tc.grants) || acl.String() != tc.grants.String() {
+			t.Errorf("expected %q to parse into %#v, but got %#v", tc.input, tc.grants, acl)
+		}
+		if acl.String() != tc.serialized {
+			t.Errorf("expected %q to serialize into %q, but got %q", tc.input, tc.serialized, acl.String())
+		}
+	}
+}
+
+func TestACLModification(t *testing.T) {
+	acl := ParseACL("AUTH_foo:alice")
+
+	added := acl.Add(ACLPublicRead...)
+	expectACL(t, added, "AUTH_foo:alice,.r:*,.rlistings")
+	expectACL(t, added.Add(ReferrerGrant("*"), UserGrant("AUTH_bar", "*")), "AUTH_foo:alice,.r:*,.rlistings,AUTH_bar:*")
+	expectACL(t, added.Remove(ACLPublicRead...), "AUTH_foo:alice")
+	expectACL(t, added.Remove(UserGrant("AUTH_foo", "alice")), ".r:*,.rlistings")
+
+	//original ACL is not modified
+	expectACL(t, acl, "AUTH_foo:alice")
+
+	if !added.Contains(".rlistings") {
+		t.Error("expected ACL to contain .rlistings")
+	}
+	if added.Contains(".r:example.com") {
+		t.Error("expected ACL to not contain .r:example.com")
+	}
+}
+
+func expectACL(t *testing.T, acl ACL, expected string) {
+	t.Helper()
+	if acl.String() != expected {
+		t.Errorf("expected ACL %q, but got %q", expected, acl.String())
+	}
+}
@@ -0,0 +1,137 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+//DownloadArchiveOptions invokes advanced behavior in the
+//Container.DownloadArchive() method.
+type DownloadArchiveOptions struct {
+	//The archive format. Only BulkUploadTar (the default) and BulkUploadTarGzip
+	//are supported.
+	Format BulkUploadFormat
+	//When Prefix is set, only objects whose name starts with this string are
+	//included in the archive.
+	Prefix string
+}
+
+//DownloadArchive writes all objects of this container (or all objects below
+//opts.Prefix) into a tar archive, which is written to w as it is being
+//generated. This is the inverse of Account.BulkUpload(): Each object becomes
+//one archive entry whose path is the object name, so the archive can be
+//extracted back into a container with
+//
+//	n, err := account.BulkUpload(container.Name(), format, archive, nil)
+//
+//The objects are downloaded one after the other and streamed into the
+//archive, so at no point is more than one object buffered in memory. The
+//modification time of each entry is taken from the object listing, and the
+//Content-Type of each object is stored in the archive entry's PAX header as
+//"SCHILY.xattr.user.mime_type", which Swift's bulk extraction (and GNU tar)
+//understand. Custom metadata (X-Object-Meta-*) is not included in the
+//archive, since that would require a HEAD request for each object.
+//
+//The first return value counts the objects written into the archive. When an
+//object cannot be downloaded, DownloadArchive() aborts and returns the error.
+//Since the archive has been written up to that point, w will contain an
+//incomplete archive in this case.
+//
+//The Context from ropts (if any) applies to all requests. All other options
+//from ropts are only used for the GET requests on the objects.
+func (c *Container) DownloadArchive(w io.Writer, opts *DownloadArchiveOptions, ropts *RequestOptions) (int, error) {
+	if opts == nil {
+		opts = &DownloadArchiveOptions{}
+	}
+
+	var gz *gzip.Writer
+	switch opts.Format {
+	case "", BulkUploadTar:
+	case BulkUploadTarGzip:
+		gz = gzip.NewWriter(w)
+		w = gz
+	default:
+		return 0, errors.New("cannot write archive with unsupported format: " + string(opts.Format))
+	}
+
+	tw := tar.NewWriter(w)
+	count := 0
+	iter := ObjectIterator{
+		Container: c,
+		Prefix:    opts.Prefix,
+		Options:   requestOptionsWithContextOnly(ropts),
+	}
+	err := iter.ForeachDetailed(func(info ObjectInfo) error {
+		if info.Object == nil {
+			return nil
+		}
+		err := c.writeArchiveEntry(tw, info, ropts)
+		if err == nil {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+	err = tw.Close()
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	return count, err
+}
+
+func (c *Container) writeArchiveEntry(tw *tar.Writer, info ObjectInfo, ropts *RequestOptions) error {
+	downloaded := info.Object.Download(ropts)
+	reader, err := downloaded.AsReadCloser()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	//the size from the listing is not reliable for large objects, and the
+	//object might have changed since the listing was obtained
+	resp := downloaded.Response()
+	if resp.ContentLength < 0 {
+		return fmt.Errorf("cannot archive %s: missing Content-Length", info.Object.FullName())
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     info.Object.Name(),
+		Size:     resp.ContentLength,
+		Mode:     0644,
+		ModTime:  info.LastModified,
+		Format:   tar.FormatPAX,
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		hdr.PAXRecords = map[string]string{"SCHILY.xattr.user.mime_type": contentType}
+	}
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, reader)
+	return err
+}
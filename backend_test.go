@@ -0,0 +1,87 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//testEndpointURL is the endpoint URL of a testBackend, unless another one is
+//given.
+const testEndpointURL = "https://swift.example.com/v1/AUTH_test/"
+
+//testHandler answers requests in place of a Swift server. Handlers that need
+//to keep state or record requests are usually methods of a fake server type,
+//e.g. bulkDeleteServer.handle.
+type testHandler func(req *http.Request) (*http.Response, error)
+
+//testBackend is the Backend for unit tests that do not need a real HTTP
+//server. It passes all requests to its handler. If the handler is nil, it
+//cannot execute requests.
+type testBackend struct {
+	endpointURL string
+	handler     testHandler
+}
+
+func (b testBackend) EndpointURL() string {
+	if b.endpointURL == "" {
+		return testEndpointURL
+	}
+	return b.endpointURL
+}
+
+func (b testBackend) Clone(newEndpointURL string) Backend {
+	b.endpointURL = newEndpointURL
+	return b
+}
+
+func (b testBackend) Do(req *http.Request) (*http.Response, error) {
+	if b.handler == nil {
+		panic("testBackend without handler cannot execute requests")
+	}
+	return b.handler(req)
+}
+
+//newTestAccount initializes an Account on a testBackend with the given
+//handler and the default endpoint URL.
+func newTestAccount(t *testing.T, handler testHandler) *Account {
+	t.Helper()
+	a, err := InitializeAccount(testBackend{handler: handler})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return a
+}
+
+//testResponse builds a response for the given request. The request and the
+//header may be nil.
+func testResponse(req *http.Request, statusCode int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
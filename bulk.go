@@ -30,7 +30,7 @@ import (
 	"github.com/majewsky/schwift/capabilities"
 )
 
-//BulkUploadFormat enumerates possible archive formats for Container.BulkUpload().
+//BulkUploadFormat enumerates possible archive formats for Account.BulkUpload().
 type BulkUploadFormat string
 
 const (
@@ -61,16 +61,30 @@ const (
 //exceeded in the middle of the archive extraction).
 //
 //If not nil, the error return value is *usually* an instance of BulkError.
+//When the archive itself could not be processed (e.g. because it is not a
+//valid tar file), BulkError.OverallError contains the server's explanation.
 //
 //This operation returns (0, ErrNotSupported) if the server does not support
 //bulk-uploading.
+//
+//Use BulkUploadDetailed() to obtain the response headers along with the
+//result.
 func (a *Account) BulkUpload(uploadPath string, format BulkUploadFormat, contents io.Reader, opts *RequestOptions) (int, error) {
+	result, err := a.BulkUploadDetailed(uploadPath, format, contents, opts)
+	return result.NumberFilesCreated, err
+}
+
+//BulkUploadDetailed is like BulkUpload, but returns a BulkResult that includes
+//the response headers and the individual errors reported by Swift. The error
+//return value is the same as for BulkUpload. In particular, when it is a
+//BulkError, the same information can be found in the BulkResult.
+func (a *Account) BulkUploadDetailed(uploadPath string, format BulkUploadFormat, contents io.Reader, opts *RequestOptions) (BulkResult, error) {
 	caps, err := a.Capabilities()
 	if err != nil {
-		return 0, err
+		return BulkResult{}, err
 	}
 	if caps.BulkUpload == nil {
-		return 0, ErrNotSupported
+		return BulkResult{}, ErrNotSupported
 	}
 
 	req := Request{
@@ -90,11 +104,9 @@ func (a *Account) BulkUpload(uploadPath string, format BulkUploadFormat, content
 
 	resp, err := req.Do(a.backend)
 	if err != nil {
-		return 0, err
+		return BulkResult{}, err
 	}
-
-	result, err := parseBulkResponse(resp.Body)
-	return result.NumberFilesCreated, err
+	return parseBulkResponse(resp)
 }
 
 func parseResponseStatus(status string) (int, error) {
@@ -137,33 +149,66 @@ func makeBulkObjectError(fullName string, statusCode int) BulkObjectError {
 //If the server does not support bulk-deletion, this function falls back to
 //deleting each object and container individually, and aggregates the result.
 //
+//Otherwise, the objects and containers are deleted in batches, using the
+//limits that the server advertises in Capabilities.BulkDelete. Each request
+//contains at most MaximumDeletesPerRequest objects or containers. When some
+//objects in a batch could not be deleted, BulkDelete continues with the next
+//batch, until MaximumFailedDeletes failures have been encountered overall.
+//(Swift itself aborts a batch after that many failures.) When the server
+//does not advertise these limits, Swift's default values (10000 and 1000,
+//respectively) are used. The same happens when the server's /info endpoint
+//cannot be queried (e.g. because the operator has disabled it): In this case,
+//bulk-deletion is assumed to be supported.
+//
 //If not nil, the error return value is *usually* an instance of BulkError.
 //
 //The objects may be located in multiple containers, but they and the
 //containers must all be located in the given account. (Otherwise,
 //ErrAccountMismatch is returned.)
+//
+//Use BulkDeleteDetailed() to obtain the response headers along with the
+//result.
 func (a *Account) BulkDelete(objects []*Object, containers []*Container, opts *RequestOptions) (numDeleted int, numNotFound int, deleteError error) {
+	result, err := a.BulkDeleteDetailed(objects, containers, opts)
+	return result.NumberDeleted, result.NumberNotFound, err
+}
+
+//BulkDeleteDetailed is like BulkDelete, but returns a BulkResult that
+//includes the response headers and the individual errors reported by Swift.
+//The error return value is the same as for BulkDelete. In particular, when it
+//is a BulkError, the same information can be found in the BulkResult.
+//
+//Large numbers of objects are deleted in multiple requests, so the BulkResult
+//may contain multiple sets of response headers. When the server does not
+//support bulk-deletion, the objects and containers are deleted individually
+//as described for BulkDelete, and the BulkResult does not contain any
+//response headers. In both cases, when the deletion was aborted before all
+//objects and containers were processed, the number of unprocessed ones is
+//reported in BulkResult.NumberUnprocessed.
+func (a *Account) BulkDeleteDetailed(objects []*Object, containers []*Container, opts *RequestOptions) (BulkResult, error) {
 	//validate that all given objects are in this account
 	for _, obj := range objects {
 		if !a.isEqualTo(obj.Container().Account()) {
-			return 0, 0, ErrAccountMismatch
+			return BulkResult{}, ErrAccountMismatch
 		}
 	}
 	for _, container := range containers {
 		if !a.isEqualTo(container.Account()) {
-			return 0, 0, ErrAccountMismatch
+			return BulkResult{}, ErrAccountMismatch
 		}
 	}
 
 	//check capabilities to choose deletion method
-	caps, err := a.Capabilities()
+	limits, err := a.bulkDeleteLimits()
 	if err != nil {
-		return 0, 0, err
+		return BulkResult{}, err
 	}
-	if caps.BulkDelete == nil || !capabilities.AllowBulkDelete {
-		return a.bulkDeleteSingle(objects, containers, opts)
+	if limits == nil {
+		result, err := a.bulkDeleteSingle(objects, containers, opts)
+		result.NumberUnprocessed = len(objects) + len(containers) - result.numberProcessed()
+		return result, err
 	}
-	chunkSize := int(caps.BulkDelete.MaximumDeletesPerRequest)
+	chunkSize := limits.MaximumDeletesPerRequest
 
 	//collect names of things to delete into one big list
 	var names []string
@@ -181,6 +226,8 @@ func (a *Account) BulkDelete(objects []*Object, containers []*Container, opts *R
 
 	//split list into chunks according to maximum allowed
 	//chunk size; aggregate results
+	var result BulkResult
+	numNames := len(names)
 	for len(names) > 0 {
 		//this condition holds only in the final iteration
 		if chunkSize > len(names) {
@@ -189,37 +236,85 @@ func (a *Account) BulkDelete(objects []*Object, containers []*Container, opts *R
 		chunk := names[0:chunkSize]
 		names = names[chunkSize:]
 
-		numDeletedNow, numNotFoundNow, err := a.bulkDelete(chunk, opts)
-		numDeleted += numDeletedNow
-		numNotFound += numNotFoundNow
-		if err != nil {
-			return numDeleted, numNotFound, err
+		chunkResult, err := a.bulkDelete(chunk, opts)
+		result.add(chunkResult)
+		if err == nil {
+			continue
+		}
+
+		//when only some objects in this chunk could not be deleted, continue
+		//with the next chunk, unless Swift would have given up by now
+		_, isBulkErr := err.(BulkError)
+		chunkCompleted := isBulkErr && chunkResult.numberProcessed() == len(chunk)
+		if !chunkCompleted || len(result.ObjectErrors) >= limits.MaximumFailedDeletes {
+			result.NumberUnprocessed = numNames - result.numberProcessed()
+			if isBulkErr {
+				err = result.bulkError()
+			}
+			return result, err
 		}
 	}
 
-	return numDeleted, numNotFound, nil
+	return result, result.bulkError()
+}
+
+//bulkLimits contains the limits that BulkDelete() observes.
+type bulkLimits struct {
+	MaximumDeletesPerRequest int
+	MaximumFailedDeletes     int
+}
+
+//bulkDeleteLimits returns the limits for bulk deletion that the server
+//advertises in /info, or nil if the server does not support bulk deletion.
+//When /info cannot be queried, Swift's default limits are returned. Only
+//errors caused by the request context are reported.
+func (a *Account) bulkDeleteLimits() (*bulkLimits, error) {
+	if !capabilities.AllowBulkDelete {
+		return nil, nil
+	}
+	//when limits are not advertised, use Swift's default values
+	limits := &bulkLimits{
+		MaximumDeletesPerRequest: 10000,
+		MaximumFailedDeletes:     1000,
+	}
+
+	caps, ok, err := a.capabilitiesIfAvailable()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		//the bulk middleware is part of Swift's default pipeline, so assume that
+		//it is present
+		return limits, nil
+	}
+	if caps.BulkDelete == nil {
+		return nil, nil
+	}
+	if caps.BulkDelete.MaximumDeletesPerRequest > 0 {
+		limits.MaximumDeletesPerRequest = int(caps.BulkDelete.MaximumDeletesPerRequest)
+	}
+	if caps.BulkDelete.MaximumFailedDeletes > 0 {
+		limits.MaximumFailedDeletes = int(caps.BulkDelete.MaximumFailedDeletes)
+	}
+	return limits, nil
 }
 
 //Implementation of BulkDelete() for servers that *do not* support bulk
 //deletion.
-func (a *Account) bulkDeleteSingle(objects []*Object, containers []*Container, opts *RequestOptions) (int, int, error) {
-	var (
-		numDeleted  = 0
-		numNotFound = 0
-		errs        []BulkObjectError
-	)
+func (a *Account) bulkDeleteSingle(objects []*Object, containers []*Container, opts *RequestOptions) (BulkResult, error) {
+	var result BulkResult
 
 	handleSingleError := func(containerName, objectName string, err error) error {
 		if err == nil {
-			numDeleted++
+			result.NumberDeleted++
 			return nil
 		}
 		if Is(err, http.StatusNotFound) {
-			numNotFound++
+			result.NumberNotFound++
 			return nil
 		}
 		if statusErr, ok := err.(UnexpectedStatusCodeError); ok {
-			errs = append(errs, BulkObjectError{
+			result.ObjectErrors = append(result.ObjectErrors, BulkObjectError{
 				ContainerName: containerName,
 				ObjectName:    objectName,
 				StatusCode:    statusErr.ActualResponse.StatusCode,
@@ -234,7 +329,7 @@ func (a *Account) bulkDeleteSingle(objects []*Object, containers []*Container, o
 		err := obj.Delete(nil, opts) //this implies Invalidate()
 		err = handleSingleError(obj.Container().Name(), obj.Name(), err)
 		if err != nil {
-			return numDeleted, numNotFound, err
+			return result, err
 		}
 	}
 
@@ -242,24 +337,23 @@ func (a *Account) bulkDeleteSingle(objects []*Object, containers []*Container, o
 		err := container.Delete(opts) //this implies Invalidate()
 		err = handleSingleError(container.Name(), "", err)
 		if err != nil {
-			return numDeleted, numNotFound, err
+			return result, err
 		}
 	}
 
-	if len(errs) == 0 {
-		return numDeleted, numNotFound, nil
-	}
-	return numDeleted, numNotFound, BulkError{
-		StatusCode:   errs[0].StatusCode,
-		OverallError: http.StatusText(errs[0].StatusCode),
-		ObjectErrors: errs,
+	if len(result.ObjectErrors) == 0 {
+		result.StatusCode = http.StatusOK
+		return result, nil
 	}
+	result.StatusCode = result.ObjectErrors[0].StatusCode
+	result.OverallError = http.StatusText(result.StatusCode)
+	return result, result.bulkError()
 }
 
 //Implementation of BulkDelete() for servers that *do* support bulk deletion.
 //This function is called *after* chunking, so `len(names) <=
 //account.Capabilities.BulkDelete.MaximumDeletesPerRequest`.
-func (a *Account) bulkDelete(names []string, opts *RequestOptions) (int, int, error) {
+func (a *Account) bulkDelete(names []string, opts *RequestOptions) (BulkResult, error) {
 	req := Request{
 		Method:            "DELETE",
 		Body:              strings.NewReader(strings.Join(names, "\n") + "\n"),
@@ -271,11 +365,69 @@ func (a *Account) bulkDelete(names []string, opts *RequestOptions) (int, int, er
 	req.Options.Values.Set("bulk-delete", "true")
 	resp, err := req.Do(a.backend)
 	if err != nil {
-		return 0, 0, err
+		return BulkResult{}, err
 	}
+	return parseBulkResponse(resp)
+}
 
-	result, err := parseBulkResponse(resp.Body)
-	return result.NumberDeleted, result.NumberNotFound, err
+//BulkResult is returned by Account.BulkUploadDetailed() and
+//Account.BulkDeleteDetailed(). It contains the counters and errors that Swift
+//reports in the response body, as well as the response headers, e.g. to
+//correlate failed operations with the server's logs via Headers.TransID().
+type BulkResult struct {
+	//NumberFilesCreated is only set by BulkUploadDetailed().
+	NumberFilesCreated int
+	//NumberDeleted and NumberNotFound are only set by BulkDeleteDetailed().
+	NumberDeleted  int
+	NumberNotFound int
+	//NumberUnprocessed is only set by BulkDeleteDetailed(). It counts the
+	//objects and containers that were neither deleted nor reported in
+	//ObjectErrors because the operation was aborted early.
+	NumberUnprocessed int
+	//StatusCode, OverallError and ObjectErrors have the same meaning as in
+	//type BulkError. When a BulkDeleteDetailed() is split into multiple
+	//requests, StatusCode and OverallError refer to the first failed request
+	//(or to the last request if none failed), and ObjectErrors contains the
+	//errors from all requests.
+	StatusCode   int
+	OverallError string
+	ObjectErrors []BulkObjectError
+	//ResponseHeaders contains the headers of each response from Swift, in the
+	//order in which the requests were made.
+	ResponseHeaders []Headers
+}
+
+//add merges the result of a subsequent request into this result.
+func (r *BulkResult) add(other BulkResult) {
+	r.NumberFilesCreated += other.NumberFilesCreated
+	r.NumberDeleted += other.NumberDeleted
+	r.NumberNotFound += other.NumberNotFound
+	//keep the overall status of the first failed request
+	if r.OverallError == "" && r.StatusCode < 300 {
+		r.StatusCode = other.StatusCode
+		r.OverallError = other.OverallError
+	}
+	r.ObjectErrors = append(r.ObjectErrors, other.ObjectErrors...)
+	r.ResponseHeaders = append(r.ResponseHeaders, other.ResponseHeaders...)
+}
+
+//numberProcessed returns how many objects and containers were processed by a
+//bulk deletion, either successfully or not.
+func (r BulkResult) numberProcessed() int {
+	return r.NumberDeleted + r.NumberNotFound + len(r.ObjectErrors)
+}
+
+//bulkError returns the BulkError corresponding to this result, or nil if the
+//bulk operation was successful.
+func (r BulkResult) bulkError() error {
+	if len(r.ObjectErrors) == 0 && r.OverallError == "" && r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+	return BulkError{
+		StatusCode:   r.StatusCode,
+		OverallError: r.OverallError,
+		ObjectErrors: r.ObjectErrors,
+	}
 }
 
 type bulkResponse struct {
@@ -298,24 +450,28 @@ type bulkResponse struct {
 	NumberNotFound int `json:"Number Not Found"`
 }
 
-func parseBulkResponse(body io.ReadCloser) (bulkResponse, error) {
+func parseBulkResponse(r *http.Response) (BulkResult, error) {
 	var resp bulkResponse
-	err := json.NewDecoder(body).Decode(&resp)
-	closeErr := body.Close()
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	closeErr := r.Body.Close()
 	if err == nil {
 		err = closeErr
 	}
+	result := BulkResult{
+		ResponseHeaders: []Headers{headersFromHTTP(r.Header)},
+	}
 	if err != nil {
-		return resp, err
+		return result, err
 	}
 
-	//parse `resp` into type BulkError
-	bulkErr := BulkError{
-		OverallError: resp.ResponseBody,
-	}
-	bulkErr.StatusCode, err = parseResponseStatus(resp.ResponseStatus)
+	//parse `resp` into type BulkResult
+	result.NumberFilesCreated = resp.NumberFilesCreated
+	result.NumberDeleted = resp.NumberDeleted
+	result.NumberNotFound = resp.NumberNotFound
+	result.OverallError = resp.ResponseBody
+	result.StatusCode, err = parseResponseStatus(resp.ResponseStatus)
 	if err != nil {
-		return resp, err
+		return result, err
 	}
 	for _, suberr := range resp.Errors {
 		if len(suberr) != 2 {
@@ -323,18 +479,12 @@ func parseBulkResponse(body io.ReadCloser) (bulkResponse, error) {
 		}
 		statusCode, err := parseResponseStatus(suberr[1])
 		if err != nil {
-			return resp, err
+			return result, err
 		}
-		bulkErr.ObjectErrors = append(bulkErr.ObjectErrors,
+		result.ObjectErrors = append(result.ObjectErrors,
 			makeBulkObjectError(suberr[0], statusCode),
 		)
 	}
 
-	//is BulkError really an error?
-	if len(bulkErr.ObjectErrors) == 0 && bulkErr.OverallError == "" && bulkErr.StatusCode >= 200 && bulkErr.StatusCode < 300 {
-		return resp, nil
-	}
-	return resp, bulkErr
-	//NOTE: `resp` is passed back to the caller to read the counters
-	//(resp.NumberFilesCreated etc.)
+	return result, result.bulkError()
 }
@@ -0,0 +1,150 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//bulkDeleteServer supports bulk deletion of up to two names per request,
+//and advertises the given max_failed_deletes (if not zero). If InfoDisabled
+//is set, /info responds with 403 instead. The object "c/locked" cannot be
+//deleted, all other objects are deleted.
+type bulkDeleteServer struct {
+	MaxFailedDeletes int
+	InfoDisabled     bool
+	requests         int
+	infoRequests     int
+}
+
+func (s *bulkDeleteServer) handle(req *http.Request) (*http.Response, error) {
+	statusCode, body := 200, ""
+	header := http.Header{}
+	switch {
+	case req.URL.Path == "/info":
+		s.infoRequests++
+		if s.InfoDisabled {
+			statusCode = 403
+		} else {
+			body = fmt.Sprintf(`{"bulk_delete":{"max_deletes_per_request":2,"max_failed_deletes":%d}}`, s.MaxFailedDeletes)
+		}
+	default:
+		s.requests++
+		buf, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		numDeleted := len(strings.Fields(string(buf)))
+		status, errs := "200 OK", "[]"
+		if strings.Contains(string(buf), "/c/locked\n") {
+			numDeleted--
+			status, errs = "400 Bad Request", `[["/c/locked","409 Conflict"]]`
+		}
+		body = fmt.Sprintf(`{"Response Status":%q,"Response Body":"","Errors":%s,"Number Deleted":%d,"Number Not Found":0}`,
+			status, errs, numDeleted)
+		header.Set("X-Trans-Id", fmt.Sprintf("tx%d", s.requests))
+	}
+	return testResponse(req, statusCode, header, body), nil
+}
+
+func TestBulkDeleteDetailed(t *testing.T) {
+	a := newTestAccount(t, (&bulkDeleteServer{}).handle)
+	c := a.Container("c")
+	objects := []*Object{c.Object("a"), c.Object("b"), c.Object("locked")}
+
+	result, err := a.BulkDeleteDetailed(objects, nil, nil)
+	if _, ok := err.(BulkError); !ok {
+		t.Fatalf("expected BulkError, got %#v", err)
+	}
+	if result.NumberDeleted != 2 || result.NumberNotFound != 0 {
+		t.Errorf("expected 2 deleted and 0 not found, got %d and %d", result.NumberDeleted, result.NumberNotFound)
+	}
+	if result.StatusCode != 400 || len(result.ObjectErrors) != 1 || result.ObjectErrors[0].StatusCode != 409 {
+		t.Errorf("unexpected error information in BulkResult: %#v", result)
+	}
+	if err.Error() != result.bulkError().Error() {
+		t.Errorf("expected error %q to match BulkResult, got %q", result.bulkError().Error(), err.Error())
+	}
+
+	//one set of response headers per request
+	var transIDs []string
+	for _, hdr := range result.ResponseHeaders {
+		transIDs = append(transIDs, hdr.TransID())
+	}
+	if strings.Join(transIDs, ",") != "tx1,tx2" {
+		t.Errorf("expected trans IDs [tx1 tx2], got %v", transIDs)
+	}
+
+	//BulkDelete() reports the same counters
+	numDeleted, numNotFound, err := a.BulkDelete(objects[:2], nil, nil)
+	if err != nil || numDeleted != 2 || numNotFound != 0 {
+		t.Errorf("expected BulkDelete() to delete 2 objects, got %d deleted, %d not found, error %v", numDeleted, numNotFound, err)
+	}
+}
+
+func TestBulkDeleteLimits(t *testing.T) {
+	//a partial failure in the first chunk does not prevent the second chunk
+	//from being deleted
+	server := &bulkDeleteServer{MaxFailedDeletes: 2}
+	a := newTestAccount(t, server.handle)
+	c := a.Container("c")
+	objects := []*Object{c.Object("locked"), c.Object("a"), c.Object("b"), c.Object("c")}
+
+	result, err := a.BulkDeleteDetailed(objects, nil, nil)
+	if bulkErr, ok := err.(BulkError); !ok || bulkErr.StatusCode != 400 || len(bulkErr.ObjectErrors) != 1 {
+		t.Errorf("expected BulkError with 400 and 1 object error, got %#v", err)
+	}
+	if result.NumberDeleted != 3 || result.NumberUnprocessed != 0 || server.requests != 2 {
+		t.Errorf("expected 3 objects deleted in 2 requests, got %#v after %d requests", result, server.requests)
+	}
+
+	//when max_failed_deletes is reached, the remaining chunks are not attempted
+	server = &bulkDeleteServer{MaxFailedDeletes: 1}
+	a = newTestAccount(t, server.handle)
+	c = a.Container("c")
+	objects = []*Object{c.Object("locked"), c.Object("a"), c.Object("b"), c.Object("c")}
+
+	result, err = a.BulkDeleteDetailed(objects, nil, nil)
+	if _, ok := err.(BulkError); !ok {
+		t.Errorf("expected BulkError, got %#v", err)
+	}
+	if result.NumberDeleted != 1 || result.NumberUnprocessed != 2 || server.requests != 1 {
+		t.Errorf("expected 1 object deleted and 2 unprocessed in 1 request, got %#v after %d requests", result, server.requests)
+	}
+
+	//when /info is disabled, bulk deletion is used with Swift's default limits
+	//(which fit all objects into one request), and /info is only asked once
+	server = &bulkDeleteServer{InfoDisabled: true}
+	a = newTestAccount(t, server.handle)
+	c = a.Container("c")
+	objects = []*Object{c.Object("a"), c.Object("b"), c.Object("c")}
+	for idx := 0; idx < 2; idx++ {
+		numDeleted, _, err := a.BulkDelete(objects, nil, nil)
+		if err != nil || numDeleted != 3 {
+			t.Errorf("expected BulkDelete() to delete 3 objects, got %d deleted, error %v", numDeleted, err)
+		}
+	}
+	if server.requests != 2 || server.infoRequests != 1 {
+		t.Errorf("expected 2 bulk requests and 1 /info request, got %d and %d", server.requests, server.infoRequests)
+	}
+}
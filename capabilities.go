@@ -21,11 +21,17 @@ package schwift
 //Capabilities describes a subset of the capabilities that Swift can report
 //under its /info endpoint. This struct is obtained through the
 //Account.Capabilities() method. To query capabilities not represented in this
-//struct, see Account.QueryCapabilities().
+//struct, see Account.RawCapabilities().
 //
 //All direct members of struct Capabilities, except for "Swift", are pointers.
 //If any of these is nil, it indicates that the middleware corresponding to
-//that field is not available on this server.
+//that field is not available on this server. This can be used to check for
+//optional features before using them, for example:
+//
+//	caps, err := account.Capabilities()
+//	if caps.BulkUpload == nil {
+//		//fall back to uploading objects one by one
+//	}
 type Capabilities struct {
 	BulkDelete *struct {
 		MaximumDeletesPerRequest uint `json:"max_deletes_per_request"`
@@ -35,6 +41,9 @@ type Capabilities struct {
 		MaximumContainersPerExtraction uint `json:"max_containers_per_extraction"`
 		MaximumFailedExtractions       uint `json:"max_failed_extractions"`
 	} `json:"bulk_upload"`
+	Encryption *struct {
+		Enabled bool `json:"enabled"`
+	} `json:"encryption"`
 	StaticLargeObject *struct {
 		MaximumManifestSegments uint `json:"max_manifest_segments"`
 		MaximumManifestSize     uint `json:"max_manifest_size"`
@@ -71,6 +80,7 @@ type Capabilities struct {
 		AccountACLs bool `json:"account_acls"`
 	} `json:"tempauth"`
 	TempURL *struct {
+		AllowedDigests        []string `json:"allowed_digests"`
 		IncomingAllowHeaders  []string `json:"incoming_allow_headers"`
 		IncomingRemoveHeaders []string `json:"incoming_remove_headers"`
 		Methods               []string `json:"methods"`
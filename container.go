@@ -19,7 +19,9 @@
 package schwift
 
 import (
+	"errors"
 	"net/http"
+	"sync"
 )
 
 //Container represents a Swift container. Instances are usually obtained by
@@ -78,9 +80,23 @@ func (c *Container) Headers() (ContainerHeaders, error) {
 		return *c.headers, nil
 	}
 
+	headers, err := c.FetchHeaders(nil)
+	if err != nil {
+		return headers, err
+	}
+	c.headers = &headers
+	return *c.headers, nil
+}
+
+//FetchHeaders is like Headers(), but always issues a HEAD request, using the
+//given RequestOptions. This can be used to add query parameters or headers
+//that Schwift does not model, or to pass a Context. The result is not stored
+//in the cache used by Headers().
+func (c *Container) FetchHeaders(opts *RequestOptions) (ContainerHeaders, error) {
 	resp, err := Request{
 		Method:            "HEAD",
 		ContainerName:     c.name,
+		Options:           opts,
 		ExpectStatusCodes: []int{204},
 	}.Do(c.a.backend)
 	if err != nil {
@@ -88,12 +104,42 @@ func (c *Container) Headers() (ContainerHeaders, error) {
 	}
 
 	headers := ContainerHeaders{headersFromHTTP(resp.Header)}
-	err = headers.Validate()
+	return headers, headers.Validate()
+}
+
+//ListingChangedSince issues a HEAD request on the container and reports
+//whether its object listing may have changed since the given headers were
+//obtained. This allows callers that cache object listings to skip re-listing
+//unchanged containers. Since every GET request done by an ObjectIterator also
+//fills the header cache, the previous headers are usually obtained by calling
+//Headers() right after listing the container:
+//
+//	objects, err := container.Objects().Collect()
+//	previous, err := container.Headers()
+//	//...later...
+//	changed, err := container.ListingChangedSince(previous, nil)
+//	if err == nil && !changed {
+//	    //reuse cached listing
+//	}
+//
+//Swift does not support conditional requests on object listings, so this
+//compares the object count, the bytes used and the Last-Modified timestamp
+//of the container. This is a heuristic: Container stats are updated
+//asynchronously by Swift, so recent changes may not be reflected yet, and an
+//object being overwritten with one of the same size may go unnoticed on
+//Swift versions that do not bump the container's Last-Modified timestamp on
+//object writes. When in doubt, re-list.
+func (c *Container) ListingChangedSince(previous ContainerHeaders, opts *RequestOptions) (bool, error) {
+	current, err := c.FetchHeaders(opts)
 	if err != nil {
-		return headers, err
+		return false, err
 	}
-	c.headers = &headers
-	return *c.headers, nil
+	for _, key := range []string{"X-Container-Object-Count", "X-Container-Bytes-Used", "Last-Modified"} {
+		if current.Get(key) != previous.Get(key) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 //Update updates the container using a POST request. To add URL parameters, pass
@@ -115,10 +161,68 @@ func (c *Container) Update(headers ContainerHeaders, opts *RequestOptions) error
 	return err
 }
 
+//EnableSync configures container synchronization for this container using a
+//POST request. All objects in this container will be replicated into the
+//target container, which is given as a URL of the form
+//"//realm/cluster/account/container" (or as a full URL when the Swift cluster
+//does not use realms). The target URL is not validated by this method, but
+//Swift will reject it if it is not allowed by the cluster's configuration. The
+//same key must be configured on the target container.
+//
+//A successful POST request implies Invalidate() since it may change metadata.
+func (c *Container) EnableSync(targetURL, key string, opts *RequestOptions) error {
+	if targetURL == "" || key == "" {
+		return errors.New("container sync requires a target URL and a key")
+	}
+	hdr := NewContainerHeaders()
+	hdr.SyncTo().Set(targetURL)
+	hdr.SyncKey().Set(key)
+	return c.Update(hdr, opts)
+}
+
+//DisableSync removes the container synchronization configuration from this
+//container using a POST request. Objects that have already been synchronized
+//are not removed from the target container.
+//
+//A successful POST request implies Invalidate() since it may change metadata.
+func (c *Container) DisableSync(opts *RequestOptions) error {
+	hdr := NewContainerHeaders()
+	hdr.SyncTo().Clear()
+	hdr.SyncKey().Clear()
+	return c.Update(hdr, opts)
+}
+
 //Create creates the container using a PUT request. To add URL parameters, pass
 //a non-nil *RequestOptions.
 //
 //This function can be used regardless of whether the container exists or not.
+//Swift answers with 201 (Created) or 202 (Accepted) respectively, and both
+//are treated as success. Note that when the container exists, the headers in
+//opts are applied to it like in Update(). To leave an existing container
+//untouched, use EnsureExists() or CreateIfMissing() instead.
+//
+//To choose a storage policy for the new container, set it in the request
+//headers:
+//
+//	hdr := schwift.NewContainerHeaders()
+//	hdr.StoragePolicy().Set("gold")
+//	err := container.Create(hdr.ToOpts())
+//
+//If no storage policy is given, Swift uses the cluster's default policy. The
+//policy that was applied can be read from Headers().StoragePolicy()
+//afterwards. The storage policy of an existing container cannot be changed:
+//Create() fails with http.StatusConflict if the container exists with a
+//different policy, and Update() ignores the X-Storage-Policy header.
+//
+//All writable container headers (ACLs, quotas, metadata, CORS and staticweb
+//settings, versioning, etc.) can be combined in opts, and are applied together
+//in the same PUT request. The With...() methods on ContainerHeaders help with
+//building such a set of headers:
+//
+//	hdr := schwift.NewContainerHeaders().
+//		WithReadACL(schwift.ACLPublicRead).
+//		WithStaticWebsite("index.html", true)
+//	err := container.Create(hdr.ToOpts())
 //
 //A successful PUT request implies Invalidate() since it may change metadata.
 func (c *Container) Create(opts *RequestOptions) error {
@@ -135,10 +239,47 @@ func (c *Container) Create(opts *RequestOptions) error {
 	return err
 }
 
+//CreateIfMissing creates the container using a PUT request if it does not
+//exist yet, and returns whether it was created. Unlike Create(), the headers
+//in opts are only applied when the container is created; an existing
+//container is left untouched (like with EnsureExists(), which does not take
+//any headers). This is useful for initial headers like ACLs or quotas that
+//must not overwrite later changes:
+//
+//	hdr := schwift.NewContainerHeaders()
+//	hdr.ObjectCountQuota().Set(1000)
+//	created, err := container.CreateIfMissing(hdr.ToOpts())
+//
+//Existence is checked with Exists() first, so a HEAD request is issued unless
+//Headers() have been cached already. If another client creates the container
+//between the HEAD and the PUT request, the headers will be applied by this
+//call anyway, and false is returned.
+func (c *Container) CreateIfMissing(opts *RequestOptions) (bool, error) {
+	exists, err := c.Exists()
+	if err != nil || exists {
+		return false, err
+	}
+
+	resp, err := Request{
+		Method:            "PUT",
+		ContainerName:     c.name,
+		Options:           opts,
+		ExpectStatusCodes: []int{201, 202},
+		DrainResponseBody: true,
+	}.Do(c.a.backend)
+	if err != nil {
+		return false, err
+	}
+	c.Invalidate()
+	return resp.StatusCode == http.StatusCreated, nil
+}
+
 //Delete deletes the container using a DELETE request. To add URL parameters,
 //pass a non-nil *RequestOptions.
 //
-//This operation fails with http.StatusConflict if the container is not empty.
+//This operation fails with a ContainerNotEmptyError (which wraps the
+//http.StatusConflict response) if the container is not empty. Use
+//IsContainerNotEmpty() to recognize this error.
 //
 //This operation fails with http.StatusNotFound if the container does not exist.
 //
@@ -153,9 +294,138 @@ func (c *Container) Delete(opts *RequestOptions) error {
 	if err == nil {
 		c.Invalidate()
 	}
+	if e, ok := err.(UnexpectedStatusCodeError); ok && e.ActualResponse.StatusCode == http.StatusConflict {
+		return ContainerNotEmptyError{Container: c, Inner: e}
+	}
 	return err
 }
 
+//DeleteRecursively deletes all objects in this container, and then the
+//container itself. The objects are listed page by page, and each page is
+//deleted with Account.BulkDelete() (which falls back to deleting objects
+//individually if the server does not support bulk deletion). Listing and
+//deletion are pipelined: While further pages are being listed, up to four
+//pages are deleted in parallel. When a deletion fails with an error other
+//than a BulkError, no further pages are listed.
+//
+//Objects that disappear concurrently are ignored, and so is the container
+//disappearing. If objects are uploaded concurrently, deleting the container
+//fails with http.StatusConflict; in this case, the remaining objects are
+//listed and deleted again (up to three times in total).
+//
+//If some objects cannot be deleted, a BulkError is returned that lists all
+//of them, and the container is not deleted.
+//
+//Large objects are deleted like any other object, so their segments are only
+//deleted if they are located in this container, too.
+//
+//A successful operation implies Invalidate() on the container and all deleted
+//objects.
+func (c *Container) DeleteRecursively(opts *RequestOptions) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		err = c.deleteAllObjects(opts)
+		if err != nil {
+			return err
+		}
+		err = c.Delete(opts)
+		if err == nil || Is(err, http.StatusNotFound) {
+			c.Invalidate()
+			return nil
+		}
+		if !IsContainerNotEmpty(err) {
+			return err
+		}
+	}
+	return err
+}
+
+//deleteRecursivelyConcurrency is the number of BulkDelete() requests that
+//DeleteRecursively() executes in parallel.
+const deleteRecursivelyConcurrency = 4
+
+func (c *Container) deleteAllObjects(opts *RequestOptions) error {
+	//fill the capabilities cache (or note that /info is unavailable) before the
+	//workers start using it concurrently; see bulkDeleteLimits() for which
+	//errors are reported here
+	_, err := c.a.bulkDeleteLimits()
+	if err != nil {
+		return err
+	}
+
+	//while the listing proceeds, the pages that have been listed so far are
+	//deleted by a pool of workers
+	//(the headers and query parameters in opts are meant for the container, so
+	//only the context is passed on to the deletion of objects)
+	var (
+		pages       = make(chan []*Object)
+		wg          sync.WaitGroup
+		mutex       sync.Mutex
+		errs        []BulkObjectError
+		deleteErr   error
+		deleteRopts = requestOptionsWithContextOnly(opts)
+	)
+	for idx := 0; idx < deleteRecursivelyConcurrency; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for objects := range pages {
+				_, _, err := c.a.BulkDelete(objects, nil, deleteRopts)
+				mutex.Lock()
+				if bulkErr, ok := err.(BulkError); ok && len(bulkErr.ObjectErrors) > 0 {
+					//keep going, and report all failed objects at the end
+					errs = append(errs, bulkErr.ObjectErrors...)
+				} else if err != nil && deleteErr == nil {
+					deleteErr = err
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	listErr := c.listObjectPages(pages, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return deleteErr != nil
+	}, opts)
+	close(pages)
+	wg.Wait()
+
+	if listErr != nil {
+		return listErr
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return BulkError{
+		StatusCode:   errs[0].StatusCode,
+		OverallError: http.StatusText(errs[0].StatusCode),
+		ObjectErrors: errs,
+	}
+}
+
+//listObjectPages lists all objects in this container and sends each page into
+//the given channel, until the listing is complete or shouldAbort() returns
+//true.
+func (c *Container) listObjectPages(pages chan<- []*Object, shouldAbort func() bool, opts *RequestOptions) error {
+	iter := c.Objects()
+	iter.Options = requestOptionsWithContextOnly(opts)
+	for !shouldAbort() {
+		objects, err := iter.NextPage(-1)
+		if Is(err, http.StatusNotFound) {
+			return nil //container disappeared concurrently
+		}
+		if err != nil || len(objects) == 0 {
+			return err
+		}
+		pages <- objects
+	}
+	return nil
+}
+
 //Invalidate clears the internal cache of this Container instance. The next call
 //to Headers() on this instance will issue a HEAD request on the container.
 func (c *Container) Invalidate() {
@@ -164,7 +434,10 @@ func (c *Container) Invalidate() {
 
 //EnsureExists issues a PUT request on this container.
 //If the container does not exist yet, it will be created by this call.
-//If the container exists already, this call does not change it.
+//If the container exists already, this call does not change it. (Swift
+//answers with 201 Created or 202 Accepted respectively; both are treated as
+//success.) To supply headers for the new container without changing an
+//existing one, use CreateIfMissing() instead.
 //This function returns the same container again, because its intended use is
 //with freshly constructed Container instances like so:
 //
@@ -200,3 +473,45 @@ func (c *Container) EnsureExists() (*Container, error) {
 func (c *Container) Objects() *ObjectIterator {
 	return &ObjectIterator{Container: c}
 }
+
+//AllObjects lists all objects in this container, and returns them in a single
+//slice. The given RequestOptions are sent with each GET request. This is a
+//shorthand for:
+//
+//	iter := container.Objects()
+//	iter.Options = opts
+//	objects, err := iter.Collect()
+//
+//All objects are held in memory at once (one *Object per object, plus its
+//name), so this is only advisable for containers with a small to medium number
+//of objects. To process large containers, use Objects() and Foreach()
+//instead, which fetch one page of object names at a time, or ObjectNames()
+//and CollectNames() if only the names are needed.
+func (c *Container) AllObjects(opts *RequestOptions) ([]*Object, error) {
+	iter := ObjectIterator{Container: c, Options: opts}
+	return iter.Collect()
+}
+
+//ObjectNames returns an ObjectIterator for this container that sends the
+//given RequestOptions with each GET request. It is meant to be used with the
+//methods that report plain object names, which are the cheapest way to
+//enumerate a container:
+//
+//	iter := container.ObjectNames(nil)
+//	for {
+//	    names, err := iter.NextPageNames(-1)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if len(names) == 0 {
+//	        break //end of listing
+//	    }
+//	    //process this page of names...
+//	}
+//
+//Fields like Prefix and PageSize can be set on the returned iterator as
+//usual. Use CollectNames() or ForeachName() to have the iterator handle
+//paging.
+func (c *Container) ObjectNames(opts *RequestOptions) *ObjectIterator {
+	return &ObjectIterator{Container: c, Options: opts}
+}
@@ -64,7 +64,22 @@ type ContainerIterator struct {
 	//When Prefix is set, only containers whose name starts with this string are
 	//returned.
 	Prefix string
+	//When Marker is set, only containers whose name sorts after this string are
+	//returned.
+	Marker string
+	//When EndMarker is set, only containers whose name sorts before this string
+	//are returned.
+	EndMarker string
+	//When PageSize is > 0, Foreach(), ForeachDetailed(), Collect() and
+	//CollectDetailed() will not request more than this many container names
+	//per GET request. Otherwise, the page size is chosen by the server.
+	PageSize int
 	//Options may contain additional headers and query parameters for the GET request.
+	//
+	//If Options.Context is set, its cancellation aborts the iteration: The
+	//context is checked before each page is fetched and before each callback
+	//invocation in Foreach() and ForeachDetailed(), and an in-flight GET request
+	//is aborted. In all these cases, the context's error is returned.
 	Options *RequestOptions
 
 	base *iteratorBase
@@ -77,6 +92,15 @@ func (i *ContainerIterator) getBase() *iteratorBase {
 	return i.base
 }
 
+//pageLimit returns the limit argument for NextPage() and NextPageDetailed()
+//as used by Foreach() and Collect().
+func (i *ContainerIterator) pageLimit() int {
+	if i.PageSize > 0 {
+		return i.PageSize
+	}
+	return -1
+}
+
 //NextPage queries Swift for the next page of container names. If limit is
 //>= 0, not more than that many container names will be returned at once. Note
 //that the server also has a limit for how many containers to list in one
@@ -137,9 +161,13 @@ func (i *ContainerIterator) NextPageDetailed(limit int) ([]ContainerInfo, error)
 //Foreach lists the container names matching this iterator and calls the
 //callback once for every container. Iteration is aborted when a GET request fails,
 //or when the callback returns a non-nil error.
+//
+//Pages are fetched lazily as the callback consumes them, so only one page of
+//container names is held in memory at a time. Use PageSize to control how
+//many container names are fetched per request.
 func (i *ContainerIterator) Foreach(callback func(*Container) error) error {
 	for {
-		containers, err := i.NextPage(-1)
+		containers, err := i.NextPage(i.pageLimit())
 		if err != nil {
 			return err
 		}
@@ -147,6 +175,9 @@ func (i *ContainerIterator) Foreach(callback func(*Container) error) error {
 			return nil //EOF
 		}
 		for _, c := range containers {
+			if err := i.getBase().contextError(); err != nil {
+				return err
+			}
 			err := callback(c)
 			if err != nil {
 				return err
@@ -158,7 +189,7 @@ func (i *ContainerIterator) Foreach(callback func(*Container) error) error {
 //ForeachDetailed is like Foreach, but includes basic metadata.
 func (i *ContainerIterator) ForeachDetailed(callback func(ContainerInfo) error) error {
 	for {
-		infos, err := i.NextPageDetailed(-1)
+		infos, err := i.NextPageDetailed(i.pageLimit())
 		if err != nil {
 			return err
 		}
@@ -166,6 +197,9 @@ func (i *ContainerIterator) ForeachDetailed(callback func(ContainerInfo) error)
 			return nil //EOF
 		}
 		for _, ci := range infos {
+			if err := i.getBase().contextError(); err != nil {
+				return err
+			}
 			err := callback(ci)
 			if err != nil {
 				return err
@@ -180,7 +214,7 @@ func (i *ContainerIterator) ForeachDetailed(callback func(ContainerInfo) error)
 func (i *ContainerIterator) Collect() ([]*Container, error) {
 	var result []*Container
 	for {
-		containers, err := i.NextPage(-1)
+		containers, err := i.NextPage(i.pageLimit())
 		if err != nil {
 			return nil, err
 		}
@@ -195,7 +229,7 @@ func (i *ContainerIterator) Collect() ([]*Container, error) {
 func (i *ContainerIterator) CollectDetailed() ([]ContainerInfo, error) {
 	var result []ContainerInfo
 	for {
-		infos, err := i.NextPageDetailed(-1)
+		infos, err := i.NextPageDetailed(i.pageLimit())
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,263 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//containerServer simulates a container whose objects are listed in pages of
+//two objects each. Bulk deletion is only supported if infoDisabled is set.
+//Container GET and HEAD responses report the object count.
+type containerServer struct {
+	mutex   sync.Mutex
+	objects map[string]bool
+	deleted []string
+	//number of DELETE requests on objects that carried an X-Container-Meta-Test header
+	deletedWithHeader int
+	//if set, /info responds with 403, and bulk deletion is supported
+	infoDisabled bool
+}
+
+func (s *containerServer) handle(req *http.Request) (*http.Response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	statusCode, body := 204, ""
+	header := http.Header{}
+	path := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/v1/AUTH_test/"), "/")
+	switch {
+	case req.URL.Path == "/info" && s.infoDisabled:
+		statusCode = 403
+	case req.URL.Path == "/info":
+		statusCode, body = 200, "{}"
+	case req.Method == "DELETE" && path == "" && req.URL.Query().Get("bulk-delete") == "true":
+		buf, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		numDeleted := 0
+		for _, name := range strings.Fields(string(buf)) {
+			name = strings.TrimPrefix(name, "/foo/")
+			if s.objects[name] {
+				numDeleted++
+			}
+			delete(s.objects, name)
+			s.deleted = append(s.deleted, name)
+		}
+		statusCode = 200
+		body = fmt.Sprintf(`{"Response Status":"200 OK","Response Body":"","Errors":[],"Number Deleted":%d,"Number Not Found":0}`, numDeleted)
+	case req.Method == "HEAD" && path == "foo":
+		header.Set("X-Container-Object-Count", strconv.Itoa(len(s.objects)))
+	case req.Method == "GET" && path == "foo":
+		header.Set("X-Container-Object-Count", strconv.Itoa(len(s.objects)))
+		var names []string
+		for name := range s.objects {
+			if name > req.URL.Query().Get("marker") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 2 {
+			names = names[:2]
+		}
+		if len(names) > 0 {
+			statusCode, body = 200, strings.Join(names, "\n")+"\n"
+		}
+	case req.Method == "DELETE" && strings.HasPrefix(path, "foo/"):
+		name := strings.TrimPrefix(path, "foo/")
+		if !s.objects[name] {
+			statusCode = 404
+		}
+		delete(s.objects, name)
+		s.deleted = append(s.deleted, name)
+		if req.Header.Get("X-Container-Meta-Test") != "" {
+			s.deletedWithHeader++
+		}
+	case req.Method == "DELETE" && path == "foo":
+		if len(s.objects) > 0 {
+			statusCode = 409
+		}
+	default:
+		statusCode = 400
+	}
+
+	//custom backends need not set resp.Request, so this one does not either
+	return testResponse(nil, statusCode, header, body), nil
+}
+
+func TestContainerDeleteRecursively(t *testing.T) {
+	server := &containerServer{objects: make(map[string]bool)}
+	for idx := 0; idx < 25; idx++ {
+		server.objects[fmt.Sprintf("object%02d", idx)] = true
+	}
+	a := newTestAccount(t, server.handle)
+
+	//headers in the RequestOptions are meant for the container, and must not be
+	//sent along with the deletion of objects
+	opts := &RequestOptions{Headers: Headers{"X-Container-Meta-Test": "1"}}
+	err := a.Container("foo").DeleteRecursively(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(server.objects) != 0 {
+		t.Errorf("expected all objects to be deleted, but %d remain", len(server.objects))
+	}
+	if len(server.deleted) != 25 {
+		t.Errorf("expected 25 DELETE requests on objects, got %d", len(server.deleted))
+	}
+	if server.deletedWithHeader != 0 {
+		t.Errorf("expected container headers not to be sent when deleting objects, but got %d such requests", server.deletedWithHeader)
+	}
+}
+
+func TestContainerDeleteRecursivelyWithoutInfo(t *testing.T) {
+	//when /info is disabled, DeleteRecursively() still works by assuming that
+	//bulk deletion is supported
+	server := &containerServer{objects: make(map[string]bool), infoDisabled: true}
+	for idx := 0; idx < 25; idx++ {
+		server.objects[fmt.Sprintf("object%02d", idx)] = true
+	}
+	a := newTestAccount(t, server.handle)
+
+	err := a.Container("foo").DeleteRecursively(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(server.objects) != 0 {
+		t.Errorf("expected all objects to be deleted, but %d remain", len(server.objects))
+	}
+	if len(server.deleted) != 25 {
+		t.Errorf("expected 25 objects to be deleted, got %d", len(server.deleted))
+	}
+}
+
+func TestContainerListingChangedSince(t *testing.T) {
+	server := &containerServer{objects: map[string]bool{"first": true, "second": true}}
+	a := newTestAccount(t, server.handle)
+	c := a.Container("foo")
+
+	_, err := c.Objects().Collect()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	previous, err := c.Headers()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	changed, err := c.ListingChangedSince(previous, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if changed {
+		t.Error("expected unchanged listing to be reported as unchanged")
+	}
+
+	server.objects["third"] = true
+	changed, err = c.ListingChangedSince(previous, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !changed {
+		t.Error("expected listing with new object to be reported as changed")
+	}
+}
+
+func TestContainerCreateWithCombinedHeaders(t *testing.T) {
+	server := &endpointServer{}
+	a := newTestAccount(t, server.handle)
+	numRequests := len(server.requests)
+
+	hdr := NewContainerHeaders().
+		WithReadACL(ACLPublicRead).
+		WithWriteACL(ACL{UserGrant("AUTH_test", "uploader")}).
+		WithStaticWebsite("index.html", true).
+		WithCORS([]string{"https://example.com", "https://example.org"}, time.Hour).
+		WithMetadata("owner", "web-team")
+	hdr.BytesUsedQuota().Set(1 << 30)
+	hdr.ObjectCountQuota().Set(1000)
+	err := a.Container("website").Create(hdr.ToOpts())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(server.requests) != numRequests+1 {
+		t.Fatalf("expected exactly one request for Create(), got %d", len(server.requests)-numRequests)
+	}
+	req := server.requests[numRequests]
+	if req.Method != "PUT" || req.URL.Path != "/v1/AUTH_test/website/" {
+		t.Errorf("expected PUT on container, got %s %s", req.Method, req.URL.Path)
+	}
+	expected := map[string]string{
+		"X-Container-Read":                             ".r:*,.rlistings",
+		"X-Container-Write":                            "AUTH_test:uploader",
+		"X-Container-Meta-Web-Index":                   "index.html",
+		"X-Container-Meta-Web-Listings":                "true",
+		"X-Container-Meta-Access-Control-Allow-Origin": "https://example.com https://example.org",
+		"X-Container-Meta-Access-Control-Max-Age":      "3600",
+		"X-Container-Meta-Owner":                       "web-team",
+		"X-Container-Meta-Quota-Bytes":                 "1073741824",
+		"X-Container-Meta-Quota-Count":                 "1000",
+	}
+	for key, value := range expected {
+		actual := req.Header.Get(key)
+		if actual != value {
+			t.Errorf("expected %s: %q, got %q", key, value, actual)
+		}
+	}
+}
+
+func TestContainerDeleteNotEmpty(t *testing.T) {
+	server := &containerServer{objects: map[string]bool{"object": true}}
+	a := newTestAccount(t, server.handle)
+
+	err := a.Container("foo").Delete(nil)
+	if !IsContainerNotEmpty(err) {
+		t.Errorf("expected IsContainerNotEmpty() to match error from Delete(), got %#v", err)
+	}
+	if !Is(err, http.StatusConflict) {
+		t.Errorf("expected Is(err, 409) to match error from Delete(), got %#v", err)
+	}
+
+	//409 responses to other requests (e.g. Container.Create()) are not matched
+	createErr := UnexpectedStatusCodeError{
+		ExpectedStatusCodes: []int{201, 202},
+		ActualResponse:      &http.Response{StatusCode: http.StatusConflict},
+	}
+	if IsContainerNotEmpty(createErr) {
+		t.Error("expected IsContainerNotEmpty() not to match plain 409 error")
+	}
+	if IsContainerNotEmpty(nil) {
+		t.Error("expected IsContainerNotEmpty(nil) to be false")
+	}
+
+	err = a.Container("foo").DeleteRecursively(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,189 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+//DefaultRedactedHeaders is the list of headers whose values are not shown in
+//the output of a DebugLogger, unless DebugLogger.RedactHeaders is set.
+var DefaultRedactedHeaders = []string{
+	"X-Auth-Token",
+	"X-Storage-Token",
+	"X-Auth-Key",
+	"X-Service-Token",
+	"X-Subject-Token",
+	"X-Account-Meta-Temp-URL-Key",
+	"X-Account-Meta-Temp-URL-Key-2",
+	"X-Container-Meta-Temp-URL-Key",
+	"X-Container-Meta-Temp-URL-Key-2",
+	"X-Container-Sync-Key",
+}
+
+//DebugLogger logs requests and responses, including their headers and
+//(optionally) the beginning of their bodies, to help with debugging. To apply
+//a DebugLogger to a Backend, use its Wrap() method before passing the Backend
+//into InitializeAccount(). When using Gophercloud, set the DebugLogger
+//attribute in gopherschwift.Options instead.
+//
+//	logger := &schwift.DebugLogger{Printf: log.Printf, MaxBodyBytes: 1024}
+//	account, err := schwift.InitializeAccount(logger.Wrap(backend))
+//
+//To write into an io.Writer instead, use the Printf method of a log.Logger:
+//
+//	logger := &schwift.DebugLogger{Printf: log.New(w, "", 0).Printf}
+//
+//When combined with a RetryPolicy, the DebugLogger should be applied first, so
+//that it logs every single attempt (see documentation on type RequestObserver).
+type DebugLogger struct {
+	//Printf is called once for each request with a description of the request
+	//and its response. This field is required.
+	Printf func(format string, args ...interface{})
+	//If MaxBodyBytes is > 0, up to this many bytes of each request body and
+	//response body are included in the log output.
+	MaxBodyBytes int
+	//The values of these headers are replaced by "<redacted>" in the log
+	//output. If nil, DefaultRedactedHeaders is used.
+	RedactHeaders []string
+}
+
+//Wrap returns a Backend that executes requests on the given Backend and logs
+//them using this DebugLogger.
+func (l *DebugLogger) Wrap(b Backend) Backend {
+	return &debugLoggingBackend{inner: b, logger: l}
+}
+
+type debugLoggingBackend struct {
+	inner  Backend
+	logger *DebugLogger
+}
+
+//EndpointURL implements the Backend interface.
+func (b *debugLoggingBackend) EndpointURL() string {
+	return b.inner.EndpointURL()
+}
+
+//Clone implements the Backend interface.
+func (b *debugLoggingBackend) Clone(newEndpointURL string) Backend {
+	return &debugLoggingBackend{inner: b.inner.Clone(newEndpointURL), logger: b.logger}
+}
+
+//Do implements the Backend interface.
+func (b *debugLoggingBackend) Do(req *http.Request) (*http.Response, error) {
+	limit := b.logger.MaxBodyBytes
+
+	//the beginning of the request body is read before sending the request,
+	//since the inner backend may still be consuming the body (e.g. in a
+	//separate goroutine of the http.Transport) after Do() has returned
+	var reqBody []byte
+	if limit > 0 && req.Body != nil && req.Body != http.NoBody {
+		var origBody io.ReadCloser
+		reqBody, origBody = peekBody(req.Body, limit)
+		req = req.Clone(req.Context())
+		req.Body = origBody
+	}
+
+	resp, err := b.inner.Do(req)
+
+	//the request is only logged now since the headers may have been changed by
+	//the inner backend (e.g. to add X-Auth-Token)
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, req.URL.String())
+	b.logger.writeHeaders(&buf, "> ", req.Header)
+	if reqBody != nil {
+		writePeekedBody(&buf, "> ", reqBody, limit)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&buf, "< error: %s\n", err.Error())
+	} else {
+		fmt.Fprintf(&buf, "< %d %s\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+		b.logger.writeHeaders(&buf, "< ", resp.Header)
+		if limit > 0 && resp.Body != nil {
+			//peek at the beginning of the response body, then put it back for the caller
+			var peeked []byte
+			peeked, resp.Body = peekBody(resp.Body, limit)
+			writePeekedBody(&buf, "< ", peeked, limit)
+		}
+	}
+
+	b.logger.Printf("%s", strings.TrimSuffix(buf.String(), "\n"))
+	return resp, err
+}
+
+func (l *DebugLogger) writeHeaders(buf *strings.Builder, prefix string, hdr http.Header) {
+	redacted := l.RedactHeaders
+	if redacted == nil {
+		redacted = DefaultRedactedHeaders
+	}
+	isRedacted := make(map[string]bool, len(redacted))
+	for _, key := range redacted {
+		isRedacted[textproto.CanonicalMIMEHeaderKey(key)] = true
+	}
+
+	keys := make([]string, 0, len(hdr))
+	for key := range hdr {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range hdr[key] {
+			if isRedacted[textproto.CanonicalMIMEHeaderKey(key)] {
+				value = "<redacted>"
+			}
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, key, value)
+		}
+	}
+}
+
+//peekBody reads up to limit+1 bytes from the given body, and returns them
+//together with a ReadCloser that yields the full body contents.
+func peekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	peeked := make([]byte, limit+1)
+	n, _ := io.ReadFull(body, peeked)
+	peeked = peeked[:n]
+	return peeked, readCloser{io.MultiReader(bytes.NewReader(peeked), body), body}
+}
+
+func writePeekedBody(buf *strings.Builder, prefix string, peeked []byte, limit int) {
+	if len(peeked) > limit {
+		writeBody(buf, prefix, peeked[:limit], true)
+	} else {
+		writeBody(buf, prefix, peeked, false)
+	}
+}
+
+func writeBody(buf *strings.Builder, prefix string, body []byte, truncated bool) {
+	if len(body) == 0 {
+		return
+	}
+	suffix := ""
+	if truncated {
+		suffix = " (truncated)"
+	}
+	fmt.Fprintf(buf, "%sbody: %q%s\n", prefix, body, suffix)
+}
@@ -0,0 +1,144 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//echoHandler answers every request with a response containing the request
+//body, and sets an auth token on the request like a real backend would.
+func echoHandler(req *http.Request) (*http.Response, error) {
+	req.Header.Set("X-Auth-Token", "secret-token")
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return testResponse(req, 200, http.Header{"Content-Type": {"text/plain"}}, string(buf)), nil
+}
+
+func TestDebugLogger(t *testing.T) {
+	var lines []string
+	logger := &DebugLogger{
+		Printf: func(format string, args ...interface{}) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		},
+		MaxBodyBytes: 5,
+	}
+
+	hdr := NewContainerHeaders()
+	hdr.TempURLKey().Set("secret-key")
+	hdr.Metadata().Set("Foo", "bar")
+	resp, err := Request{
+		Method:        "PUT",
+		ContainerName: "foo",
+		ObjectName:    "bar",
+		Options:       hdr.ToOpts(),
+		Body:          strings.NewReader("hello world"),
+	}.Do(logger.Wrap(testBackend{handler: echoHandler}))
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+
+	//the caller must see the full response body despite the logger peeking into it
+	body, err := collectResponseBody(resp)
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+	if string(body) != "hello world" {
+		t.Errorf("expected response body %q, got %q", "hello world", string(body))
+	}
+
+	expected := strings.Join([]string{
+		"> PUT https://swift.example.com/v1/AUTH_test/foo/bar",
+		"> Expect: 100-continue",
+		"> X-Auth-Token: <redacted>",
+		"> X-Container-Meta-Foo: bar",
+		"> X-Container-Meta-Temp-Url-Key: <redacted>",
+		`> body: "hello" (truncated)`,
+		"< 200 OK",
+		"< Content-Type: text/plain",
+		`< body: "hello" (truncated)`,
+	}, "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log message, got %d: %#v", len(lines), lines)
+	}
+	if lines[0] != expected {
+		t.Errorf("expected log message:\n%s\ngot:\n%s", expected, lines[0])
+	}
+}
+
+//asyncServer consumes the request body in a separate goroutine after the
+//handler has returned, like http.Transport may do.
+type asyncServer struct {
+	received chan []byte
+}
+
+func (s asyncServer) handle(req *http.Request) (*http.Response, error) {
+	go func() {
+		buf, _ := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		s.received <- buf
+	}()
+	return testResponse(req, 201, nil, ""), nil
+}
+
+func TestDebugLoggerAsyncRequestBody(t *testing.T) {
+	var lines []string
+	logger := &DebugLogger{
+		Printf: func(format string, args ...interface{}) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		},
+		MaxBodyBytes: 5,
+	}
+
+	//use a body that is neither seekable nor known to http.NewRequest, so that
+	//req.GetBody is not set
+	body := ioutil.NopCloser(io.MultiReader(strings.NewReader("hello "), strings.NewReader("world")))
+	req, err := http.NewRequest("PUT", "https://swift.example.com/v1/AUTH_test/foo/bar", body)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server := asyncServer{received: make(chan []byte)}
+	_, err = logger.Wrap(testBackend{handler: server.handle}).Do(req)
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+
+	received := <-server.received
+	if string(received) != "hello world" {
+		t.Errorf("expected backend to receive %q, got %q", "hello world", string(received))
+	}
+	expected := strings.Join([]string{
+		"> PUT https://swift.example.com/v1/AUTH_test/foo/bar",
+		`> body: "hello" (truncated)`,
+		"< 201 Created",
+	}, "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log message, got %d: %#v", len(lines), lines)
+	}
+	if lines[0] != expected {
+		t.Errorf("expected log message:\n%s\ngot:\n%s", expected, lines[0])
+	}
+}
@@ -65,6 +65,10 @@ the client object that it provides and wrap it into something that implements
 the schwift.Backend interface. Then use schwift.InitializeAccount() to obtain a
 schwift.Account.
 
+If your library only provides auth tokens, you can implement the
+schwift.TokenProvider interface instead, and use schwift.NewTokenBackend() to
+obtain a schwift.Backend.
+
 Caching
 
 When a GET or HEAD request is sent by an Account, Container or Object instance,
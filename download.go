@@ -19,8 +19,20 @@
 package schwift
 
 import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 //DownloadedObject is returned by Object.Download(). It wraps the io.ReadCloser
@@ -51,8 +63,43 @@ import (
 //	//Do this instead:
 //	reader, err := obj.Download(nil).AsReadCloser()
 type DownloadedObject struct {
-	r   io.ReadCloser
-	err error
+	r    io.ReadCloser
+	err  error
+	resp *http.Response
+}
+
+//NewDownloadedObject returns a DownloadedObject that yields the given reader
+//(or the given error, if not nil). This is intended for mock implementations
+//of ObjectAPI; Object.Download() does not use it. The Response() of the
+//returned DownloadedObject is nil.
+func NewDownloadedObject(r io.ReadCloser, err error) DownloadedObject {
+	return DownloadedObject{r: r, err: err}
+}
+
+//ContentRange returns the value of the Content-Range header of the GET
+//response, e.g. "bytes 1024-2047/4096". This is only set when a range was
+//requested through DownloadOptions and Swift responded with 206 Partial
+//Content, otherwise the empty string is returned.
+func (o DownloadedObject) ContentRange() string {
+	if o.resp == nil {
+		return ""
+	}
+	return o.resp.Header.Get("Content-Range")
+}
+
+//Response returns the http.Response of the GET request, or nil if the request
+//failed. This is an escape hatch for callers that need response headers which
+//Schwift does not model, e.g. headers added by deployment-specific
+//middlewares. (The well-known object headers are also available through
+//Object.Headers(), which is filled by a successful download.)
+//
+//Calling this method does not consume the response body, so one of the other
+//methods can still be called afterwards to read the object's content. Do not
+//read from Response().Body directly, since that would bypass checksum
+//verification, progress reporting and decompression. Response().Trailer is
+//only filled after the body has been read completely.
+func (o DownloadedObject) Response() *http.Response {
+	return o.resp
 }
 
 //AsReadCloser returns an io.ReadCloser containing the contents of the
@@ -71,7 +118,7 @@ func (o DownloadedObject) AsByteSlice() ([]byte, error) {
 	if err == nil {
 		err = closeErr
 	}
-	return slice, closeErr
+	return slice, err
 }
 
 //AsString collects the contents of this downloaded object into a string.
@@ -79,3 +126,474 @@ func (o DownloadedObject) AsString() (string, error) {
 	slice, err := o.AsByteSlice()
 	return string(slice), err
 }
+
+//readCloser combines an io.Reader wrapping a http.Response.Body with the
+//Close() method of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+//etagVerifyingReader computes the MD5 checksum of everything read from it,
+//and returns ErrChecksumMismatch instead of io.EOF if it does not match the
+//expected Etag.
+type etagVerifyingReader struct {
+	Reader       io.Reader
+	Hasher       hash.Hash
+	ExpectedEtag string
+}
+
+func (r *etagVerifyingReader) Read(buf []byte) (int, error) {
+	n, err := r.Reader.Read(buf)
+	r.Hasher.Write(buf[:n])
+	if err == io.EOF && hex.EncodeToString(r.Hasher.Sum(nil)) != r.ExpectedEtag {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+//canVerifyEtag checks whether the Etag of a GET response can be compared to
+//the MD5 checksum of the response body.
+func canVerifyEtag(resp *http.Response, hdr ObjectHeaders) bool {
+	//partial content does not match the checksum of the entire object; the
+	//Etag of large objects is computed from the Etags of their segments; and
+	//when net/http has already decompressed the body, the Etag refers to the
+	//compressed content
+	return resp.StatusCode == 200 && !resp.Uncompressed && hdr.Etag().Exists() && !hdr.IsLargeObject()
+}
+
+//canVerifySLOEtag checks whether a GET response contains the concatenated
+//segments of a static large object, whose Etag can be verified with
+//newSLOVerifyingReader().
+func canVerifySLOEtag(resp *http.Response, hdr ObjectHeaders) bool {
+	return resp.StatusCode == 200 && !resp.Uncompressed && hdr.Etag().Exists() && hdr.IsStaticLargeObject()
+}
+
+//sloManifestEntry is a segment in the manifest of a static large object, as
+//returned by "?multipart-manifest=get" (without "format=raw", since that
+//format does not identify nested SLOs).
+type sloManifestEntry struct {
+	Hash       string `json:"hash"`
+	Bytes      uint64 `json:"bytes"`
+	Range      string `json:"range"`
+	SubSLO     bool   `json:"sub_slo"`
+	DataBase64 string `json:"data"`
+}
+
+//sloVerifiedSegment describes what sloVerifyingReader expects of a single
+//segment.
+type sloVerifiedSegment struct {
+	Length uint64
+	//If Verify is true, the MD5 checksum of the segment's data must match Etag.
+	//Otherwise (for segments with ranges and nested SLOs), the data cannot be
+	//verified, and CompositeEntry is used in place of the checksum when
+	//computing the composite Etag.
+	Verify         bool
+	Etag           string
+	CompositeEntry string
+}
+
+//newSLOVerifyingReader fetches the manifest of the static large object that
+//produced the given GET response, and returns a reader that verifies the
+//content read from `r` against it.
+func (o *Object) newSLOVerifyingReader(r io.Reader, resp *http.Response, ropts *RequestOptions) (io.Reader, error) {
+	mopts := cloneRequestOptions(requestOptionsWithContextOnly(ropts), nil)
+	mopts.Values.Set("multipart-manifest", "get")
+	mresp, err := Request{
+		Method:            "GET",
+		ContainerName:     o.c.name,
+		ObjectName:        o.name,
+		Options:           mopts,
+		ExpectStatusCodes: []int{200},
+	}.Do(o.c.a.backend)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := collectResponseBody(mresp)
+	if err != nil {
+		return nil, err
+	}
+
+	//the Etag of the manifest is reported as X-Manifest-Etag when the content
+	//is downloaded; if they differ, the object was replaced in the meantime
+	manifestEtag := normalizeEtag(resp.Header.Get("X-Manifest-Etag"))
+	if manifestEtag != "" && manifestEtag != normalizeEtag(mresp.Header.Get("Etag")) {
+		return nil, ErrObjectChanged
+	}
+
+	var entries []sloManifestEntry
+	err = json.Unmarshal(buf, &entries)
+	if err != nil {
+		return nil, errors.New("invalid SLO manifest: " + err.Error())
+	}
+	segments := make([]sloVerifiedSegment, len(entries))
+	for idx, entry := range entries {
+		//data segment: checksum can be computed from the manifest
+		if entry.DataBase64 != "" {
+			data, err := base64.StdEncoding.DecodeString(entry.DataBase64)
+			if err != nil {
+				return nil, errors.New("invalid SLO data segment: " + err.Error())
+			}
+			sum := md5.Sum(data)
+			segments[idx] = sloVerifiedSegment{
+				Length: uint64(len(data)),
+				Verify: true,
+				Etag:   hex.EncodeToString(sum[:]),
+			}
+			continue
+		}
+
+		//segment backed by object
+		etag := normalizeEtag(entry.Hash)
+		segments[idx] = sloVerifiedSegment{
+			Length:         entry.Bytes,
+			Verify:         entry.Range == "" && !entry.SubSLO,
+			Etag:           etag,
+			CompositeEntry: etag,
+		}
+		if entry.Range != "" {
+			offset, length, ok := parseHTTPRange(entry.Range)
+			if !ok {
+				return nil, errors.New("invalid SLO segment: malformed range: " + entry.Range)
+			}
+			switch {
+			case offset < 0:
+				segments[idx].Length = length
+			case length == 0:
+				segments[idx].Length = entry.Bytes - uint64(offset)
+			default:
+				segments[idx].Length = length
+			}
+			segments[idx].CompositeEntry = etag + ":" + entry.Range + ";"
+		}
+	}
+
+	return &sloVerifyingReader{
+		Reader:       r,
+		Segments:     segments,
+		ExpectedEtag: normalizeEtag(resp.Header.Get("Etag")),
+		hasher:       md5.New(),
+		composite:    md5.New(),
+	}, nil
+}
+
+//sloVerifyingReader verifies the content of a static large object while it is
+//read: The MD5 checksum of each segment is compared to the segment's Etag from
+//the manifest as soon as the segment has been read completely, and the
+//composite Etag (the MD5 checksum of the concatenated segment Etags) is
+//compared to the object's Etag at the end. ErrChecksumMismatch is returned
+//for the first mismatch that is found.
+type sloVerifyingReader struct {
+	Reader       io.Reader
+	Segments     []sloVerifiedSegment
+	ExpectedEtag string
+	hasher       hash.Hash
+	composite    hash.Hash
+	idx          int    //index of current segment
+	offset       uint64 //bytes read from current segment
+}
+
+func (r *sloVerifyingReader) Read(buf []byte) (int, error) {
+	n, err := r.Reader.Read(buf)
+	data := buf[:n]
+	for {
+		//finish all segments that have been read completely (including empty ones)
+		for r.idx < len(r.Segments) && r.offset == r.Segments[r.idx].Length {
+			if !r.finishSegment() {
+				return n, ErrChecksumMismatch
+			}
+		}
+		if len(data) == 0 {
+			break
+		}
+		if r.idx == len(r.Segments) {
+			//more data than announced by the manifest
+			return n, ErrChecksumMismatch
+		}
+
+		chunk := r.Segments[r.idx].Length - r.offset
+		if chunk > uint64(len(data)) {
+			chunk = uint64(len(data))
+		}
+		r.hasher.Write(data[:chunk])
+		r.offset += chunk
+		data = data[chunk:]
+	}
+
+	if err == io.EOF {
+		if r.idx < len(r.Segments) || hex.EncodeToString(r.composite.Sum(nil)) != r.ExpectedEtag {
+			return n, ErrChecksumMismatch
+		}
+	}
+	return n, err
+}
+
+//finishSegment verifies the checksum of the current segment and moves on to
+//the next one. It returns false on checksum mismatch.
+func (r *sloVerifyingReader) finishSegment() bool {
+	s := r.Segments[r.idx]
+	actual := hex.EncodeToString(r.hasher.Sum(nil))
+	if s.Verify {
+		if actual != s.Etag {
+			return false
+		}
+		r.composite.Write([]byte(actual))
+	} else {
+		r.composite.Write([]byte(s.CompositeEntry))
+	}
+	r.hasher.Reset()
+	r.idx++
+	r.offset = 0
+	return true
+}
+
+//isGzipEncoded checks whether the body of a GET response is gzip-compressed
+//and can be decompressed as a whole.
+func isGzipEncoded(resp *http.Response, hdr ObjectHeaders) bool {
+	if resp.StatusCode != 200 || resp.Uncompressed {
+		return false
+	}
+	for _, encoding := range strings.Split(hdr.ContentEncoding().Get(), ",") {
+		if strings.EqualFold(strings.TrimSpace(encoding), "gzip") {
+			return true
+		}
+	}
+	return false
+}
+
+//gzipReader decompresses the gzip stream read from the wrapped io.Reader. The
+//gzip.Reader is only created on the first Read(), since creating it already
+//reads the gzip header from the network.
+type gzipReader struct {
+	Reader io.Reader
+	gz     *gzip.Reader
+}
+
+func (r *gzipReader) Read(buf []byte) (int, error) {
+	if r.gz == nil {
+		var err error
+		r.gz, err = gzip.NewReader(r.Reader)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return r.gz.Read(buf)
+}
+
+//resumingReader reads the body of a GET response, and when reading fails
+//midway, re-issues the GET request with a Range header to continue reading
+//where the previous response left off.
+type resumingReader struct {
+	object      *Object
+	ropts       *RequestOptions
+	body        io.ReadCloser
+	etag        string
+	start       int64 //absolute offset of the first byte of the original response
+	end         int64 //absolute offset of the last byte to read, or -1 if unknown
+	offset      int64 //number of bytes read so far
+	maxAttempts int
+	failures    int //consecutive failed attempts to resume
+}
+
+//newResumingReader returns nil if the given response cannot be resumed.
+func newResumingReader(o *Object, ropts *RequestOptions, resp *http.Response, maxAttempts int) *resumingReader {
+	etag := resp.Header.Get("Etag")
+	if etag == "" || resp.Uncompressed {
+		return nil
+	}
+	r := &resumingReader{
+		object:      o,
+		ropts:       ropts,
+		body:        resp.Body,
+		etag:        etag,
+		end:         resp.ContentLength - 1,
+		maxAttempts: maxAttempts,
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = 3
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		var ok bool
+		r.start, r.end, ok = parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok {
+			return nil
+		}
+	}
+	return r
+}
+
+func (r *resumingReader) Read(buf []byte) (int, error) {
+	n, err := r.body.Read(buf)
+	r.offset += int64(n)
+	if n > 0 {
+		r.failures = 0
+	}
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	if r.end >= 0 && r.start+r.offset > r.end {
+		//everything was read, only the connection teardown failed
+		return n, io.EOF
+	}
+
+	for r.failures < r.maxAttempts {
+		if r.ropts != nil && r.ropts.Context != nil && r.ropts.Context.Err() != nil {
+			break
+		}
+		r.failures++
+		resumeErr := r.resume()
+		if resumeErr == nil {
+			return n, nil
+		}
+		if resumeErr == ErrObjectChanged {
+			return n, resumeErr
+		}
+		err = resumeErr
+	}
+	return n, err
+}
+
+func (r *resumingReader) resume() error {
+	r.body.Close()
+
+	rangeStr := "bytes=" + strconv.FormatInt(r.start+r.offset, 10) + "-"
+	if r.end >= 0 {
+		rangeStr += strconv.FormatInt(r.end, 10)
+	}
+	ropts := cloneRequestOptions(r.ropts, nil)
+	ropts.Headers.Set("Range", rangeStr)
+	ropts.Headers.Set("If-Match", r.etag)
+	ropts.Headers.Del("If-None-Match")
+	ropts.Headers.Del("If-Modified-Since")
+	ropts.Headers.Del("If-Unmodified-Since")
+
+	resp, err := Request{
+		Method:            "GET",
+		ContainerName:     r.object.c.name,
+		ObjectName:        r.object.name,
+		Options:           ropts,
+		ExpectStatusCodes: []int{206},
+	}.Do(r.object.c.a.backend)
+	if Is(err, http.StatusPreconditionFailed) {
+		return ErrObjectChanged
+	}
+	if err != nil {
+		return err
+	}
+	//do not rely on the server evaluating If-Match
+	if normalizeEtag(resp.Header.Get("Etag")) != normalizeEtag(r.etag) {
+		resp.Body.Close()
+		return ErrObjectChanged
+	}
+	r.body = resp.Body
+	return nil
+}
+
+func (r *resumingReader) Close() error {
+	return r.body.Close()
+}
+
+//parseContentRange parses a Content-Range header like "bytes 1024-2047/4096"
+//and returns the offsets of the first and last byte.
+func parseContentRange(str string) (first, last int64, ok bool) {
+	str = strings.TrimPrefix(str, "bytes ")
+	idx := strings.IndexByte(str, '/')
+	if idx >= 0 {
+		str = str[:idx]
+	}
+	fields := strings.SplitN(str, "-", 2)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err = strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || last < first {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+func normalizeEtag(etag string) string {
+	return strings.ToLower(strings.Trim(etag, `"`))
+}
+
+//DownloadToFileOptions invokes advanced behavior in the Object.DownloadToFile()
+//method.
+type DownloadToFileOptions struct {
+	//These options are passed on to Object.DownloadWithOptions().
+	DownloadOptions
+	//If set, missing parent directories of the target path are created.
+	CreateParentDirectories bool
+	//The permissions of the target file. If zero, 0644 is used.
+	FileMode os.FileMode
+}
+
+//DownloadToFile downloads the object into a file at the given path. The
+//content is written into a temporary file in the same directory first, which
+//is renamed to the target path only after the download has completed
+//successfully. On error, the temporary file is removed and an existing file at
+//the target path remains untouched. This includes checksum mismatches if
+//DownloadOptions.VerifyChecksum is set.
+//
+//If Swift reports a Last-Modified time for the object, the modification time
+//of the file is set to it.
+func (o *Object) DownloadToFile(path string, opts *DownloadToFileOptions, ropts *RequestOptions) (returnedErr error) {
+	if opts == nil {
+		opts = &DownloadToFileOptions{}
+	}
+	dir := filepath.Dir(path)
+	if opts.CreateParentDirectories {
+		err := os.MkdirAll(dir, 0777)
+		if err != nil {
+			return err
+		}
+	}
+	mode := opts.FileMode
+	if mode == 0 {
+		mode = 0644
+	}
+
+	downloaded := o.DownloadWithOptions(&opts.DownloadOptions, ropts)
+	reader, err := downloaded.AsReadCloser()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if returnedErr != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return err
+	}
+	err = file.Chmod(mode)
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	hdr := ObjectHeaders{headersFromHTTP(downloaded.Response().Header)}
+	if hdr.UpdatedAt().Exists() {
+		mtime := hdr.UpdatedAt().Get()
+		err = os.Chtimes(file.Name(), mtime, mtime)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(file.Name(), path)
+}
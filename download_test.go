@@ -0,0 +1,458 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEtagVerifyingReader(t *testing.T) {
+	content := "hello world"
+	etag := "5eb63bbbe01eeed093cb22bb8f5acdc3"
+
+	r := &etagVerifyingReader{
+		Reader:       strings.NewReader(content),
+		Hasher:       md5.New(),
+		ExpectedEtag: normalizeEtag(`"` + strings.ToUpper(etag) + `"`),
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+	if string(buf) != content {
+		t.Errorf("expected content %q, got %q", content, string(buf))
+	}
+
+	r = &etagVerifyingReader{
+		Reader:       strings.NewReader(content + "!"),
+		Hasher:       md5.New(),
+		ExpectedEtag: etag,
+	}
+	_, err = ioutil.ReadAll(r)
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %#v", err)
+	}
+
+	//AsByteSlice() must report the checksum error
+	_, err = DownloadedObject{r: ioutil.NopCloser(&etagVerifyingReader{
+		Reader:       strings.NewReader(content + "!"),
+		Hasher:       md5.New(),
+		ExpectedEtag: etag,
+	})}.AsByteSlice()
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %#v", err)
+	}
+}
+
+func TestGzipDecompression(t *testing.T) {
+	content := "hello world"
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(content))
+	w.Close()
+
+	//creating the reader must not read anything yet
+	compressed := bytes.NewReader(buf.Bytes())
+	r := &gzipReader{Reader: compressed}
+	if compressed.Len() != buf.Len() {
+		t.Error("expected gzipReader to not read during construction")
+	}
+	str, err := DownloadedObject{r: ioutil.NopCloser(r)}.AsString()
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+	if str != content {
+		t.Errorf("expected content %q, got %q", content, str)
+	}
+
+	testCases := []struct {
+		statusCode      int
+		uncompressed    bool
+		contentEncoding string
+		expected        bool
+	}{
+		{200, false, "gzip", true},
+		{200, false, "GZIP", true},
+		{200, false, "identity, gzip", true},
+		{200, false, "", false},
+		{200, false, "br", false},
+		{200, true, "gzip", false},
+		{206, false, "gzip", false},
+	}
+	for _, tc := range testCases {
+		hdr := NewObjectHeaders()
+		if tc.contentEncoding != "" {
+			hdr.ContentEncoding().Set(tc.contentEncoding)
+		}
+		resp := &http.Response{StatusCode: tc.statusCode, Uncompressed: tc.uncompressed}
+		if actual := isGzipEncoded(resp, hdr); actual != tc.expected {
+			t.Errorf("expected isGzipEncoded() = %t for %#v, got %t", tc.expected, tc, actual)
+		}
+	}
+}
+
+//objectServer answers every request with the given object content and Etag.
+type objectServer struct {
+	Content string
+	Etag    string
+}
+
+func (s objectServer) handle(req *http.Request) (*http.Response, error) {
+	return testResponse(req, 200, http.Header{
+		"Etag":          {s.Etag},
+		"Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"},
+	}, s.Content), nil
+}
+
+func TestDownloadToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schwift-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "subdir", "file.txt")
+
+	content := "hello world"
+	etag := "5eb63bbbe01eeed093cb22bb8f5acdc3"
+	a := newTestAccount(t, objectServer{content, etag}.handle)
+	obj := a.Container("foo").Object("bar")
+
+	//successful download
+	opts := &DownloadToFileOptions{
+		DownloadOptions:         DownloadOptions{VerifyChecksum: true},
+		CreateParentDirectories: true,
+	}
+	err = obj.DownloadToFile(path, opts, nil)
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil || string(buf) != content {
+		t.Errorf("expected file content %q, got %q (error: %v)", content, string(buf), err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectedMtime := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !fi.ModTime().Equal(expectedMtime) {
+		t.Errorf("expected mtime %s, got %s", expectedMtime, fi.ModTime())
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Errorf("expected file mode 0644, got %o", fi.Mode().Perm())
+	}
+
+	//checksum mismatch must not clobber the existing file or leave temp files behind
+	a = newTestAccount(t, objectServer{"something else", etag}.handle)
+	err = a.Container("foo").Object("bar").DownloadToFile(path, opts, nil)
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %#v", err)
+	}
+	buf, err = ioutil.ReadFile(path)
+	if err != nil || string(buf) != content {
+		t.Errorf("expected file content %q, got %q (error: %v)", content, string(buf), err)
+	}
+	entries, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file in the directory, got %d entries", len(entries))
+	}
+}
+
+//flakyServer serves the given object content, honoring Range and If-Match
+//headers. The connection breaks after FailAfter bytes of each response body.
+type flakyServer struct {
+	Content   string
+	Etag      string
+	FailAfter int
+	Ranges    []string //Range headers of all requests
+}
+
+//errConnectionReset is returned by flakyServer response bodies.
+var errConnectionReset = errors.New("connection reset by peer")
+
+type flakyReader struct {
+	r         io.Reader
+	remaining int
+}
+
+func (r *flakyReader) Read(buf []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, errConnectionReset
+	}
+	if len(buf) > r.remaining {
+		buf = buf[:r.remaining]
+	}
+	n, err := r.r.Read(buf)
+	r.remaining -= n
+	return n, err
+}
+
+func (s *flakyServer) handle(req *http.Request) (*http.Response, error) {
+	s.Ranges = append(s.Ranges, req.Header.Get("Range"))
+	hdr := http.Header{"Etag": {`"` + s.Etag + `"`}}
+	if ifMatch := req.Header.Get("If-Match"); ifMatch != "" && ifMatch != hdr.Get("Etag") {
+		return testResponse(req, 412, hdr, ""), nil
+	}
+
+	statusCode := 200
+	content := s.Content
+	if rangeStr := req.Header.Get("Range"); rangeStr != "" {
+		rangeStr = strings.TrimPrefix(rangeStr, "bytes=")
+		last := int64(len(s.Content) - 1)
+		var first int64
+		switch {
+		case strings.HasPrefix(rangeStr, "-"): //e.g. "-500"
+			length, _ := strconv.ParseInt(rangeStr[1:], 10, 64)
+			first = last + 1 - length
+		case strings.HasSuffix(rangeStr, "-"): //e.g. "1024-"
+			first, _ = strconv.ParseInt(strings.TrimSuffix(rangeStr, "-"), 10, 64)
+		default: //e.g. "1024-2047"
+			first, last, _ = parseContentRange(rangeStr)
+		}
+		statusCode = 206
+		content = s.Content[first : last+1]
+		hdr.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, len(s.Content)))
+	}
+	return &http.Response{
+		StatusCode:    statusCode,
+		Header:        hdr,
+		ContentLength: int64(len(content)),
+		Body:          ioutil.NopCloser(&flakyReader{strings.NewReader(content), s.FailAfter}),
+		Request:       req,
+	}, nil
+}
+
+func TestResumableDownload(t *testing.T) {
+	content := "hello world"
+	etag := "5eb63bbbe01eeed093cb22bb8f5acdc3"
+
+	//without ResumableDownload, the network error is passed through
+	server := &flakyServer{Content: content, Etag: etag, FailAfter: 4}
+	a := newTestAccount(t, server.handle)
+	obj := a.Container("foo").Object("bar")
+	_, err := obj.Download(nil).AsString()
+	if err != errConnectionReset {
+		t.Errorf("expected errConnectionReset, got %#v", err)
+	}
+
+	//with ResumableDownload, the download is resumed until complete
+	testCases := []struct {
+		opts     DownloadOptions
+		expected string
+		ranges   []string
+	}{
+		{
+			opts:     DownloadOptions{VerifyChecksum: true},
+			expected: content,
+			ranges:   []string{"", "bytes=4-10", "bytes=8-10"},
+		},
+		{
+			opts:     DownloadOptions{RangeOffset: 2, RangeLength: 7},
+			expected: content[2:9],
+			ranges:   []string{"bytes=2-8", "bytes=6-8"},
+		},
+		{
+			opts:     DownloadOptions{RangeOffset: -1, RangeLength: 5},
+			expected: content[6:],
+			ranges:   []string{"bytes=-5", "bytes=10-10"},
+		},
+	}
+	for _, tc := range testCases {
+		server.Ranges = nil
+		tc.opts.ResumableDownload = true
+		str, err := obj.DownloadWithOptions(&tc.opts, nil).AsString()
+		if err != nil {
+			t.Errorf("expected success for %#v, got %s", tc.opts, err.Error())
+		}
+		if str != tc.expected {
+			t.Errorf("expected content %q for %#v, got %q", tc.expected, tc.opts, str)
+		}
+		if strings.Join(server.Ranges, ",") != strings.Join(tc.ranges, ",") {
+			t.Errorf("expected requests with ranges %v for %#v, got %v", tc.ranges, tc.opts, server.Ranges)
+		}
+	}
+
+	//when the object changes while resuming, the download fails
+	reader, err := obj.DownloadWithOptions(&DownloadOptions{ResumableDownload: true}, nil).AsReadCloser()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server.Etag = "0123456789abcdef0123456789abcdef"
+	_, err = ioutil.ReadAll(reader)
+	if err != ErrObjectChanged {
+		t.Errorf("expected ErrObjectChanged, got %#v", err)
+	}
+	reader.Close()
+}
+
+func TestParseContentRange(t *testing.T) {
+	testCases := []struct {
+		input       string
+		first, last int64
+		ok          bool
+	}{
+		{"bytes 1024-2047/4096", 1024, 2047, true},
+		{"bytes 0-0/*", 0, 0, true},
+		{"bytes */4096", 0, 0, false},
+		{"bytes 2047-1024/4096", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tc := range testCases {
+		first, last, ok := parseContentRange(tc.input)
+		if first != tc.first || last != tc.last || ok != tc.ok {
+			t.Errorf("expected parseContentRange(%q) = (%d, %d, %t), got (%d, %d, %t)",
+				tc.input, tc.first, tc.last, tc.ok, first, last, ok)
+		}
+	}
+}
+
+func TestRangeIgnored(t *testing.T) {
+	//objectServer always responds with 200 and the entire content
+	a := newTestAccount(t, objectServer{"hello world", "5eb63bbbe01eeed093cb22bb8f5acdc3"}.handle)
+	obj := a.Container("foo").Object("bar")
+	_, err := obj.DownloadWithOptions(&DownloadOptions{RangeOffset: 6}, nil).AsString()
+	if err != ErrRangeIgnored {
+		t.Errorf("expected ErrRangeIgnored, got %#v", err)
+	}
+	str, err := obj.Download(nil).AsString()
+	if err != nil || str != "hello world" {
+		t.Errorf("expected full download to succeed, got %q (error: %v)", str, err)
+	}
+
+	//a Range header given by the caller is not checked
+	ropts := &RequestOptions{Headers: Headers{"Range": "bytes=6-"}}
+	str, err = obj.DownloadWithOptions(&DownloadOptions{VerifyChecksum: true}, ropts).AsString()
+	if err != nil || str != "hello world" {
+		t.Errorf("expected download with manual Range header to succeed, got %q (error: %v)", str, err)
+	}
+
+	testCases := map[string]bool{
+		"":            false,
+		"none":        false,
+		"bytes":       true,
+		"Bytes":       true,
+		"none, bytes": true,
+	}
+	for value, expected := range testCases {
+		hdr := NewObjectHeaders()
+		if value != "" {
+			hdr.Set("Accept-Ranges", value)
+		}
+		if actual := hdr.AcceptsRanges(); actual != expected {
+			t.Errorf("expected AcceptsRanges() = %t for %q, got %t", expected, value, actual)
+		}
+	}
+}
+
+func TestNewDownloadedObject(t *testing.T) {
+	str, err := NewDownloadedObject(ioutil.NopCloser(strings.NewReader("hello")), nil).AsString()
+	if err != nil || str != "hello" {
+		t.Errorf("expected %q, got %q (error: %v)", "hello", str, err)
+	}
+
+	expectedErr := errors.New("download failed")
+	_, err = NewDownloadedObject(nil, expectedErr).AsByteSlice()
+	if err != expectedErr {
+		t.Errorf("expected error %q, got %#v", expectedErr.Error(), err)
+	}
+}
+
+//sloServer serves a static large object with the given content and
+//manifest.
+type sloServer struct {
+	Content      string
+	Etag         string
+	Manifest     string
+	ManifestEtag string
+}
+
+func (s sloServer) handle(req *http.Request) (*http.Response, error) {
+	hdr := http.Header{"X-Static-Large-Object": {"True"}}
+	if req.URL.Query().Get("multipart-manifest") == "get" {
+		hdr.Set("Etag", s.ManifestEtag)
+		return testResponse(req, 200, hdr, s.Manifest), nil
+	}
+	hdr.Set("Etag", `"`+s.Etag+`"`)
+	hdr.Set("X-Manifest-Etag", etagOfString(s.Manifest))
+	return testResponse(req, 200, hdr, s.Content), nil
+}
+
+func etagOfString(str string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(str)))
+}
+
+func TestSLOChecksumVerification(t *testing.T) {
+	//segments: object "hello ", data segment "wor", range "2-3" of object "xxld"
+	manifest := fmt.Sprintf(`[
+		{"name":"/segments/1","hash":"%s","bytes":6},
+		{"data":"d29y"},
+		{"name":"/segments/2","hash":"%s","bytes":4,"range":"2-3"}
+	]`, etagOfString("hello "), etagOfString("xxld"))
+	compositeEtag := etagOfString(etagOfString("hello ") + etagOfString("wor") + etagOfString("xxld") + ":2-3;")
+
+	testCases := []struct {
+		content      string
+		etag         string
+		manifestEtag string
+		expectedErr  error
+	}{
+		{"hello world", compositeEtag, etagOfString(manifest), nil},
+		//data of ranged segments is not verified
+		{"hello worxx", compositeEtag, etagOfString(manifest), nil},
+		{"jello world", compositeEtag, etagOfString(manifest), ErrChecksumMismatch},
+		{"hello wold", compositeEtag, etagOfString(manifest), ErrChecksumMismatch},
+		{"hello world!", compositeEtag, etagOfString(manifest), ErrChecksumMismatch},
+		{"hello world", etagOfString("hello world"), etagOfString(manifest), ErrChecksumMismatch},
+		{"hello world", compositeEtag, etagOfString("other manifest"), ErrObjectChanged},
+	}
+	for _, tc := range testCases {
+		a := newTestAccount(t, sloServer{tc.content, tc.etag, manifest, tc.manifestEtag}.handle)
+		obj := a.Container("foo").Object("bar")
+
+		str, err := obj.DownloadWithOptions(&DownloadOptions{VerifyChecksum: true}, nil).AsString()
+		if err != tc.expectedErr {
+			t.Errorf("expected error %v for content %q, got %v", tc.expectedErr, tc.content, err)
+		}
+		if err == nil && str != tc.content {
+			t.Errorf("expected content %q, got %q", tc.content, str)
+		}
+
+		//without VerifyChecksum, the content is passed through unchecked
+		str, err = obj.Download(nil).AsString()
+		if err != nil || str != tc.content {
+			t.Errorf("expected unverified download of %q to succeed, got %q (error: %v)", tc.content, str, err)
+		}
+	}
+}
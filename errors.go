@@ -19,17 +19,28 @@
 package schwift
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	//ErrChecksumMismatch is returned by Object.Upload() when the Etag in the
-	//server response does not match the uploaded data.
+	//server response does not match the uploaded data, by
+	//LargeObject.WriteManifest() when the Etag of a new static large object does
+	//not match the Etags of its segments, and by the DownloadedObject returned by
+	//Object.DownloadWithOptions() when the downloaded data does not match the Etag
+	//(if DownloadOptions.VerifyChecksum is set).
 	ErrChecksumMismatch = errors.New("Etag on uploaded object does not match MD5 checksum of uploaded data")
+	//ErrContentLengthMismatch is returned by Object.Upload() when
+	//UploadOptions.ContentLength is set, and the content is shorter or longer
+	//than that.
+	ErrContentLengthMismatch = errors.New("content length does not match UploadOptions.ContentLength")
 	//ErrNoContainerName is returned by Request.Do() if ObjectName is given, but
 	//ContainerName is empty.
 	ErrNoContainerName = errors.New("missing container name")
@@ -57,15 +68,45 @@ var (
 	//ErrNotASymlink is returned by Object.SymlinkTarget() if the object in
 	//question exists, but is not a symlink.
 	ErrNotASymlink = errors.New("not a symlink")
+	//ErrSymlinkLoop is returned by Object.ResolveSymlinks() (and by
+	//Object.DownloadWithOptions() when DownloadOptions.ResolveSymlinks is set) if
+	//a chain of symlinks contains a cycle.
+	ErrSymlinkLoop = errors.New("symlink loop detected")
+	//ErrTooManySymlinks is returned by Object.ResolveSymlinks() (and by
+	//Object.DownloadWithOptions() when DownloadOptions.ResolveSymlinks is set) if
+	//a chain of symlinks is longer than the maximum depth.
+	ErrTooManySymlinks = errors.New("too many levels of symlinks")
+	//ErrObjectChanged is returned by the DownloadedObject returned by
+	//Object.DownloadWithOptions() when DownloadOptions.ResumableDownload is set,
+	//and the object was replaced on the server while the download was being
+	//resumed. The data read up to this point belongs to the old version of the
+	//object. It is also returned by Object.DownloadWithOptions() when
+	//DownloadOptions.VerifyChecksum is set, and a static large object was replaced
+	//while its manifest was being fetched for verification.
+	ErrObjectChanged = errors.New("object was changed on the server while resuming download")
+	//ErrRangeIgnored is returned by Object.DownloadWithOptions() when a range was
+	//requested through DownloadOptions, but the server responded with the entire
+	//object instead of a partial response (e.g. because a middleware does not
+	//support ranges).
+	//Use ObjectHeaders.AcceptsRanges() to check beforehand whether range
+	//requests are supported for an object.
+	ErrRangeIgnored = errors.New("server ignored the requested range and returned the entire object")
 )
 
 //UnexpectedStatusCodeError is generated when a request to Swift does not yield
 //a response with the expected successful status code. The actual status code
 //can be checked with the Is() function; see documentation over there.
+//
+//TransID contains the transaction ID that Swift reported for the failed
+//request in the X-Trans-Id response header (or the empty string if the
+//header was missing). Operators need this ID to find the request in the
+//Swift logs, so include it when reporting problems. Error() includes it in
+//the error message when present.
 type UnexpectedStatusCodeError struct {
 	ExpectedStatusCodes []int
 	ActualResponse      *http.Response
 	ResponseBody        []byte
+	TransID             string
 }
 
 //Error implements the builtin/error interface.
@@ -78,12 +119,61 @@ func (e UnexpectedStatusCodeError) Error() string {
 		strings.Join(codeStrs, "/"),
 		e.ActualResponse.StatusCode,
 	)
+	if e.TransID != "" {
+		msg += " (txn: " + e.TransID + ")"
+	}
 	if len(e.ResponseBody) > 0 {
 		msg += ": " + string(e.ResponseBody)
 	}
 	return msg
 }
 
+//ErrorResponse is the structured form of a JSON error body returned by Swift,
+//as obtained from UnexpectedStatusCodeError.ParseErrorResponse(). This shape
+//is used by the bulk middleware and by the SLO middleware (for manifest
+//uploads with "Accept: application/json").
+type ErrorResponse struct {
+	//ResponseStatus contains the overall HTTP status, e.g. "400 Bad Request".
+	ResponseStatus string
+	//ResponseBody contains an overall error message. It may be empty.
+	ResponseBody string
+	//Errors contains errors for individual objects or segments.
+	Errors []ErrorResponseItem
+}
+
+//ErrorResponseItem appears in type ErrorResponse.
+type ErrorResponseItem struct {
+	//Path of the object or segment, e.g. "/container/object".
+	Path string
+	//Message is either a HTTP status (e.g. "404 Not Found"), or an explanation
+	//like "Etag Mismatch" (for SLO segments).
+	Message string
+}
+
+//ParseErrorResponse attempts to parse the ResponseBody as a JSON error body
+//in one of the shapes known to Schwift (see type ErrorResponse). If the body
+//does not have a known shape, false is returned, and callers need to look at
+//the raw ResponseBody instead.
+func (e UnexpectedStatusCodeError) ParseErrorResponse() (ErrorResponse, bool) {
+	var data bulkResponse
+	err := json.Unmarshal(e.ResponseBody, &data)
+	if err != nil || (data.ResponseStatus == "" && data.ResponseBody == "" && len(data.Errors) == 0) {
+		return ErrorResponse{}, false
+	}
+
+	result := ErrorResponse{
+		ResponseStatus: data.ResponseStatus,
+		ResponseBody:   data.ResponseBody,
+	}
+	for _, item := range data.Errors {
+		if len(item) != 2 {
+			return ErrorResponse{}, false
+		}
+		result.Errors = append(result.Errors, ErrorResponseItem{Path: item[0], Message: item[1]})
+	}
+	return result, true
+}
+
 //BulkObjectError is the error message for a single object in a bulk operation.
 //It is not generated individually, only as part of BulkError.
 type BulkObjectError struct {
@@ -128,6 +218,25 @@ func (e BulkError) Error() string {
 	return result
 }
 
+//MoveError is returned by Object.MoveTo() when the object was copied to the
+//target successfully, but the source object could not be deleted afterwards.
+//When this error is returned, the source object (and, for large objects,
+//possibly some of its segments) still exists alongside the target object, so
+//no data has been lost.
+type MoveError struct {
+	Source *Object
+	Target *Object
+	//Inner contains the error returned by Object.Delete() on the source object.
+	Inner error
+}
+
+//Error implements the builtin/error interface.
+func (e MoveError) Error() string {
+	return fmt.Sprintf("%s was copied to %s, but the source still exists because deleting it failed: %s",
+		e.Source.FullName(), e.Target.FullName(), e.Inner.Error(),
+	)
+}
+
 //Is checks if the given error is an UnexpectedStatusCodeError for that status
 //code. For example:
 //
@@ -142,16 +251,140 @@ func (e BulkError) Error() string {
 //	    }
 //	}
 //
+//Errors that wrap an UnexpectedStatusCodeError (e.g. UploadLargeError) are
+//also recognized.
+//
 //It is safe to pass a nil error, in which case Is() always returns false.
 func Is(err error, code int) bool {
-	if e, ok := err.(UnexpectedStatusCodeError); ok {
+	var e UnexpectedStatusCodeError
+	if errors.As(err, &e) {
 		return e.ActualResponse.StatusCode == code
 	}
 	return false
 }
 
+//IsRateLimited checks if the given error is an UnexpectedStatusCodeError
+//indicating that the request was rejected by Swift's ratelimit middleware.
+//This is the case for status 498 (which Swift uses by default) and 429 (Too
+//Many Requests, which some deployments use instead). Use
+//UnexpectedStatusCodeError.RetryAfter() to find out how long the server wants
+//the client to back off.
+//
+//It is safe to pass a nil error, in which case IsRateLimited() always returns
+//false.
+func IsRateLimited(err error) bool {
+	var e UnexpectedStatusCodeError
+	if errors.As(err, &e) {
+		return isRateLimitedStatus(e.ActualResponse.StatusCode)
+	}
+	return false
+}
+
+//IsChecksumRejected checks if the given error is an UnexpectedStatusCodeError
+//with status 422 (Unprocessable Entity), which Object.Upload() returns when
+//Swift rejects the uploaded data because it does not match the Etag request
+//header (or a middleware rejects it because it does not match the
+//Content-MD5 request header, see UploadOptions.SendContentMD5). In this case,
+//the object was not stored. Like Is(), this also recognizes the Cause of an
+//UploadLargeError.
+//
+//It is safe to pass a nil error, in which case IsChecksumRejected() always
+//returns false.
+func IsChecksumRejected(err error) bool {
+	return Is(err, http.StatusUnprocessableEntity)
+}
+
+//IsContainerNotEmpty checks if the given error is a ContainerNotEmptyError,
+//which Container.Delete() returns when the container still contains objects.
+//In this case, Container.DeleteRecursively() can be used to delete the
+//objects along with the container:
+//
+//	err := container.Delete(nil)
+//	if schwift.IsContainerNotEmpty(err) {
+//	    err = container.DeleteRecursively(nil)
+//	}
+//
+//Other 409 responses (e.g. from Container.Create() when the container exists
+//with a different storage policy, or from Object.Delete()) are not matched.
+//
+//It is safe to pass a nil error, in which case IsContainerNotEmpty() always
+//returns false.
+func IsContainerNotEmpty(err error) bool {
+	var e ContainerNotEmptyError
+	return errors.As(err, &e)
+}
+
+func isRateLimitedStatus(code int) bool {
+	return code == statusRateLimited || code == http.StatusTooManyRequests
+}
+
+//isContextError checks if the given error was caused by a request context
+//being cancelled or expiring.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+//statusRateLimited is the non-standard status code returned by Swift's
+//ratelimit middleware.
+const statusRateLimited = 498
+
+//RetryAfter returns the delay that the server suggested in the Retry-After
+//header of the response. Both forms of the header (a number of seconds or an
+//HTTP date) are understood. If the header is missing or malformed, false is
+//returned.
+func (e UnexpectedStatusCodeError) RetryAfter() (time.Duration, bool) {
+	return parseRetryAfter(e.ActualResponse.Header, time.Now())
+}
+
+//UploadLargeError is returned by Object.UploadLarge() when the upload failed,
+//and some of the segments uploaded up to that point remain in the segment
+//container, either because UploadLargeOptions.KeepSegmentsOnError was set, or
+//because they could not be deleted. Since these segments are not referenced
+//by a manifest, the caller should delete them eventually.
+type UploadLargeError struct {
+	//Cause is the error that caused the upload to fail.
+	Cause error
+	//Segments lists the segment objects that may remain in the segment
+	//container, in order.
+	Segments []*Object
+}
+
+//Error implements the builtin/error interface.
+func (e UploadLargeError) Error() string {
+	return fmt.Sprintf("%s (%d segments left behind)", e.Cause.Error(), len(e.Segments))
+}
+
+//Unwrap returns the Cause of this error, for use with errors.Is() and
+//errors.As().
+func (e UploadLargeError) Unwrap() error {
+	return e.Cause
+}
+
+//ContainerNotEmptyError is returned by Container.Delete() when Swift refuses
+//to delete the container because it still contains objects (i.e. when it
+//responds with status 409). Since it wraps the original
+//UnexpectedStatusCodeError, Is(err, http.StatusConflict) matches it as well.
+type ContainerNotEmptyError struct {
+	Container *Container
+	//Inner contains the error returned by the DELETE request.
+	Inner UnexpectedStatusCodeError
+}
+
+//Error implements the builtin/error interface.
+func (e ContainerNotEmptyError) Error() string {
+	return fmt.Sprintf("cannot delete container %s because it is not empty: %s",
+		e.Container.Name(), e.Inner.Error(),
+	)
+}
+
+//Unwrap returns the Inner error, for use with errors.Is() and errors.As().
+func (e ContainerNotEmptyError) Unwrap() error {
+	return e.Inner
+}
+
 //MalformedHeaderError is generated when a response from Swift contains a
-//malformed header.
+//malformed header, or by Request.Do() when a request header (e.g. a metadata
+//key) has a name that cannot be sent to Swift.
 type MalformedHeaderError struct {
 	Key        string
 	ParseError error
@@ -0,0 +1,117 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUnexpectedStatusCodeErrorMessage(t *testing.T) {
+	testCases := []struct {
+		err      UnexpectedStatusCodeError
+		expected string
+	}{
+		{
+			UnexpectedStatusCodeError{
+				ExpectedStatusCodes: []int{200, 204},
+				ActualResponse:      &http.Response{StatusCode: 404},
+			},
+			"expected 200/204 response, got 404 instead",
+		},
+		{
+			UnexpectedStatusCodeError{
+				ExpectedStatusCodes: []int{204},
+				ActualResponse:      &http.Response{StatusCode: 500},
+				ResponseBody:        []byte("Internal Server Error"),
+				TransID:             "tx1234abcd",
+			},
+			"expected 204 response, got 500 instead (txn: tx1234abcd): Internal Server Error",
+		},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.err.Error(); actual != tc.expected {
+			t.Errorf("expected error message %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestParseErrorResponse(t *testing.T) {
+	makeError := func(body string) UnexpectedStatusCodeError {
+		return UnexpectedStatusCodeError{
+			ExpectedStatusCodes: []int{201},
+			ActualResponse:      &http.Response{StatusCode: 400},
+			ResponseBody:        []byte(body),
+		}
+	}
+
+	//SLO manifest upload with invalid segments
+	result, ok := makeError(`{"Response Status": "400 Bad Request", "Response Body": "", "Errors": [["/c/seg1", "404 Not Found"], ["/c/seg2", "Etag Mismatch"]]}`).ParseErrorResponse()
+	expected := ErrorResponse{
+		ResponseStatus: "400 Bad Request",
+		Errors: []ErrorResponseItem{
+			{Path: "/c/seg1", Message: "404 Not Found"},
+			{Path: "/c/seg2", Message: "Etag Mismatch"},
+		},
+	}
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %#v, got %#v (ok = %t)", expected, result, ok)
+	}
+
+	//unknown shapes
+	for _, body := range []string{"", "Unauthorized", "{}", `{"error": "foo"}`, `{"Errors": [["too", "many", "fields"]]}`} {
+		_, ok := makeError(body).ParseErrorResponse()
+		if ok {
+			t.Errorf("expected ParseErrorResponse() to fail for body %q", body)
+		}
+	}
+}
+
+func TestUploadLargeErrorUnwrap(t *testing.T) {
+	cause := UnexpectedStatusCodeError{
+		ExpectedStatusCodes: []int{201},
+		ActualResponse:      &http.Response{StatusCode: http.StatusUnprocessableEntity},
+	}
+	var err error = UploadLargeError{Cause: cause, Segments: []*Object{nil, nil}}
+
+	var unwrapped UnexpectedStatusCodeError
+	if !errors.As(err, &unwrapped) {
+		t.Errorf("expected errors.As() to find the UnexpectedStatusCodeError in %#v", err)
+	}
+	if !Is(err, http.StatusUnprocessableEntity) {
+		t.Error("expected Is(err, 422) to be true for wrapped 422 error")
+	}
+	if Is(err, http.StatusNotFound) {
+		t.Error("expected Is(err, 404) to be false for wrapped 422 error")
+	}
+	if !IsChecksumRejected(err) {
+		t.Error("expected IsChecksumRejected(err) to be true for wrapped 422 error")
+	}
+
+	err = UploadLargeError{Cause: ErrChecksumMismatch}
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Error("expected errors.Is() to find ErrChecksumMismatch in UploadLargeError")
+	}
+	if IsChecksumRejected(err) {
+		t.Error("expected IsChecksumRejected(err) to be false for wrapped non-HTTP error")
+	}
+}
@@ -18,6 +18,13 @@
 
 package schwift
 
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
 //FieldMetadata is a helper type that provides safe access to the metadata headers
 //in a headers instance. It cannot be directly constructed, but each headers
 //type has a method "Metadata" returning this type. For example:
@@ -26,6 +33,12 @@ package schwift
 //	//the following two statements are equivalent
 //	hdr["X-Object-Meta-Access"] = "strictly confidential"
 //	hdr.Metadata().Set("Access", "strictly confidential")
+//
+//Like header names, metadata keys are case-insensitive: Set("Foo", ...) and
+//Get("foo") refer to the same key. Keys may only contain ASCII letters, digits
+//and the punctuation characters allowed in HTTP header names (most notably no
+//spaces, colons or non-ASCII characters). Requests with invalid keys fail
+//with MalformedHeaderError before they are sent.
 type FieldMetadata struct {
 	h Headers
 	k string
@@ -46,11 +59,86 @@ func (m FieldMetadata) Get(key string) string {
 	return m.h.Get(m.k + key)
 }
 
+//Remove marks the given key for removal on the server. It deletes the key
+//from this headers instance (so Get() returns the empty string afterwards),
+//and adds an "X-Remove-<Type>-Meta-<key>" header instead, which instructs
+//Swift to delete the key when the headers instance is sent with Update().
+//Other metadata keys on the server remain unchanged. (For objects, see also
+//MetadataMode in UpdateOptions.) A later Set() for the same key cancels the
+//removal.
+func (m FieldMetadata) Remove(key string) {
+	m.h.Del(m.k + key)
+	m.h.Set(m.removePrefix()+key, "1")
+}
+
 //Set works like Headers.Set(), but prepends the metadata prefix to the key.
 func (m FieldMetadata) Set(key, value string) {
+	m.h.Del(m.removePrefix() + key)
 	m.h.Set(m.k+key, value)
 }
 
+//GetInt parses the value for the given key as a decimal integer, as written
+//by SetInt(). If the key does not exist, 0 is returned. If the value is not
+//a valid integer, MalformedHeaderError is returned.
+func (m FieldMetadata) GetInt(key string) (int64, error) {
+	str := m.Get(key)
+	if str == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, MalformedHeaderError{m.k + key, err}
+	}
+	return value, nil
+}
+
+//SetInt works like Set(), but formats the given integer as a decimal string.
+func (m FieldMetadata) SetInt(key string, value int64) {
+	m.Set(key, strconv.FormatInt(value, 10))
+}
+
+//GetTime parses the value for the given key as a RFC 3339 timestamp, as
+//written by SetTime(). If the key does not exist, the zero time is returned.
+//If the value is not a valid timestamp, MalformedHeaderError is returned.
+func (m FieldMetadata) GetTime(key string) (time.Time, error) {
+	str := m.Get(key)
+	if str == "" {
+		return time.Time{}, nil
+	}
+	value, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return time.Time{}, MalformedHeaderError{m.k + key, err}
+	}
+	return value, nil
+}
+
+//SetTime works like Set(), but formats the given timestamp in UTC according
+//to RFC 3339, e.g. "2006-01-02T15:04:05.999999999Z". Fractional seconds are
+//only included when non-zero.
+func (m FieldMetadata) SetTime(key string, value time.Time) {
+	m.Set(key, value.UTC().Format(time.RFC3339Nano))
+}
+
+//removePrefix returns the header prefix for metadata removal, e.g.
+//"X-Remove-Object-Meta-" for "X-Object-Meta-".
+func (m FieldMetadata) removePrefix() string {
+	return "X-Remove-" + strings.TrimPrefix(m.k, "X-")
+}
+
+//Keys returns the keys of all metadata in this headers instance (without the
+//metadata prefix) in sorted order. Since keys are case-insensitive, they are
+//reported in their canonical form, e.g. "Foo-Bar" for "X-Object-Meta-foo-bar".
+func (m FieldMetadata) Keys() []string {
+	var result []string
+	for k := range m.h {
+		if strings.HasPrefix(k, m.k) && len(k) > len(m.k) {
+			result = append(result, strings.TrimPrefix(k, m.k))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 func (m FieldMetadata) validate() error {
 	return nil
 }
@@ -0,0 +1,71 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "strings"
+
+//FieldStringList is a helper type that provides type-safe access to a Swift
+//header whose value is a space-separated list of strings. It cannot be
+//directly constructed, but methods on the Headers types return this type. For
+//example:
+//
+//	hdr := NewContainerHeaders()
+//	//the following two statements are equivalent:
+//	hdr["X-Container-Meta-Access-Control-Allow-Origin"] = "https://example.com https://example.org"
+//	hdr.CORSAllowOrigin().Set([]string{"https://example.com", "https://example.org"})
+type FieldStringList struct {
+	h Headers
+	k string
+}
+
+//Exists checks whether there is a value for this header.
+func (f FieldStringList) Exists() bool {
+	return f.h.Get(f.k) != ""
+}
+
+//Get returns the list of values for this header, or nil if there is no value.
+func (f FieldStringList) Get() []string {
+	fields := strings.Fields(f.h.Get(f.k))
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+//Set writes a new value for this header into the corresponding headers
+//instance. The values themselves may not contain spaces.
+func (f FieldStringList) Set(values []string) {
+	f.h.Set(f.k, strings.Join(values, " "))
+}
+
+//Del removes this key from the original headers instance, so that the
+//key will remain unchanged on the server during Update().
+func (f FieldStringList) Del() {
+	f.h.Del(f.k)
+}
+
+//Clear sets this key to an empty string in the original headers
+//instance, so that the key will be removed on the server during Update().
+func (f FieldStringList) Clear() {
+	f.h.Clear(f.k)
+}
+
+func (f FieldStringList) validate() error {
+	return nil
+}
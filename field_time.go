@@ -19,8 +19,10 @@
 package schwift
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -92,6 +94,9 @@ func (f FieldHTTPTimeReadonly) validate() error {
 //	//or you can just:
 //	time := hdr.ExpiresAt().Get()
 //
+//Fractional seconds (as in X-Timestamp) are supported. Unlike in the first
+//example above, they are parsed without loss of precision.
+//
 //Don't worry about the missing `err` in the last line. When the header fails
 //to parse, Object.Headers() already returns the corresponding
 //MalformedHeaderError.
@@ -108,11 +113,11 @@ func (f FieldUnixTime) Exists() bool {
 //Get returns the value for this header, or the zero value if there is no value
 //(or if it is not a valid timestamp).
 func (f FieldUnixTime) Get() time.Time {
-	v, err := strconv.ParseFloat(f.h.Get(f.k), 64)
+	t, err := parseUnixTime(f.h.Get(f.k))
 	if err != nil {
 		return time.Time{}
 	}
-	return time.Unix(0, int64(1e9*v))
+	return t
 }
 
 //Set writes a new value for this header into the corresponding headers
@@ -138,13 +143,37 @@ func (f FieldUnixTime) validate() error {
 	if val == "" {
 		return nil
 	}
-	_, err := strconv.ParseFloat(val, 64)
+	_, err := parseUnixTime(val)
 	if err == nil {
 		return nil
 	}
 	return MalformedHeaderError{f.k, err}
 }
 
+func parseUnixTime(str string) (time.Time, error) {
+	//"_" is Swift's marker for the offset suffix of internal timestamps (as in
+	//archived object names). It does not belong in headers, and must be
+	//rejected explicitly since parseSwiftTimestamp() below would silently drop
+	//the offset.
+	if strings.Contains(str, "_") {
+		return time.Time{}, errors.New("unexpected offset in Unix timestamp")
+	}
+	//Swift's own format (e.g. "1500000000.12345") can be parsed without loss of
+	//precision (but parseSwiftTimestamp() does not handle negative values)
+	if !strings.HasPrefix(str, "-") {
+		t, err := parseSwiftTimestamp(str)
+		if err == nil {
+			return t, nil
+		}
+	}
+	//otherwise fall back to the more lenient float parsing
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(1e9*v)), nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 //FieldUnixTimeReadonly is a readonly variant of FieldUnixTime. It is used for
@@ -168,3 +197,75 @@ func (f FieldUnixTimeReadonly) Get() time.Time {
 func (f FieldUnixTimeReadonly) validate() error {
 	return FieldUnixTime{f.h, f.k}.validate()
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+//FieldDuration is a helper type that provides type-safe access to a Swift
+//header whose value is a duration in seconds, like X-Delete-After. It cannot
+//be directly constructed, but methods on the Headers types return this type.
+//For example:
+//
+//	hdr := NewObjectHeaders()
+//	//the following two statements are equivalent:
+//	hdr["X-Delete-After"] = "604800"
+//	hdr.DeleteAfter().Set(7 * 24 * time.Hour)
+//
+//Note that Swift converts X-Delete-After into X-Delete-At when storing the
+//object, so headers read back from the server will not contain DeleteAfter(),
+//but ExpiresAt() instead.
+type FieldDuration struct {
+	h Headers
+	k string
+}
+
+//Exists checks whether there is a value for this header.
+func (f FieldDuration) Exists() bool {
+	return f.h.Get(f.k) != ""
+}
+
+//Get returns the value for this header, or the zero value if there is no value
+//(or if it is not a valid duration).
+func (f FieldDuration) Get() time.Duration {
+	v, err := strconv.ParseUint(f.h.Get(f.k), 10, 63)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(v) * time.Second
+}
+
+//Set writes a new value for this header into the corresponding headers
+//instance. Since Swift only accepts whole seconds, durations with a
+//sub-second part are rounded up to the next second. Negative durations are
+//ignored, i.e. the header is left unchanged. (Writing them as zero would
+//cause e.g. an X-Delete-After to delete the object immediately.)
+func (f FieldDuration) Set(value time.Duration) {
+	if value < 0 {
+		return
+	}
+	seconds := (value + time.Second - 1) / time.Second
+	f.h.Set(f.k, strconv.FormatUint(uint64(seconds), 10))
+}
+
+//Del removes this key from the original headers instance, so that the key will
+//remain unchanged on the server during Update().
+func (f FieldDuration) Del() {
+	f.h.Del(f.k)
+}
+
+//Clear sets this key to an empty string in the original headers instance, so
+//that the key will be removed on the server during Update().
+func (f FieldDuration) Clear() {
+	f.h.Clear(f.k)
+}
+
+func (f FieldDuration) validate() error {
+	val := f.h.Get(f.k)
+	if val == "" {
+		return nil
+	}
+	_, err := strconv.ParseUint(val, 10, 63)
+	if err == nil {
+		return nil
+	}
+	return MalformedHeaderError{f.k, err}
+}
@@ -30,6 +30,16 @@ import (
 //	//the following two statements are equivalent:
 //	hdr["X-Account-Meta-Quota-Bytes"] = "1048576"
 //	hdr.BytesUsedQuota().Set(1 << 20)
+//
+//Since Get() returns 0 for missing headers, use Exists() to distinguish an
+//unset value from an explicit zero, e.g. to check whether a quota is set:
+//
+//	hdr, err := container.Headers()
+//	if hdr.BytesUsedQuota().Exists() {
+//	    log.Printf("quota is %d bytes", hdr.BytesUsedQuota().Get())
+//	} else {
+//	    log.Print("no quota set")
+//	}
 type FieldUint64 struct {
 	h Headers
 	k string
@@ -28,6 +28,9 @@ func NewAccountHeaders() AccountHeaders {
 //usually do not need to do it yourself. You will get the validation error from
 //the Account method doing the request, e.g. Headers().
 func (h AccountHeaders) Validate() error {
+	if err := h.UpdatedAt().validate(); err != nil {
+		return err
+	}
 	if err := h.BytesUsed().validate(); err != nil {
 		return err
 	}
@@ -55,6 +58,25 @@ func (h AccountHeaders) Validate() error {
 	return evadeGolintComplaint1()
 }
 
+//wellKnownAccountHeaders lists the headers that AccountHeaders has
+//methods for. Entries ending in "-" are prefixes.
+var wellKnownAccountHeaders = []string{
+	"Last-Modified",
+	"X-Account-Bytes-Used",
+	"X-Account-Container-Count",
+	"X-Account-Meta-",
+	"X-Account-Meta-Quota-Bytes",
+	"X-Account-Meta-Temp-URL-Key-2",
+	"X-Account-Meta-Temp-URL-Key",
+	"X-Account-Object-Count",
+	"X-Timestamp",
+}
+
+//UpdatedAt provides type-safe access to Last-Modified headers.
+func (h AccountHeaders) UpdatedAt() FieldHTTPTimeReadonly {
+	return FieldHTTPTimeReadonly{h.Headers, "Last-Modified"}
+}
+
 //BytesUsed provides type-safe access to X-Account-Bytes-Used headers.
 func (h AccountHeaders) BytesUsed() FieldUint64Readonly {
 	return FieldUint64Readonly{h.Headers, "X-Account-Bytes-Used"}
@@ -115,9 +137,21 @@ func NewContainerHeaders() ContainerHeaders {
 //usually do not need to do it yourself. You will get the validation error from
 //the Container method doing the request, e.g. Headers().
 func (h ContainerHeaders) Validate() error {
+	if err := h.UpdatedAt().validate(); err != nil {
+		return err
+	}
 	if err := h.BytesUsed().validate(); err != nil {
 		return err
 	}
+	if err := h.CORSAllowOrigin().validate(); err != nil {
+		return err
+	}
+	if err := h.CORSExposeHeaders().validate(); err != nil {
+		return err
+	}
+	if err := h.CORSMaxAge().validate(); err != nil {
+		return err
+	}
 	if err := h.Metadata().validate(); err != nil {
 		return err
 	}
@@ -133,6 +167,18 @@ func (h ContainerHeaders) Validate() error {
 	if err := h.TempURLKey().validate(); err != nil {
 		return err
 	}
+	if err := h.WebError().validate(); err != nil {
+		return err
+	}
+	if err := h.WebIndex().validate(); err != nil {
+		return err
+	}
+	if err := h.WebListingsCSS().validate(); err != nil {
+		return err
+	}
+	if err := h.WebListings().validate(); err != nil {
+		return err
+	}
 	if err := h.ObjectCount().validate(); err != nil {
 		return err
 	}
@@ -163,11 +209,59 @@ func (h ContainerHeaders) Validate() error {
 	return evadeGolintComplaint1()
 }
 
+//wellKnownContainerHeaders lists the headers that ContainerHeaders has
+//methods for. Entries ending in "-" are prefixes.
+var wellKnownContainerHeaders = []string{
+	"Last-Modified",
+	"X-Container-Bytes-Used",
+	"X-Container-Meta-Access-Control-Allow-Origin",
+	"X-Container-Meta-Access-Control-Expose-Headers",
+	"X-Container-Meta-Access-Control-Max-Age",
+	"X-Container-Meta-",
+	"X-Container-Meta-Quota-Bytes",
+	"X-Container-Meta-Quota-Count",
+	"X-Container-Meta-Temp-URL-Key-2",
+	"X-Container-Meta-Temp-URL-Key",
+	"X-Container-Meta-Web-Error",
+	"X-Container-Meta-Web-Index",
+	"X-Container-Meta-Web-Listings-CSS",
+	"X-Container-Meta-Web-Listings",
+	"X-Container-Object-Count",
+	"X-Container-Read",
+	"X-Container-Sync-Key",
+	"X-Container-Sync-To",
+	"X-Container-Write",
+	"X-History-Location",
+	"X-Storage-Policy",
+	"X-Timestamp",
+	"X-Versions-Location",
+}
+
+//UpdatedAt provides type-safe access to Last-Modified headers.
+func (h ContainerHeaders) UpdatedAt() FieldHTTPTimeReadonly {
+	return FieldHTTPTimeReadonly{h.Headers, "Last-Modified"}
+}
+
 //BytesUsed provides type-safe access to X-Container-Bytes-Used headers.
 func (h ContainerHeaders) BytesUsed() FieldUint64Readonly {
 	return FieldUint64Readonly{h.Headers, "X-Container-Bytes-Used"}
 }
 
+//CORSAllowOrigin provides type-safe access to X-Container-Meta-Access-Control-Allow-Origin headers.
+func (h ContainerHeaders) CORSAllowOrigin() FieldStringList {
+	return FieldStringList{h.Headers, "X-Container-Meta-Access-Control-Allow-Origin"}
+}
+
+//CORSExposeHeaders provides type-safe access to X-Container-Meta-Access-Control-Expose-Headers headers.
+func (h ContainerHeaders) CORSExposeHeaders() FieldStringList {
+	return FieldStringList{h.Headers, "X-Container-Meta-Access-Control-Expose-Headers"}
+}
+
+//CORSMaxAge provides type-safe access to X-Container-Meta-Access-Control-Max-Age headers.
+func (h ContainerHeaders) CORSMaxAge() FieldDuration {
+	return FieldDuration{h.Headers, "X-Container-Meta-Access-Control-Max-Age"}
+}
+
 //Metadata provides type-safe access to X-Container-Meta- headers.
 func (h ContainerHeaders) Metadata() FieldMetadata {
 	return FieldMetadata{h.Headers, "X-Container-Meta-"}
@@ -193,6 +287,26 @@ func (h ContainerHeaders) TempURLKey() FieldString {
 	return FieldString{h.Headers, "X-Container-Meta-Temp-URL-Key"}
 }
 
+//WebError provides type-safe access to X-Container-Meta-Web-Error headers.
+func (h ContainerHeaders) WebError() FieldString {
+	return FieldString{h.Headers, "X-Container-Meta-Web-Error"}
+}
+
+//WebIndex provides type-safe access to X-Container-Meta-Web-Index headers.
+func (h ContainerHeaders) WebIndex() FieldString {
+	return FieldString{h.Headers, "X-Container-Meta-Web-Index"}
+}
+
+//WebListingsCSS provides type-safe access to X-Container-Meta-Web-Listings-CSS headers.
+func (h ContainerHeaders) WebListingsCSS() FieldString {
+	return FieldString{h.Headers, "X-Container-Meta-Web-Listings-CSS"}
+}
+
+//WebListings provides type-safe access to X-Container-Meta-Web-Listings headers.
+func (h ContainerHeaders) WebListings() FieldBool {
+	return FieldBool{h.Headers, "X-Container-Meta-Web-Listings"}
+}
+
 //ObjectCount provides type-safe access to X-Container-Object-Count headers.
 func (h ContainerHeaders) ObjectCount() FieldUint64Readonly {
 	return FieldUint64Readonly{h.Headers, "X-Container-Object-Count"}
@@ -276,9 +390,15 @@ func (h ObjectHeaders) Validate() error {
 	if err := h.UpdatedAt().validate(); err != nil {
 		return err
 	}
+	if err := h.DeleteAfter().validate(); err != nil {
+		return err
+	}
 	if err := h.ExpiresAt().validate(); err != nil {
 		return err
 	}
+	if err := h.ObjectManifest().validate(); err != nil {
+		return err
+	}
 	if err := h.Metadata().validate(); err != nil {
 		return err
 	}
@@ -294,6 +414,24 @@ func (h ObjectHeaders) Validate() error {
 	return evadeGolintComplaint1()
 }
 
+//wellKnownObjectHeaders lists the headers that ObjectHeaders has
+//methods for. Entries ending in "-" are prefixes.
+var wellKnownObjectHeaders = []string{
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Length",
+	"Content-Type",
+	"Etag",
+	"Last-Modified",
+	"X-Delete-After",
+	"X-Delete-At",
+	"X-Object-Manifest",
+	"X-Object-Meta-",
+	"X-Symlink-Target-Account",
+	"X-Symlink-Target",
+	"X-Timestamp",
+}
+
 //ContentDisposition provides type-safe access to Content-Disposition headers.
 func (h ObjectHeaders) ContentDisposition() FieldString {
 	return FieldString{h.Headers, "Content-Disposition"}
@@ -324,11 +462,21 @@ func (h ObjectHeaders) UpdatedAt() FieldHTTPTimeReadonly {
 	return FieldHTTPTimeReadonly{h.Headers, "Last-Modified"}
 }
 
+//DeleteAfter provides type-safe access to X-Delete-After headers.
+func (h ObjectHeaders) DeleteAfter() FieldDuration {
+	return FieldDuration{h.Headers, "X-Delete-After"}
+}
+
 //ExpiresAt provides type-safe access to X-Delete-At headers.
 func (h ObjectHeaders) ExpiresAt() FieldUnixTime {
 	return FieldUnixTime{h.Headers, "X-Delete-At"}
 }
 
+//ObjectManifest provides type-safe access to X-Object-Manifest headers.
+func (h ObjectHeaders) ObjectManifest() FieldString {
+	return FieldString{h.Headers, "X-Object-Manifest"}
+}
+
 //Metadata provides type-safe access to X-Object-Meta- headers.
 func (h ObjectHeaders) Metadata() FieldMetadata {
 	return FieldMetadata{h.Headers, "X-Object-Meta-"}
@@ -55,6 +55,33 @@ type Options struct {
 	//If set, this User-Agent will be reported in HTTP requests instead of
 	//schwift.DefaultUserAgent.
 	UserAgent string
+	//If set, requests to Swift are executed with this HTTP client instead of
+	//the provider client's HTTPClient. This can be used to configure timeouts,
+	//TLS settings or proxies for Swift only. (Requests to Keystone for
+	//reauthentication still use the provider client's HTTPClient.)
+	//
+	//Note that http.Client.Timeout covers the entire exchange including
+	//reading the response body, so it will abort long-running downloads and
+	//uploads of large objects. To bound only the time until the server starts
+	//responding, use the timeouts on the http.Transport instead (e.g.
+	//ResponseHeaderTimeout and TLSHandshakeTimeout), or per-request deadlines
+	//via the Context field of schwift.RequestOptions.
+	HTTPClient *http.Client
+	//If set, these headers are added to each request to Swift, unless the
+	//request already carries the respective header (e.g. from
+	//schwift.RequestOptions.Headers). The auth token and User-Agent headers
+	//always take precedence.
+	ExtraHeaders http.Header
+	//If set, failed requests will be retried according to this policy. See
+	//documentation on type schwift.RetryPolicy for details.
+	RetryPolicy *schwift.RetryPolicy
+	//If set, this callback will be invoked after each HTTP request (including
+	//each retry attempt). See documentation on type schwift.RequestObserver
+	//for details.
+	RequestObserver schwift.RequestObserver
+	//If set, all requests and responses (including each retry attempt) will be
+	//logged. See documentation on type schwift.DebugLogger for details.
+	DebugLogger *schwift.DebugLogger
 }
 
 //Wrap creates a schwift.Account that uses the given service client as its
@@ -65,15 +92,33 @@ func Wrap(client *gophercloud.ServiceClient, opts *Options) (*schwift.Account, e
 		c:         client,
 		userAgent: schwift.DefaultUserAgent,
 	}
-	if opts != nil && opts.UserAgent != "" {
+	if opts == nil {
+		return schwift.InitializeAccount(b)
+	}
+	if opts.UserAgent != "" {
 		b.userAgent = opts.UserAgent
 	}
-	return schwift.InitializeAccount(b)
+	b.httpClient = opts.HTTPClient
+	b.extraHeaders = opts.ExtraHeaders
+
+	var result schwift.Backend = b
+	if opts.DebugLogger != nil {
+		result = opts.DebugLogger.Wrap(result)
+	}
+	if opts.RequestObserver != nil {
+		result = opts.RequestObserver.Wrap(result)
+	}
+	if opts.RetryPolicy != nil {
+		result = opts.RetryPolicy.Wrap(result)
+	}
+	return schwift.InitializeAccount(result)
 }
 
 type backend struct {
-	c         *gophercloud.ServiceClient
-	userAgent string
+	c            *gophercloud.ServiceClient
+	userAgent    string
+	httpClient   *http.Client //if nil, use c.ProviderClient.HTTPClient
+	extraHeaders http.Header
 }
 
 func (g *backend) EndpointURL() string {
@@ -84,8 +129,10 @@ func (g *backend) Clone(newEndpointURL string) schwift.Backend {
 	clonedClient := *g.c
 	clonedClient.Endpoint = newEndpointURL
 	return &backend{
-		c:         &clonedClient,
-		userAgent: g.userAgent,
+		c:            &clonedClient,
+		userAgent:    g.userAgent,
+		httpClient:   g.httpClient,
+		extraHeaders: g.extraHeaders,
 	}
 }
 
@@ -96,18 +143,29 @@ func (g *backend) Do(req *http.Request) (*http.Response, error) {
 func (g *backend) do(req *http.Request, afterReauth bool) (*http.Response, error) {
 	provider := g.c.ProviderClient
 
+	for key, values := range g.extraHeaders {
+		if req.Header.Get(key) == "" {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
 	for key, value := range provider.AuthenticatedHeaders() {
 		req.Header.Set(key, value)
 	}
 	req.Header.Set("User-Agent", g.userAgent)
 
-	resp, err := provider.HTTPClient.Do(req)
+	httpClient := g.httpClient
+	if httpClient == nil {
+		httpClient = &provider.HTTPClient
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	//detect expired token
-	if resp.StatusCode == http.StatusUnauthorized && !afterReauth {
+	//detect expired token (but only restart the request if its body can be
+	//re-read from the start)
+	canResend := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if resp.StatusCode == http.StatusUnauthorized && !afterReauth && canResend {
 		_, err := io.Copy(ioutil.Discard, resp.Body)
 		if err != nil {
 			return nil, err
@@ -121,6 +179,14 @@ func (g *backend) do(req *http.Request, afterReauth bool) (*http.Response, error
 			return nil, err
 		}
 		//restart request with new token
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
 		return g.do(req, true)
 	}
 
@@ -19,8 +19,12 @@
 package schwift
 
 import (
+	"errors"
+	"mime"
 	"net/http"
 	"net/textproto"
+	"strings"
+	"time"
 )
 
 //Headers represents a set of request headers or response headers.
@@ -54,7 +58,8 @@ func (h Headers) Clear(key string) {
 //
 //For object metadata (but not other object attributes), deleting a key will
 //cause that key to be deleted on the server. Del() is identical to Clear() in
-//this case.
+//this case, unless Object.UpdateWithOptions() is called with
+//MetadataMode = MetadataMerge.
 func (h Headers) Del(key string) {
 	delete(h, textproto.CanonicalMIMEHeaderKey(key))
 }
@@ -70,6 +75,36 @@ func (h Headers) Set(key, value string) {
 	h[textproto.CanonicalMIMEHeaderKey(key)] = value
 }
 
+//TransID returns the transaction ID that Swift reported in the X-Trans-Id
+//response header, or the empty string if the header is not present. Operators
+//need this ID to find a request in the Swift logs.
+//
+//For headers returned by Account.Headers(), Container.Headers() or
+//Object.Headers(), this is the transaction ID of the request that filled the
+//header cache, usually a HEAD request.
+func (h Headers) TransID() string {
+	return h.Get("X-Trans-Id")
+}
+
+var errInvalidHeaderName = errors.New("header name contains characters other than ASCII letters, digits and !#$%&'*+-.^_`|~")
+
+//validateHeaderName checks that the given header name is a valid token as
+//defined in RFC 7230, section 3.2.6.
+func validateHeaderName(key string) error {
+	if key == "" {
+		return errors.New("header name is empty")
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return errInvalidHeaderName
+		}
+	}
+	return nil
+}
+
 //ToHTTP converts this Headers instance into the equivalent http.Header
 //instance. The return value is guaranteed to be non-nil.
 func (h Headers) ToHTTP() http.Header {
@@ -105,10 +140,122 @@ func headersFromHTTP(src http.Header) Headers {
 ////////////////////////////////////////////////////////////////////////////////
 // specialized accessors on Headers subtypes that are not autogenerated
 
+//Custom provides access to a header that AccountHeaders does not model, e.g.
+//a header that is only understood by a middleware in a particular Swift
+//deployment. See ObjectHeaders.Custom() for details.
+func (h AccountHeaders) Custom(key string) (FieldString, error) {
+	return customField(h.Headers, key, wellKnownAccountHeaders)
+}
+
+//Custom provides access to a header that ContainerHeaders does not model, e.g.
+//a header that is only understood by a middleware in a particular Swift
+//deployment. See ObjectHeaders.Custom() for details.
+func (h ContainerHeaders) Custom(key string) (FieldString, error) {
+	return customField(h.Headers, key, wellKnownContainerHeaders)
+}
+
+//Custom provides access to a header that ObjectHeaders does not model, e.g.
+//a header that influences write affinity or data placement in a multi-region
+//cluster, and is only understood by a middleware in a particular deployment.
+//For example:
+//
+//	hdr := schwift.NewObjectHeaders()
+//	placement, err := hdr.Custom("X-Example-Placement")
+//	if err != nil {
+//	    return err
+//	}
+//	placement.Set("region2")
+//	err = obj.Upload(content, nil, hdr.ToOpts())
+//
+//To avoid clobbering headers that schwift manages, this method returns a
+//MalformedHeaderError if the key is not a valid header name, if it refers to
+//a header that ObjectHeaders has a method for (e.g. "Content-Type"), or if it
+//refers to metadata (use Metadata() instead).
+func (h ObjectHeaders) Custom(key string) (FieldString, error) {
+	return customField(h.Headers, key, wellKnownObjectHeaders)
+}
+
+//WithReadACL sets the X-Container-Read header, and returns the same
+//ContainerHeaders instance. Together with the other With...() methods, this
+//allows to build a set of container headers in a single expression, e.g. for
+//setting up a static website in a single Container.Create() call:
+//
+//	hdr := schwift.NewContainerHeaders().
+//		WithReadACL(schwift.ACLPublicRead).
+//		WithStaticWebsite("index.html", true).
+//		WithCORS([]string{"https://example.com"}, time.Hour).
+//		WithMetadata("owner", "web-team")
+//	hdr.BytesUsedQuota().Set(1 << 30)
+//	err := container.Create(hdr.ToOpts())
+//
+//Since ContainerHeaders is a map type, the returned instance is not a copy.
+func (h ContainerHeaders) WithReadACL(acl ACL) ContainerHeaders {
+	h.ReadACL().Set(acl.String())
+	return h
+}
+
+//WithWriteACL sets the X-Container-Write header, and returns the same
+//ContainerHeaders instance. See WithReadACL() for details.
+func (h ContainerHeaders) WithWriteACL(acl ACL) ContainerHeaders {
+	h.WriteACL().Set(acl.String())
+	return h
+}
+
+//WithStaticWebsite sets the headers that configure the staticweb middleware,
+//and returns the same ContainerHeaders instance. If index is empty, the
+//X-Container-Meta-Web-Index header is not set. See WithReadACL() for details.
+//Note that the staticweb middleware only serves containers that are publicly
+//readable, so this is usually combined with WithReadACL(ACLPublicRead).
+func (h ContainerHeaders) WithStaticWebsite(index string, listings bool) ContainerHeaders {
+	if index != "" {
+		h.WebIndex().Set(index)
+	}
+	h.WebListings().Set(listings)
+	return h
+}
+
+//WithCORS sets the headers for cross-origin resource sharing, and returns the
+//same ContainerHeaders instance. If maxAge is zero, the
+//X-Container-Meta-Access-Control-Max-Age header is not set. See WithReadACL()
+//for details.
+func (h ContainerHeaders) WithCORS(allowOrigins []string, maxAge time.Duration) ContainerHeaders {
+	h.CORSAllowOrigin().Set(allowOrigins)
+	if maxAge != 0 {
+		h.CORSMaxAge().Set(maxAge)
+	}
+	return h
+}
+
+//WithMetadata sets the given metadata key, and returns the same
+//ContainerHeaders instance. See WithReadACL() for details.
+func (h ContainerHeaders) WithMetadata(key, value string) ContainerHeaders {
+	h.Metadata().Set(key, value)
+	return h
+}
+
+//customField implements the Custom() methods on the Headers subtypes.
+func customField(h Headers, key string, wellKnown []string) (FieldString, error) {
+	if err := validateHeaderName(key); err != nil {
+		return FieldString{}, MalformedHeaderError{key, err}
+	}
+	lowerKey := strings.ToLower(key)
+	if strings.HasPrefix(lowerKey, "x-remove-") {
+		return FieldString{}, MalformedHeaderError{key, errors.New("use Clear() to remove headers")}
+	}
+	for _, wk := range wellKnown {
+		wk = strings.ToLower(wk)
+		if lowerKey == wk || (strings.HasSuffix(wk, "-") && strings.HasPrefix(lowerKey, wk)) {
+			return FieldString{}, MalformedHeaderError{key, errors.New("header is managed by schwift")}
+		}
+	}
+	return FieldString{h, key}, nil
+}
+
 //IsDynamicLargeObject returns true if this set of headers belongs to a Dynamic
-//Large Object (DLO).
+//Large Object (DLO). The location of the DLO's segments can be read from
+//ObjectManifest(), in the format "<container>/<prefix>".
 func (h ObjectHeaders) IsDynamicLargeObject() bool {
-	return h.Headers.Get("X-Object-Manifest") != ""
+	return h.ObjectManifest().Exists()
 }
 
 //IsStaticLargeObject returns true if this set of headers belongs to a Static
@@ -122,3 +269,43 @@ func (h ObjectHeaders) IsStaticLargeObject() bool {
 func (h ObjectHeaders) IsLargeObject() bool {
 	return h.IsDynamicLargeObject() || h.IsStaticLargeObject()
 }
+
+//AcceptsRanges returns true if the server reported "Accept-Ranges: bytes" for
+//this object, i.e. if ranges can be requested with DownloadOptions. When the
+//Accept-Ranges header is missing or has the value "none", false is returned.
+func (h ObjectHeaders) AcceptsRanges() bool {
+	for _, unit := range strings.Split(h.Headers.Get("Accept-Ranges"), ",") {
+		if strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+			return true
+		}
+	}
+	return false
+}
+
+//SetAttachmentFilename sets the Content-Disposition header such that browsers
+//will download the object as a file with the given name instead of displaying
+//it. Quoting is applied as necessary, and non-ASCII filenames are encoded as
+//described in RFC 2231. For example:
+//
+//	hdr.SetAttachmentFilename("report.pdf")
+//	hdr.ContentDisposition().Get() //returns `attachment; filename=report.pdf`
+//
+//If the filename cannot be encoded, the Content-Disposition header is left
+//unchanged.
+func (h ObjectHeaders) SetAttachmentFilename(filename string) {
+	value := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if value != "" {
+		h.ContentDisposition().Set(value)
+	}
+}
+
+//AttachmentFilename is the reverse of SetAttachmentFilename. It returns the
+//filename from the Content-Disposition header, or an empty string if the header
+//is missing, malformed, or does not contain a filename.
+func (h ObjectHeaders) AttachmentFilename() string {
+	_, params, err := mime.ParseMediaType(h.ContentDisposition().Get())
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
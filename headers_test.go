@@ -0,0 +1,100 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateHeaderName(t *testing.T) {
+	testCases := map[string]bool{
+		"X-Object-Meta-Foo":     true,
+		"X-Object-Meta-foo_bar": true,
+		"X-Object-Meta-1.5":     true,
+		"":                      false,
+		"X-Object-Meta-Foo Bar": false,
+		"X-Object-Meta-Foo:":    false,
+		"X-Object-Meta-Ümlaut":  false,
+		"X-Object-Meta-Foo\n":   false,
+	}
+	for key, expected := range testCases {
+		err := validateHeaderName(key)
+		if expected && err != nil {
+			t.Errorf("expected %q to be valid, got error %q", key, err.Error())
+		}
+		if !expected && err == nil {
+			t.Errorf("expected %q to be rejected, but was accepted", key)
+		}
+	}
+}
+
+func TestFieldUnixTimeOffset(t *testing.T) {
+	hdr := NewObjectHeaders()
+	hdr.Headers["X-Delete-At"] = "1500000000.25000"
+	if actual := hdr.ExpiresAt().Get(); !actual.Equal(time.Unix(1500000000, 250000000)) {
+		t.Errorf("expected X-Delete-At to parse with sub-second precision, got %s", actual)
+	}
+
+	//the offset suffix of Swift's internal timestamps is not valid in headers
+	hdr.Headers["X-Delete-At"] = "1500000000.25000_0000000000000001"
+	if actual := hdr.ExpiresAt().Get(); !actual.IsZero() {
+		t.Errorf("expected X-Delete-At with offset to be rejected, got %s", actual)
+	}
+	if _, ok := hdr.Validate().(MalformedHeaderError); !ok {
+		t.Errorf("expected MalformedHeaderError for X-Delete-At with offset, got %#v", hdr.Validate())
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	hdr := NewObjectHeaders()
+	placement, err := hdr.Custom("X-Example-Placement")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	placement.Set("region2")
+	if hdr.Headers["X-Example-Placement"] != "region2" {
+		t.Errorf("expected custom header to be set, got %#v", hdr.Headers)
+	}
+	placement, err = hdr.Custom("x-example-placement")
+	if err != nil || !placement.Exists() {
+		t.Errorf("expected custom header to be found case-insensitively, got error %v", err)
+	}
+
+	testCases := map[string]bool{
+		"X-Example-Placement":    true,
+		"X-Container-Meta-Foo":   true, //not managed by ObjectHeaders
+		"Content-Type":           false,
+		"content-type":           false,
+		"X-Object-Meta-Foo":      false,
+		"X-Remove-Object-Meta-A": false,
+		"X-Example Placement":    false,
+	}
+	for key, ok := range testCases {
+		_, err := hdr.Custom(key)
+		if ok && err != nil {
+			t.Errorf("expected Custom(%q) to succeed, got error %q", key, err.Error())
+		}
+		if !ok {
+			if _, isMalformed := err.(MalformedHeaderError); !isMalformed {
+				t.Errorf("expected Custom(%q) to return MalformedHeaderError, got %#v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "io"
+
+//AccountAPI contains the basic operations on an account. It is satisfied by
+//*Account, and is intended to be used by applications that want to replace
+//the account with a mock implementation in their unit tests.
+//
+//Methods that return handles to containers or objects (e.g.
+//Account.Container()) are not included since they return concrete types.
+//Applications that need those should wrap them in their own interfaces.
+type AccountAPI interface {
+	Name() string
+	Headers() (AccountHeaders, error)
+	FetchHeaders(opts *RequestOptions) (AccountHeaders, error)
+	Update(headers AccountHeaders, opts *RequestOptions) error
+	Create(opts *RequestOptions) error
+	Invalidate()
+}
+
+//ContainerAPI contains the basic operations on a container. It is satisfied
+//by *Container. See AccountAPI for details.
+type ContainerAPI interface {
+	Name() string
+	Exists() (bool, error)
+	Headers() (ContainerHeaders, error)
+	FetchHeaders(opts *RequestOptions) (ContainerHeaders, error)
+	Update(headers ContainerHeaders, opts *RequestOptions) error
+	Create(opts *RequestOptions) error
+	Delete(opts *RequestOptions) error
+	Invalidate()
+}
+
+//ObjectAPI contains the basic operations on an object. It is satisfied by
+//*Object. See AccountAPI for details. Mock implementations can use
+//NewDownloadedObject() to construct the return value of Download() and
+//DownloadWithOptions().
+type ObjectAPI interface {
+	Name() string
+	FullName() string
+	Exists() (bool, error)
+	Headers() (ObjectHeaders, error)
+	FetchHeaders(opts *RequestOptions) (ObjectHeaders, error)
+	Update(headers ObjectHeaders, opts *RequestOptions) error
+	UpdateWithOptions(headers ObjectHeaders, opts *UpdateOptions, ropts *RequestOptions) error
+	Upload(content io.Reader, opts *UploadOptions, ropts *RequestOptions) error
+	Download(opts *RequestOptions) DownloadedObject
+	DownloadWithOptions(opts *DownloadOptions, ropts *RequestOptions) DownloadedObject
+	Delete(opts *DeleteOptions, ropts *RequestOptions) error
+	Invalidate()
+}
+
+var (
+	_ AccountAPI   = &Account{}
+	_ ContainerAPI = &Container{}
+	_ ObjectAPI    = &Object{}
+)
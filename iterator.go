@@ -32,6 +32,8 @@ type iteratorInterface interface {
 	getContainerName() string
 	getDelimiter() string
 	getPrefix() string
+	getMarker() string
+	getEndMarker() string
 	getOptions() *RequestOptions
 	//putHeader initializes the AccountHeaders/ContainerHeaders field of the
 	//Account/Container using the response headers from the GET request.
@@ -42,6 +44,8 @@ func (i ContainerIterator) getAccount() *Account        { return i.Account }
 func (i ContainerIterator) getContainerName() string    { return "" }
 func (i ContainerIterator) getDelimiter() string        { return "" }
 func (i ContainerIterator) getPrefix() string           { return i.Prefix }
+func (i ContainerIterator) getMarker() string           { return i.Marker }
+func (i ContainerIterator) getEndMarker() string        { return i.EndMarker }
 func (i ContainerIterator) getOptions() *RequestOptions { return i.Options }
 
 func (i ContainerIterator) putHeader(hdr http.Header) error {
@@ -57,6 +61,8 @@ func (i ObjectIterator) getAccount() *Account        { return i.Container.Accoun
 func (i ObjectIterator) getContainerName() string    { return i.Container.Name() }
 func (i ObjectIterator) getDelimiter() string        { return i.Delimiter }
 func (i ObjectIterator) getPrefix() string           { return i.Prefix }
+func (i ObjectIterator) getMarker() string           { return i.Marker }
+func (i ObjectIterator) getEndMarker() string        { return i.EndMarker }
 func (i ObjectIterator) getOptions() *RequestOptions { return i.Options }
 
 func (i ObjectIterator) putHeader(hdr http.Header) error {
@@ -89,10 +95,19 @@ func (b *iteratorBase) request(limit int, detailed bool) Request {
 		r.Options.Values.Set("prefix", prefix)
 	}
 
-	if b.marker == "" {
+	if endMarker := b.i.getEndMarker(); endMarker != "" {
+		r.Options.Values.Set("end_marker", endMarker)
+	}
+
+	//on the first page, start from the user-supplied marker (if any)
+	marker := b.marker
+	if marker == "" {
+		marker = b.i.getMarker()
+	}
+	if marker == "" {
 		r.Options.Values.Del("marker")
 	} else {
-		r.Options.Values.Set("marker", b.marker)
+		r.Options.Values.Set("marker", marker)
 	}
 
 	if limit < 0 {
@@ -114,11 +129,38 @@ func (b *iteratorBase) request(limit int, detailed bool) Request {
 	return r
 }
 
+//contextError returns the error of the context from the iterator's
+//RequestOptions, if any, or nil if the context is still alive.
+func (b *iteratorBase) contextError() error {
+	opts := b.i.getOptions()
+	if opts == nil || opts.Context == nil {
+		return nil
+	}
+	return opts.Context.Err()
+}
+
+//do executes the GET request for the next page. If the context is cancelled
+//while the request is in flight, the context's error is returned instead of
+//the transport error wrapping it.
+func (b *iteratorBase) do(r Request) (*http.Response, error) {
+	if err := b.contextError(); err != nil {
+		return nil, err
+	}
+	resp, err := r.Do(b.i.getAccount().backend)
+	if err != nil {
+		if ctxErr := b.contextError(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (b *iteratorBase) nextPage(limit int) ([]string, error) {
 	if b.eof {
 		return nil, nil
 	}
-	resp, err := b.request(limit, false).Do(b.i.getAccount().backend)
+	resp, err := b.do(b.request(limit, false))
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +189,7 @@ func (b *iteratorBase) nextPageDetailed(limit int, data interface{}) error {
 	if b.eof {
 		return nil
 	}
-	resp, err := b.request(limit, true).Do(b.i.getAccount().backend)
+	resp, err := b.do(b.request(limit, true))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,161 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//listingServer answers every GET request with a plain-text listing of two
+//names per page, and counts the requests.
+type listingServer struct {
+	pages    map[string]string //marker -> listing
+	requests int
+	formats  []string
+}
+
+func (s *listingServer) handle(req *http.Request) (*http.Response, error) {
+	s.requests++
+	s.formats = append(s.formats, req.URL.Query().Get("format"))
+	return testResponse(req, 200, nil, s.pages[req.URL.Query().Get("marker")]), nil
+}
+
+func TestIteratorContextCancellation(t *testing.T) {
+	server := &listingServer{pages: map[string]string{
+		"":  "a\nb\n",
+		"b": "c\nd\n",
+	}}
+	a := newTestAccount(t, server.handle)
+
+	//when the context is cancelled by the callback, the remaining objects on
+	//the current page are not visited, and the next page is not fetched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	iter := a.Container("foo").Objects()
+	iter.Options = &RequestOptions{Context: ctx}
+	var visited []string
+	err := iter.Foreach(func(o *Object) error {
+		visited = append(visited, o.Name())
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected Foreach() to return context.Canceled, got %#v", err)
+	}
+	if len(visited) != 1 {
+		t.Errorf("expected Foreach() to visit 1 object, visited %v", visited)
+	}
+	if server.requests != 1 {
+		t.Errorf("expected 1 GET request, got %d", server.requests)
+	}
+
+	//with an already cancelled context, no request is made at all
+	server.requests = 0
+	iter = a.Container("foo").Objects()
+	iter.Options = &RequestOptions{Context: ctx}
+	_, err = iter.CollectDetailed()
+	if err != context.Canceled {
+		t.Errorf("expected CollectDetailed() to return context.Canceled, got %#v", err)
+	}
+	if server.requests != 0 {
+		t.Errorf("expected 0 GET requests, got %d", server.requests)
+	}
+
+	//without cancellation, all pages are fetched
+	server.requests = 0
+	names, err := a.Containers().Collect()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(names) != 4 || server.requests != 3 {
+		t.Errorf("expected 4 containers in 3 requests, got %d in %d requests", len(names), server.requests)
+	}
+}
+
+func TestObjectNames(t *testing.T) {
+	server := &listingServer{pages: map[string]string{
+		"":  "a\nb\n",
+		"b": "c\nd\n",
+	}}
+	a := newTestAccount(t, server.handle)
+	server.requests = 0
+	server.formats = nil
+
+	names, err := a.Container("foo").ObjectNames(nil).CollectNames()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(names, ",") != "a,b,c,d" {
+		t.Errorf("expected CollectNames() to return [a b c d], got %v", names)
+	}
+	if server.requests != 3 {
+		t.Errorf("expected 3 GET requests, got %d", server.requests)
+	}
+	for _, format := range server.formats {
+		if format != "plain" {
+			t.Errorf("expected plain-text listing, got format=%q", format)
+		}
+	}
+
+	//the RequestOptions are used for each page
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = a.Container("foo").ObjectNames(&RequestOptions{Context: ctx}).ForeachName(func(string) error {
+		t.Error("expected callback not to be called with cancelled context")
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expected ForeachName() to return context.Canceled, got %#v", err)
+	}
+}
+
+func TestAllObjectsAndContainers(t *testing.T) {
+	server := &listingServer{pages: map[string]string{
+		"":  "a\nb\n",
+		"b": "c\nd\n",
+	}}
+	a := newTestAccount(t, server.handle)
+
+	objects, err := a.Container("foo").AllObjects(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.FullName())
+	}
+	if strings.Join(names, ",") != "foo/a,foo/b,foo/c,foo/d" {
+		t.Errorf("expected AllObjects() to return foo/[abcd], got %v", names)
+	}
+
+	containers, err := a.AllContainers(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	names = nil
+	for _, c := range containers {
+		names = append(names, c.Name())
+	}
+	if strings.Join(names, ",") != "a,b,c,d" {
+		t.Errorf("expected AllContainers() to return [a b c d], got %v", names)
+	}
+}
@@ -20,6 +20,7 @@ package schwift
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
@@ -28,6 +29,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	"math"
 	"net/http"
 	"net/url"
@@ -35,6 +37,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jpillora/longestcommon"
@@ -55,6 +58,11 @@ import (
 //
 //Sorry that specifying a range is that involved. I was just following orders ^W
 //RFC 7233, section 3.1 here.
+//
+//For static large objects, .SizeBytes and .Etag are optional. When they are
+//set, Swift will verify them against the segment object when the manifest is
+//written, and WriteManifest() fails if they do not match. Append() always fills
+//in both fields.
 type SegmentInfo struct {
 	Object      *Object
 	SizeBytes   uint64
@@ -87,6 +95,9 @@ type LargeObjectStrategy int
 //this behavior may change in future versions of Schwift, esp. if new
 //strategies become available. The choice may also start to depend on the
 //capabilities advertised by the server.
+//
+//When returned by Object.LargeObjectStrategy(), a value of 0 means that the
+//object is not a large object.
 const (
 	//StaticLargeObject is the default LargeObjectStrategy used by Schwift.
 	StaticLargeObject LargeObjectStrategy = iota + 1
@@ -225,7 +236,7 @@ func (o *Object) AsLargeObject() (*LargeObject, error) {
 
 	h := o.headers
 	if h.IsDynamicLargeObject() {
-		return o.asDLO(h.Get("X-Object-Manifest"))
+		return o.asDLO(h.ObjectManifest().Get())
 	}
 	if h.IsStaticLargeObject() {
 		return o.asSLO()
@@ -233,6 +244,48 @@ func (o *Object) AsLargeObject() (*LargeObject, error) {
 	return nil, ErrNotLarge
 }
 
+//LargeObjectStrategy reports whether this object is a static large object
+//(StaticLargeObject), a dynamic large object (DynamicLargeObject), or a plain
+//object (0). This is derived from the X-Static-Large-Object and
+//X-Object-Manifest headers, so a HEAD request is issued if the object's
+//headers have not been cached yet. If the object does not exist, a 404 error
+//is returned.
+func (o *Object) LargeObjectStrategy() (LargeObjectStrategy, error) {
+	hdr, err := o.Headers()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case hdr.IsStaticLargeObject():
+		return StaticLargeObject, nil
+	case hdr.IsDynamicLargeObject():
+		return DynamicLargeObject, nil
+	default:
+		return 0, nil
+	}
+}
+
+//ManifestSegments returns the list of segments referenced by this large
+//object. For static large objects, the manifest is retrieved with
+//"?multipart-manifest=get". For dynamic large objects, the segment prefix
+//from the manifest is listed. If the object is not a large object,
+//ErrNotLarge is returned.
+//
+//This is a shorthand for:
+//
+//	lo, err := o.AsLargeObject()
+//	segments, err := lo.Segments()
+//
+//Note that, for segments with ranges, SegmentInfo.SizeBytes refers to the
+//size of the entire segment object, not just the range.
+func (o *Object) ManifestSegments() ([]SegmentInfo, error) {
+	lo, err := o.AsLargeObject()
+	if err != nil {
+		return nil, err
+	}
+	return lo.Segments()
+}
+
 func (o *Object) asDLO(manifestStr string) (*LargeObject, error) {
 	manifest := strings.SplitN(manifestStr, "/", 2)
 	if len(manifest) < 2 {
@@ -662,22 +715,28 @@ func (lo *LargeObject) AddSegment(segment SegmentInfo) error {
 //This function uploads segment objects, so it may return any error that
 //Object.Upload() returns, see documentation over there.
 func (lo *LargeObject) Append(contents io.Reader, segmentSizeBytes int64) error {
+	return lo.append(contents, segmentSizeBytes, 1, &progressAggregator{}, nil)
+}
+
+//append implements Append() and Object.UploadLarge(). The segments are
+//uploaded with the Context from the given RequestOptions (if any).
+func (lo *LargeObject) append(contents io.Reader, segmentSizeBytes int64, concurrency int, progress *progressAggregator, ropts *RequestOptions) error {
 	if segmentSizeBytes < 0 {
 		panic("segmentSizeBytes may not be negative")
 	}
 	if segmentSizeBytes == 0 {
-		//apply default value for segmenting size
-		caps, err := lo.object.c.a.Capabilities()
+		var err error
+		segmentSizeBytes, err = lo.defaultSegmentSize()
 		if err != nil {
 			return err
 		}
-		segmentSizeBytes = int64(caps.Swift.MaximumFileSize)
-		if segmentSizeBytes <= 0 {
-			return errors.New("cannot infer SegmentSizeBytes from Swift /info")
-		}
 	}
 
 	sr := segmentingReader{contents, segmentSizeBytes}
+	if concurrency > 1 {
+		return lo.appendConcurrently(&sr, concurrency, progress, ropts)
+	}
+
 	for {
 		segment := sr.NextSegment()
 		if segment == nil {
@@ -690,7 +749,7 @@ func (lo *LargeObject) Append(contents io.Reader, segmentSizeBytes int64) error
 		}
 
 		obj := lo.NextSegmentObject()
-		err := obj.Upload(&tracker, nil, nil)
+		err := obj.Upload(&tracker, &UploadOptions{Progress: progress.Track()}, requestOptionsWithContextOnly(ropts))
 		if err != nil {
 			return err
 		}
@@ -707,6 +766,349 @@ func (lo *LargeObject) Append(contents io.Reader, segmentSizeBytes int64) error
 	return nil
 }
 
+//defaultSegmentSize returns the segment size that is used when the caller
+//of Append() or Object.UploadLarge() does not choose one.
+func (lo *LargeObject) defaultSegmentSize() (int64, error) {
+	caps, err := lo.object.c.a.Capabilities()
+	if err != nil {
+		return 0, err
+	}
+	segmentSizeBytes := int64(caps.Swift.MaximumFileSize)
+	if segmentSizeBytes <= 0 {
+		return 0, errors.New("cannot infer SegmentSizeBytes from Swift /info")
+	}
+	return segmentSizeBytes, nil
+}
+
+//appendConcurrently is the part of append() that uploads multiple segments in
+//parallel. Since the segments are read sequentially from the segmentingReader,
+//each segment is read into memory before it is handed to a worker.
+//
+//The segments are added to lo.segments in the order in which they are read,
+//regardless of the order in which their uploads complete.
+func (lo *LargeObject) appendConcurrently(sr *segmentingReader, concurrency int, progress *progressAggregator, ropts *RequestOptions) error {
+	ctx := context.Background()
+	if ropts != nil && ropts.Context != nil {
+		ctx = ropts.Context
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	//fail records the first error and cancels all uploads that are still running
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	//each token in the semaphore represents one buffered segment
+	semaphore := make(chan struct{}, concurrency)
+	for {
+		semaphore <- struct{}{}
+		if ctx.Err() != nil {
+			fail(ctx.Err())
+			break
+		}
+
+		segment := sr.NextSegment()
+		if segment == nil {
+			break
+		}
+		buf, err := ioutil.ReadAll(segment)
+		if err != nil {
+			fail(err)
+			break
+		}
+		sum := md5.Sum(buf)
+		etag := hex.EncodeToString(sum[:])
+
+		obj := lo.NextSegmentObject()
+		err = lo.AddSegment(SegmentInfo{
+			Object:    obj,
+			SizeBytes: uint64(len(buf)),
+			Etag:      etag,
+		})
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			hdr := NewObjectHeaders()
+			hdr.Etag().Set(etag)
+			segmentOpts := hdr.ToOpts()
+			segmentOpts.Context = ctx
+			err := obj.Upload(bytes.NewReader(buf), &UploadOptions{Progress: progress.Track()}, segmentOpts)
+			if err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+//UploadLargeOptions contains options that can be passed to
+//Object.UploadLarge().
+//
+//The embedded SegmentingOptions work as described for Object.AsNewLargeObject(),
+//except that SegmentContainer may be nil. In that case, the segments are
+//uploaded into the container named by SegmentContainerName, in the same
+//account as the large object. If CreateSegmentContainer is set, this container
+//is created if it does not exist yet. Otherwise, UploadLarge() fails if the
+//container does not exist.
+//
+//If SegmentContainerName is empty as well, the container "<container>_segments"
+//is used (where <container> is the name of the large object's container),
+//with the same rules regarding CreateSegmentContainer.
+//
+//To share one segment container between several containers, set
+//SegmentContainerName to the shared container's name, and SegmentPrefix to a
+//prefix that does not collide with the segments of other large objects, e.g.
+//by including the large object's container name.
+//
+//TruncateOptions is passed to Object.AsNewLargeObject() and controls what
+//happens to the segments of a large object that previously existed at the
+//target location.
+//
+//If Concurrency is larger than 1, up to that many segments are uploaded in
+//parallel. Since the content can only be read sequentially, each segment is
+//read into memory before it is uploaded. Memory usage is therefore bounded by
+//Concurrency times the segment size. Otherwise, segments are uploaded one
+//after another and streamed directly from the given io.Reader.
+//
+//If Progress is set, it is called periodically while the segments are
+//uploaded with the total number of bytes uploaded so far, summed across all
+//segments. Since the total size is not known in advance, -1 is reported as
+//totalBytes. Calls to Progress are serialized, even when segments are
+//uploaded in parallel.
+//
+//If KeepSegmentsOnError is set, the segments that have been uploaded are not
+//deleted when the upload fails (see below).
+//
+//If Resume is set, segments that exist already from a previous, interrupted
+//call to UploadLarge() are not uploaded again (see below). Resume implies
+//KeepSegmentsOnError.
+type UploadLargeOptions struct {
+	SegmentingOptions
+	TruncateOptions     *TruncateOptions
+	Concurrency         int
+	Progress            ProgressFunc
+	KeepSegmentsOnError bool
+	Resume              bool
+	//SegmentContainerName and CreateSegmentContainer are only used when
+	//SegmentingOptions.SegmentContainer is nil (see above).
+	SegmentContainerName   string
+	CreateSegmentContainer bool
+}
+
+//UploadLarge uploads the contents of the given io.Reader as a large object.
+//This is a convenience function that combines AsNewLargeObject(), Append()
+//and WriteManifest() like so:
+//
+//	lo, err := o.AsNewLargeObject(opts.SegmentingOptions, opts.TruncateOptions)
+//	err = lo.Append(contents, segmentSizeBytes)
+//	err = lo.WriteManifest(ropts)
+//
+//The segment size, as well as the etag of each segment, are recorded in the
+//manifest, so Swift can verify the integrity of the segments when the
+//manifest is written. When segmentSizeBytes is zero, the default from
+//Append() is used.
+//
+//If uploading a segment or writing the manifest fails, all outstanding segment
+//uploads are cancelled, and the segments that have already been uploaded by
+//this call are deleted again (on a best-effort basis) before the original
+//error is returned. If some segments cannot be deleted, or if
+//KeepSegmentsOnError is set (e.g. to resume the upload manually), the error
+//is wrapped in an UploadLargeError that lists the segments that remain in the
+//segment container.
+//
+//To resume an interrupted upload, call UploadLarge() again with Resume set,
+//and with the same contents, segment size and SegmentingOptions as before.
+//Since the segments are found by their names, an explicit SegmentPrefix is
+//required in this case. Starting with the first segment, UploadLarge() checks
+//each segment object with a HEAD request, and reads the corresponding part of
+//the contents to compare it to the segment's size and Etag. Matching segments
+//are skipped. When the first segment is missing or does not match, the
+//contents are rewound to the start of that segment, and the upload continues
+//from there as usual. The contents must therefore implement io.Seeker, and
+//must be positioned at the start of the data (just like in the first call).
+//
+//The Context from ropts (if any) also applies to the segment uploads.
+func (o *Object) UploadLarge(contents io.Reader, segmentSizeBytes int64, opts *UploadLargeOptions, ropts *RequestOptions) error {
+	if opts == nil {
+		opts = &UploadLargeOptions{}
+	}
+	var seekableContents io.ReadSeeker
+	if opts.Resume {
+		var ok bool
+		seekableContents, ok = contents.(io.ReadSeeker)
+		if !ok {
+			return errors.New("cannot resume UploadLarge() for contents that do not implement io.Seeker")
+		}
+		if opts.SegmentingOptions.SegmentPrefix == "" {
+			return errors.New("cannot resume UploadLarge() without an explicit SegmentPrefix")
+		}
+	}
+	sopts := opts.SegmentingOptions
+	if sopts.SegmentContainer == nil {
+		c, err := o.segmentContainerForUpload(opts)
+		if err != nil {
+			return err
+		}
+		sopts.SegmentContainer = c
+	}
+
+	lo, err := o.AsNewLargeObject(sopts, opts.TruncateOptions)
+	if err != nil {
+		return err
+	}
+
+	if segmentSizeBytes == 0 {
+		segmentSizeBytes, err = lo.defaultSegmentSize()
+		if err != nil {
+			return err
+		}
+	}
+	progress := &progressAggregator{Callback: opts.Progress}
+	if opts.Resume {
+		err = lo.skipUploadedSegments(seekableContents, segmentSizeBytes, ropts)
+	}
+	if err == nil {
+		err = lo.append(contents, segmentSizeBytes, opts.Concurrency, progress, ropts)
+	}
+	segments := lo.SegmentObjects()
+	if err != nil {
+		//when a sequential segment upload fails, the segment has not been added
+		//to lo.segments, but it may exist anyway (e.g. on ErrChecksumMismatch)
+		segments = append(segments, lo.NextSegmentObject())
+	} else {
+		err = lo.WriteManifest(ropts)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if opts.KeepSegmentsOnError || opts.Resume {
+		return UploadLargeError{Cause: err, Segments: segments}
+	}
+	//clean up segments that are not referenced by a manifest
+	_, _, deleteErr := o.c.a.BulkDelete(segments, nil, requestOptionsWithContextOnly(ropts))
+	if leftover := segmentsNotDeleted(segments, deleteErr); len(leftover) > 0 {
+		return UploadLargeError{Cause: err, Segments: leftover}
+	}
+	return err
+}
+
+//segmentContainerForUpload chooses the segment container for UploadLarge() if
+//none was given explicitly.
+func (o *Object) segmentContainerForUpload(opts *UploadLargeOptions) (*Container, error) {
+	name := opts.SegmentContainerName
+	if name == "" {
+		name = o.c.name + "_segments"
+	}
+	c := o.c.a.Container(name)
+	if opts.CreateSegmentContainer {
+		return c.EnsureExists()
+	}
+	exists, err := c.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("segment container %q does not exist", c.Name())
+	}
+	return c, nil
+}
+
+//skipUploadedSegments implements UploadLargeOptions.Resume. It adds all
+//segments that exist already and match the next parts of the contents to this
+//large object, and rewinds the contents to the start of the first segment that
+//still needs to be uploaded.
+func (lo *LargeObject) skipUploadedSegments(contents io.ReadSeeker, segmentSizeBytes int64, ropts *RequestOptions) error {
+	for {
+		startPos, err := contents.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		obj := lo.NextSegmentObject()
+		hdr, err := obj.FetchHeaders(requestOptionsWithContextOnly(ropts))
+		if Is(err, http.StatusNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		//compare segment to the next part of the contents
+		sizeBytes := hdr.SizeBytes().Get()
+		etag := normalizeEtag(hdr.Etag().Get())
+		matches := sizeBytes > 0 && sizeBytes <= uint64(segmentSizeBytes)
+		if matches {
+			hasher := md5.New()
+			n, err := io.Copy(hasher, io.LimitReader(contents, int64(sizeBytes)))
+			if err != nil {
+				return err
+			}
+			matches = uint64(n) == sizeBytes && hex.EncodeToString(hasher.Sum(nil)) == etag
+		}
+		if !matches {
+			_, err := contents.Seek(startPos, io.SeekStart)
+			return err
+		}
+
+		err = lo.AddSegment(SegmentInfo{
+			Object:    obj,
+			SizeBytes: sizeBytes,
+			Etag:      etag,
+		})
+		if err != nil {
+			return err
+		}
+		//a short segment can only be the last one
+		if sizeBytes < uint64(segmentSizeBytes) {
+			return nil
+		}
+	}
+}
+
+//segmentsNotDeleted returns those of the given segments that may still exist
+//after BulkDelete() returned the given error.
+func segmentsNotDeleted(segments []*Object, deleteErr error) []*Object {
+	if deleteErr == nil {
+		return nil
+	}
+	bulkErr, ok := deleteErr.(BulkError)
+	if !ok || len(bulkErr.ObjectErrors) == 0 {
+		//cannot tell which segments were deleted
+		return segments
+	}
+	failed := make(map[string]bool, len(bulkErr.ObjectErrors))
+	for _, e := range bulkErr.ObjectErrors {
+		failed[e.ContainerName+"/"+e.ObjectName] = true
+	}
+	var result []*Object
+	for _, obj := range segments {
+		if failed[obj.FullName()] {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
 type segmentingReader struct {
 	Reader           io.Reader
 	SegmentSizeBytes int64 //must be >0
@@ -752,7 +1154,16 @@ func (r *lengthAndEtagTrackingReader) Read(buf []byte) (int, error) {
 }
 
 //WriteManifest creates this large object by writing a manifest to its
-//location using a PUT request.
+//location using a PUT request. For static large objects, the request uses
+//"?multipart-manifest=put" and the manifest is a JSON document listing all
+//segments. Afterwards, Object().Headers().SizeBytes() reports the total size of
+//all segments.
+//
+//For static large objects, the Etag reported by Swift for the new manifest is
+//compared to the Etag computed by ComputeSLOEtag() from the segments, and
+//ErrChecksumMismatch is returned if they disagree. The check is skipped if
+//some segment's Etag is not known (e.g. it was added by AddSegment() without
+//an Etag) or if the Swift cluster is too old to report the SLO Etag.
 //
 //For dynamic large objects, this method does not generate a PUT request
 //if the object already exists and has the correct manifest (i.e.
@@ -776,13 +1187,13 @@ func (lo *LargeObject) writeDLOManifest(opts *RequestOptions) error {
 	if err != nil && !Is(err, http.StatusNotFound) {
 		return err
 	}
-	if headers.Get("X-Object-Manifest") == manifest {
+	if headers.ObjectManifest().Get() == manifest {
 		return nil
 	}
 
 	//write manifest; make sure that this is a DLO
 	opts = cloneRequestOptions(opts, nil)
-	opts.Headers.Set("X-Object-Manifest", manifest)
+	ObjectHeaders{opts.Headers}.ObjectManifest().Set(manifest)
 	return lo.object.Upload(nil, nil, opts)
 }
 
@@ -800,9 +1211,14 @@ func (lo *LargeObject) writeSLOManifest(opts *RequestOptions) error {
 				Etag:      s.Etag,
 			}
 
-			if s.RangeOffset < 0 {
+			switch {
+			case s.RangeOffset < 0:
 				si.Range = "-" + strconv.FormatUint(s.RangeLength, 10)
-			} else {
+			case s.RangeOffset == 0 && s.RangeLength == 0:
+				//entire object - no range required
+			case s.RangeLength == 0:
+				si.Range = strconv.FormatUint(uint64(s.RangeOffset), 10) + "-"
+			default:
 				firstByteStr := strconv.FormatUint(uint64(s.RangeOffset), 10)
 				lastByteStr := strconv.FormatUint(uint64(s.RangeOffset)+s.RangeLength-1, 10)
 				si.Range = firstByteStr + "-" + lastByteStr
@@ -821,5 +1237,91 @@ func (lo *LargeObject) writeSLOManifest(opts *RequestOptions) error {
 	opts = cloneRequestOptions(opts, nil)
 	opts.Headers.Del("X-Object-Manifest") //ensure sanity :)
 	opts.Values.Set("multipart-manifest", "put")
-	return lo.object.Upload(bytes.NewReader(manifest), nil, opts)
+	_, hdr, err := lo.object.upload(bytes.NewReader(manifest), nil, opts)
+	if err != nil {
+		return err
+	}
+
+	//Swift reports the SLO Etag in quotes to distinguish it from the MD5 of the
+	//manifest itself (which older Swift versions report without quotes)
+	actualEtag := hdr.Get("Etag")
+	if !strings.HasPrefix(actualEtag, `"`) {
+		return nil
+	}
+	expectedEtag, ok := lo.computeSLOEtag()
+	if ok && expectedEtag != normalizeEtag(actualEtag) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+//ComputeSLOEtag computes the Etag that Swift reports for a static large object
+//with the given segments, that is, the MD5 hash of the concatenation of the
+//Etags of all segments. For data segments, the MD5 of the data shall be given.
+//For segments that only cover a byte range of their backing object, Swift
+//hashes "<etag>:<first>-<last>;" instead of the bare Etag, so that string
+//shall be given instead.
+//
+//LargeObject.WriteManifest() uses this to verify the Etag reported by Swift
+//for the manifest, so callers only need this function when they want to
+//compare against the Etag of an SLO independently.
+func ComputeSLOEtag(segmentEtags []string) string {
+	hasher := md5.New()
+	for _, etag := range segmentEtags {
+		hasher.Write([]byte(etag))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+//computeSLOEtag computes the Etag that Swift should report for this SLO. If
+//the Etag cannot be predicted because some segment's Etag or size is not
+//known, false is returned.
+func (lo *LargeObject) computeSLOEtag() (string, bool) {
+	etags := make([]string, len(lo.segments))
+	for idx, s := range lo.segments {
+		if len(s.Data) > 0 {
+			sum := md5.Sum(s.Data)
+			etags[idx] = hex.EncodeToString(sum[:])
+			continue
+		}
+		if s.Etag == "" {
+			//Swift fills in the segment's Etag itself
+			return "", false
+		}
+		etag := normalizeEtag(s.Etag)
+		if s.RangeOffset == 0 && s.RangeLength == 0 {
+			etags[idx] = etag
+			continue
+		}
+
+		//normalize the range like Swift does
+		if s.SizeBytes == 0 {
+			return "", false
+		}
+		var firstByte, lastByte uint64
+		switch {
+		case s.RangeOffset < 0:
+			if s.RangeLength < s.SizeBytes {
+				firstByte = s.SizeBytes - s.RangeLength
+			}
+			lastByte = s.SizeBytes - 1
+		case s.RangeLength == 0 || uint64(s.RangeOffset)+s.RangeLength > s.SizeBytes:
+			firstByte = uint64(s.RangeOffset)
+			lastByte = s.SizeBytes - 1
+		default:
+			firstByte = uint64(s.RangeOffset)
+			lastByte = firstByte + s.RangeLength - 1
+		}
+		if firstByte > lastByte {
+			//unsatisfiable range - Swift will reject the manifest anyway
+			return "", false
+		}
+		if firstByte == 0 && lastByte == s.SizeBytes-1 {
+			//Swift drops ranges that cover the entire segment
+			etags[idx] = etag
+		} else {
+			etags[idx] = fmt.Sprintf("%s:%d-%d;", etag, firstByte, lastByte)
+		}
+	}
+	return ComputeSLOEtag(etags), true
 }
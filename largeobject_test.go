@@ -20,6 +20,8 @@ package schwift
 
 import (
 	"bytes"
+	"crypto/md5"
+	"encoding/hex"
 	"io/ioutil"
 	"testing"
 )
@@ -67,6 +69,54 @@ func TestParseHTTPRange(t *testing.T) {
 	}
 }
 
+func TestComputeSLOEtag(t *testing.T) {
+	md5hex := func(str string) string {
+		sum := md5.Sum([]byte(str))
+		return hex.EncodeToString(sum[:])
+	}
+	etag1 := md5hex("segment1")
+	etag2 := md5hex("segment2")
+
+	actual := ComputeSLOEtag([]string{etag1, etag2})
+	if expected := md5hex(etag1 + etag2); actual != expected {
+		t.Errorf("expected ComputeSLOEtag() = %q, got %q", expected, actual)
+	}
+
+	testCases := []struct {
+		segment  SegmentInfo
+		ok       bool
+		etagPart string
+	}{
+		{SegmentInfo{Etag: etag1, SizeBytes: 100}, true, etag1},
+		{SegmentInfo{Etag: `"` + etag1 + `"`}, true, etag1},
+		{SegmentInfo{Data: []byte("data")}, true, md5hex("data")},
+		//ranges covering the entire segment are dropped by Swift
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeLength: 100}, true, etag1},
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: -1, RangeLength: 200}, true, etag1},
+		//other ranges are normalized
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: 10, RangeLength: 20}, true, etag1 + ":10-29;"},
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: 10}, true, etag1 + ":10-99;"},
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: 90, RangeLength: 20}, true, etag1 + ":90-99;"},
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: -1, RangeLength: 20}, true, etag1 + ":80-99;"},
+		//cannot predict
+		{SegmentInfo{SizeBytes: 100}, false, ""},
+		{SegmentInfo{Etag: etag1, RangeOffset: 10}, false, ""},
+		{SegmentInfo{Etag: etag1, SizeBytes: 100, RangeOffset: 100}, false, ""},
+	}
+
+	for _, tc := range testCases {
+		lo := LargeObject{segments: []SegmentInfo{tc.segment, {Etag: etag2}}}
+		actual, ok := lo.computeSLOEtag()
+		if ok != tc.ok {
+			t.Errorf("expected ok = %t for %#v, got %t", tc.ok, tc.segment, ok)
+			continue
+		}
+		if expected := md5hex(tc.etagPart + etag2); ok && actual != expected {
+			t.Errorf("expected SLO Etag %q for %#v, got %q", expected, tc.segment, actual)
+		}
+	}
+}
+
 func TestSegmentingReader(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -110,3 +160,22 @@ func TestSegmentingReader(t *testing.T) {
 		}
 	}
 }
+
+func TestSegmentsNotDeleted(t *testing.T) {
+	c := &Container{name: "segments"}
+	segments := []*Object{c.Object("1"), c.Object("2"), c.Object("3")}
+
+	if leftover := segmentsNotDeleted(segments, nil); len(leftover) != 0 {
+		t.Errorf("expected no leftover segments, got %d", len(leftover))
+	}
+	if leftover := segmentsNotDeleted(segments, ErrNotSupported); len(leftover) != 3 {
+		t.Errorf("expected all segments to be left over on unknown error, got %d", len(leftover))
+	}
+	leftover := segmentsNotDeleted(segments, BulkError{
+		StatusCode:   400,
+		ObjectErrors: []BulkObjectError{{ContainerName: "segments", ObjectName: "2", StatusCode: 409}},
+	})
+	if len(leftover) != 1 || leftover[0].Name() != "2" {
+		t.Errorf("expected only segment 2 to be left over, got %#v", leftover)
+	}
+}
@@ -20,14 +20,23 @@ package schwift
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/md5"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
-	"net/url"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 //Object represents a Swift object. Instances are usually obtained by
@@ -77,16 +86,64 @@ func (o *Object) FullName() string {
 	return o.c.name + "/" + o.name
 }
 
+//URL returns the canonical URL for this object on the server, with the
+//container and object names percent-encoded as necessary. This URL can be
+//used to access the object without authentication if the container has a
+//public read ACL. (For temporary access to private containers, use TempURL()
+//instead.)
+//
+//	obj := account.Container("docs").Object("2018-02-10/invoice #1.pdf")
+//	obj.URL() //returns "https://swift.example.com/v1/AUTH_abc/docs/2018-02-10/invoice%20%231.pdf"
+func (o *Object) URL() (string, error) {
+	return Request{
+		ContainerName: o.c.name,
+		ObjectName:    o.name,
+	}.URL(o.c.a.backend, nil)
+}
+
+//PublicURL is like URL(), but uses the given base URL instead of the
+//account's storage URL. This is useful when the public endpoint differs from
+//the storage URL, e.g. for a CDN in front of Swift. The container and object
+//names are appended to the base URL:
+//
+//	obj := account.Container("docs").Object("invoice.pdf")
+//	obj.PublicURL("https://cdn.example.com/") //returns "https://cdn.example.com/docs/invoice.pdf"
+func (o *Object) PublicURL(baseURL string) (string, error) {
+	return Request{
+		ContainerName: o.c.name,
+		ObjectName:    o.name,
+	}.urlBelow(baseURL, nil)
+}
+
 //Exists checks if this object exists, potentially by issuing a HEAD request
 //if no Headers() have been cached yet.
+//
+//Only a 404 (Not Found) response is interpreted as non-existence, in which
+//case (false, nil) is returned. All other errors are returned as-is, most
+//notably 401 (Unauthorized) and 403 (Forbidden): When the user is not allowed
+//to read the object, Swift cannot tell whether it exists, so neither can
+//Exists(). Use Is() to check for these cases.
+//
+//When the object is a symlink whose target does not exist, Swift reports 404
+//for the HEAD request, so Exists() returns false. Use InspectSymlink() to
+//check the symlink itself.
 func (o *Object) Exists() (bool, error) {
-	_, err := o.Headers()
+	exists, _, err := o.ExistsWithHeaders()
+	return exists, err
+}
+
+//ExistsWithHeaders is like Exists(), but also returns the object's headers if
+//it exists. This avoids a second HEAD request when the caller needs both
+//pieces of information. If the object does not exist or an error occurs, the
+//returned ObjectHeaders instance is empty.
+func (o *Object) ExistsWithHeaders() (bool, ObjectHeaders, error) {
+	hdr, err := o.Headers()
 	if Is(err, http.StatusNotFound) {
-		return false, nil
+		return false, ObjectHeaders{}, nil
 	} else if err != nil {
-		return false, err
+		return false, ObjectHeaders{}, err
 	}
-	return true, nil
+	return true, hdr, nil
 }
 
 //Headers returns the ObjectHeaders for this object. If the ObjectHeaders
@@ -109,6 +166,19 @@ func (o *Object) Headers() (ObjectHeaders, error) {
 	return *hdr, nil
 }
 
+//FetchHeaders is like Headers(), but always issues a HEAD request, using the
+//given RequestOptions. This can be used to add query parameters or headers
+//that Schwift does not model (e.g. for deployment-specific middlewares), or to
+//pass a Context. Since such options may influence the result, the result is
+//not stored in the cache used by Headers(), and the cache is not consulted.
+func (o *Object) FetchHeaders(opts *RequestOptions) (ObjectHeaders, error) {
+	hdr, err := o.fetchHeaders(opts)
+	if err != nil {
+		return ObjectHeaders{}, err
+	}
+	return *hdr, nil
+}
+
 func (o *Object) fetchHeaders(opts *RequestOptions) (*ObjectHeaders, error) {
 	resp, err := Request{
 		Method:        "HEAD",
@@ -128,18 +198,112 @@ func (o *Object) fetchHeaders(opts *RequestOptions) (*ObjectHeaders, error) {
 	return &headers, headers.Validate()
 }
 
+//MetadataMode is an option for Object.UpdateWithOptions() that controls what
+//happens to object metadata that is not mentioned in the update.
+type MetadataMode int
+
+const (
+	//MetadataReplace is the default behavior of Swift: All existing object
+	//metadata is replaced by the metadata in the update, so keys that are not
+	//included in the update are deleted.
+	MetadataReplace MetadataMode = iota
+	//MetadataMerge preserves existing metadata keys that are not mentioned in
+	//the update. This is the same behavior that Swift shows for account and
+	//container metadata.
+	MetadataMerge
+)
+
+//UpdateOptions invokes advanced behavior in the Object.UpdateWithOptions()
+//method.
+type UpdateOptions struct {
+	MetadataMode MetadataMode
+	//If set, UpdateWithOptions() does not preserve the existing Content-Type when
+	//the given headers do not include one (see below).
+	ResetContentType bool
+}
+
 //Update updates the object's headers using a POST request. To add URL
-//parameters, pass a non-nil *RequestOptions.
+//parameters, pass a non-nil *RequestOptions. This is equivalent to calling
+//UpdateWithOptions() with default UpdateOptions, see there for details on how
+//metadata and the Content-Type are handled.
 //
 //This operation fails with http.StatusNotFound if the object does not exist.
 //
 //A successful POST request implies Invalidate() since it may change metadata.
 func (o *Object) Update(headers ObjectHeaders, opts *RequestOptions) error {
+	return o.UpdateWithOptions(headers, nil, opts)
+}
+
+//UpdateWithOptions is like Update(), but takes an *UpdateOptions to invoke
+//advanced behavior.
+//
+//Swift replaces all object metadata on POST, so by default, metadata keys that
+//are not included in the given headers will be deleted. With
+//MetadataMode = MetadataMerge, UpdateWithOptions() instead issues a HEAD
+//request first to obtain the current metadata, and sends the union of the
+//current and the new metadata in the POST request. In this mode, keys can be deleted by setting
+//them to the empty string with Clear(), or by including an
+//"X-Remove-Object-Meta-<key>" header. Note that the merge is not atomic: when
+//the metadata is changed by someone else between the HEAD and the POST request,
+//that change will be lost.
+//
+//Depending on its configuration, Swift may also reset the Content-Type to a
+//default value when it is not included in a POST request. To avoid surprises in
+//metadata-only updates, UpdateWithOptions() therefore sends the current
+//Content-Type along, unless the given headers contain a Content-Type already.
+//The current Content-Type is taken from the cache used by Headers() or from the
+//HEAD request of MetadataMerge if possible. Otherwise, this costs an extra HEAD
+//request before the POST request. If that HEAD request is forbidden (e.g.
+//because the user can only write to the container), the POST request is sent
+//without a Content-Type. Set ResetContentType to skip all of this and send the
+//given headers as they are.
+//
+//This operation fails with http.StatusNotFound if the object does not exist.
+//
+//A successful POST request implies Invalidate() since it may change metadata.
+func (o *Object) UpdateWithOptions(headers ObjectHeaders, opts *UpdateOptions, ropts *RequestOptions) error {
+	if opts == nil {
+		opts = &UpdateOptions{}
+	}
+	mergeMetadata := opts.MetadataMode == MetadataMerge
+	preserveContentType := !opts.ResetContentType && !headers.ContentType().Exists()
+	if preserveContentType && ropts != nil {
+		preserveContentType = !(ObjectHeaders{ropts.Headers}).ContentType().Exists()
+	}
+
+	var current *ObjectHeaders
+	switch {
+	case mergeMetadata:
+		var err error
+		current, err = o.fetchHeaders(requestOptionsWithContextOnly(ropts))
+		if err != nil {
+			return err
+		}
+		headers = mergeObjectMetadata(*current, headers)
+	case preserveContentType && o.headers != nil:
+		current = o.headers
+	case preserveContentType:
+		var err error
+		current, err = o.fetchHeaders(requestOptionsWithContextOnly(ropts))
+		if Is(err, http.StatusForbidden) {
+			//preserving the Content-Type is best-effort only
+			current, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ropts = cloneRequestOptions(ropts, headers.Headers)
+	if preserveContentType && current != nil && current.ContentType().Exists() {
+		ObjectHeaders{ropts.Headers}.ContentType().Set(current.ContentType().Get())
+	}
+
 	_, err := Request{
 		Method:            "POST",
 		ContainerName:     o.c.name,
 		ObjectName:        o.name,
-		Options:           cloneRequestOptions(opts, headers.Headers),
+		Options:           ropts,
 		ExpectStatusCodes: []int{202},
 	}.Do(o.c.a.backend)
 	if err == nil {
@@ -148,11 +312,68 @@ func (o *Object) Update(headers ObjectHeaders, opts *RequestOptions) error {
 	return err
 }
 
+//mergeObjectMetadata implements MetadataMerge for Object.UpdateWithOptions().
+func mergeObjectMetadata(current, headers ObjectHeaders) ObjectHeaders {
+	const (
+		metadataPrefix = "X-Object-Meta-"
+		removePrefix   = "X-Remove-Object-Meta-"
+	)
+	merged := NewObjectHeaders()
+	for key, value := range current.Headers {
+		if strings.HasPrefix(key, metadataPrefix) {
+			merged.Headers[key] = value
+		}
+	}
+	for key, value := range headers.Headers {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if strings.HasPrefix(key, removePrefix) {
+			//the object server does not understand this header, but omitting the
+			//key from the POST request has the same effect
+			merged.Metadata().Del(strings.TrimPrefix(key, removePrefix))
+			continue
+		}
+		merged.Headers[key] = value
+	}
+	return merged
+}
+
 //UploadOptions invokes advanced behavior in the Object.Upload() method.
 type UploadOptions struct {
 	//When overwriting a large object, delete its segments. This will cause
 	//Upload() to call into BulkDelete(), so a BulkError may be returned.
 	DeleteSegments bool
+	//If set, this callback will be called periodically while the object's
+	//content is uploaded. The total size is taken from the Content-Length
+	//request header, which is filled automatically for some types of content
+	//(see below), and -1 is reported if it is not known.
+	Progress ProgressFunc
+	//If set, the upload is conditional on the If-Match or If-None-Match header
+	//(see below).
+	IfMatch     string
+	IfNoneMatch string
+	//If set, the content is compressed with gzip while it is uploaded, and the
+	//Content-Encoding header is set to "gzip" (see below).
+	CompressGzip bool
+	//The compression level for CompressGzip, see package compress/gzip. The
+	//default value of 0 selects gzip.DefaultCompression (instead of
+	//gzip.NoCompression).
+	CompressionLevel int
+	//If set, content that cannot seek is first copied into a temporary file,
+	//and then uploaded from that file (see below).
+	SpoolToDisk bool
+	//The directory in which SpoolToDisk creates its temporary files. The
+	//default value of "" selects os.TempDir().
+	SpoolDirectory string
+	//If set, the MD5 checksum of the content is also sent in the Content-MD5
+	//header for middlewares or proxies that check it, if it is known in
+	//advance (see below).
+	SendContentMD5 bool
+	//If > 0, the object is scheduled for deletion after this duration by
+	//setting the X-Delete-After header (see below).
+	ExpireAfter time.Duration
+	//If > 0, the content must be exactly this many bytes long, and is sent
+	//with a Content-Length instead of chunked transfer encoding (see below).
+	ContentLength int64
 }
 
 //Upload creates the object using a PUT request.
@@ -178,7 +399,13 @@ type UploadOptions struct {
 //If content is a *bytes.Reader or a *bytes.Buffer instance, the Content-Length
 //and Etag request headers will be computed automatically. Otherwise, it is
 //highly recommended that the caller set these headers (if possible) to allow
-//the server to check the integrity of the uploaded file.
+//the server to check the integrity of the uploaded file. When the MD5 checksum
+//of the content is known in advance (e.g. from the source of a streaming
+//transfer), supplying it also avoids the overhead of computing it on the fly:
+//
+//	hdr := schwift.NewObjectHeaders()
+//	hdr.Etag().Set(knownMD5HexDigest)
+//	o.Upload(reader, nil, hdr.ToOpts())
 //
 //If Etag and/or Content-Length is supplied and the content does not match
 //these parameters, http.StatusUnprocessableEntity is returned. If Etag is not
@@ -186,17 +413,177 @@ type UploadOptions struct {
 //data is read from the io.Reader, and compare the result to the Etag returned
 //by Swift, returning ErrChecksumMismatch in case of mismatch. The object will
 //have been uploaded at that point, so you will usually want to Delete() it.
+//This verification can be disabled with Account.SetUploadChecksumVerification().
+//
+//Upload() never buffers the content in memory. If the Content-Length is not
+//known in advance, the content is streamed to Swift using chunked transfer
+//encoding, and the Etag is computed on the fly as described above. It is
+//therefore safe to upload arbitrarily large streams, e.g. the output of a
+//pipe, as long as they do not exceed the maximum object size of the cluster
+//(see Capabilities.Swift.MaximumFileSize and type LargeObject). This includes
+//an *os.File that refers to a pipe (e.g. os.Stdin): Even though it implements
+//io.Seeker, Upload() recognizes that it cannot seek, and treats it like any
+//other stream.
+//
+//If CompressGzip is set, the content is piped through a gzip.Writer and the
+//compressed data is stored in Swift with "Content-Encoding: gzip". Since the
+//size and checksum of the compressed data cannot be known in advance, the
+//Content-Length and Etag request headers are removed in this case, and the
+//Etag is computed on the fly over the compressed data (i.e. what is actually
+//stored) as described above. Progress reports count compressed bytes. Use
+//DownloadOptions.DecompressGzip to read such objects back.
+//
+//If SpoolToDisk is set and the content cannot seek (e.g. the output of a
+//pipe, including an *os.File like os.Stdin that implements io.Seeker, but
+//fails to seek), the content is first copied into a temporary file in
+//SpoolDirectory, computing its Etag and Content-Length along the way. The
+//upload then reads from this file, so the Etag is verified by Swift before
+//the object is stored, and the request can be retried (see RetryPolicy). The
+//temporary file is deleted before Upload() returns. This trades disk space
+//for the guarantees that are otherwise only available for seekable content.
+//When combined with CompressGzip, the compressed data is spooled.
+//
+//If SendContentMD5 is set, the Etag is additionally sent in the Content-MD5
+//header (as base64 instead of hex). Stock Swift ignores this header, so this
+//option is only useful with middlewares or proxies in front of Swift that
+//check Content-MD5 (e.g. for compatibility with S3 clients). This only works
+//if the Etag is known in advance, i.e. if it was supplied by the caller,
+//computed from a *bytes.Reader or similar, or obtained through SpoolToDisk.
+//For other streaming uploads, the header cannot be sent. If such a middleware
+//or proxy rejects the upload, an error is returned for which
+//IsChecksumRejected() is true.
+//
+//If ContentLength is set, the Content-Length request header is set to this
+//value, so that the content is sent as a regular request body instead of with
+//chunked transfer encoding, even if Upload() cannot determine the size of the
+//content by itself (e.g. when reading from a pipe). This is required by some
+//proxies that do not support chunked transfer encoding. Setting SizeBytes()
+//in the request headers has the same effect. If the content cannot seek,
+//Upload() additionally ensures that it contains exactly ContentLength bytes:
+//If it ends early, or contains more data, the request is aborted before it is
+//complete (so the object is not stored), and ErrContentLengthMismatch is
+//returned. ContentLength cannot be combined with CompressGzip, since the size
+//of the compressed data is not known in advance.
+//
+//If ExpireAfter is set, the object expires (i.e. is deleted by Swift) after
+//the given duration. This is equivalent to setting DeleteAfter() in the
+//request headers, and Swift converts it into ExpiresAt() when storing the
+//object. Since Swift only accepts whole seconds, sub-second durations are
+//rounded up. Negative durations are rejected with an error before any request
+//is made.
+//
+//	//keep this object for 7 days
+//	err := obj.Upload(content, &schwift.UploadOptions{ExpireAfter: 7 * 24 * time.Hour}, nil)
 //
 //This function can be used regardless of whether the object exists or not.
+//To implement optimistic concurrency, set IfNoneMatch to "*" to only create
+//the object if it does not exist yet, or set IfMatch to a previously observed
+//Etag to only overwrite the object if it has not changed since. When the
+//condition fails, Upload() returns an error with status code 412
+//(Precondition Failed), which can be checked with Is():
+//
+//	err := obj.Upload(content, &schwift.UploadOptions{IfNoneMatch: "*"}, nil)
+//	if schwift.Is(err, http.StatusPreconditionFailed) {
+//		//someone else created the object first
+//	}
+//
+//Note that stock Swift only evaluates If-None-Match on PUT requests. If-Match
+//is sent as requested, but clusters without support for it will perform the
+//upload unconditionally.
 //
 //A successful PUT request implies Invalidate() since it may change metadata.
 func (o *Object) Upload(content io.Reader, opts *UploadOptions, ropts *RequestOptions) error {
+	_, _, err := o.upload(content, opts, ropts)
+	return err
+}
+
+//UploadResult is returned by Object.UploadReturning().
+type UploadResult struct {
+	//BytesWritten is the number of bytes that were stored in the object. When
+	//CompressGzip is set, this counts compressed bytes.
+	BytesWritten int64
+	//Etag is the MD5 checksum of the object's content, as reported by Swift.
+	Etag string
+}
+
+//UploadReturning is like Upload(), but on success, it also returns the size
+//and Etag of the uploaded content. This saves a HEAD request when this
+//information is needed afterwards, e.g. for metering or logging.
+func (o *Object) UploadReturning(content io.Reader, opts *UploadOptions, ropts *RequestOptions) (UploadResult, error) {
+	result, _, err := o.upload(content, opts, ropts)
+	return result, err
+}
+
+//upload is the implementation of Upload() and UploadReturning(). It
+//additionally returns the response headers of the PUT request on success.
+func (o *Object) upload(content io.Reader, opts *UploadOptions, ropts *RequestOptions) (UploadResult, http.Header, error) {
 	if opts == nil {
 		opts = &UploadOptions{}
 	}
+	if opts.ExpireAfter < 0 {
+		return UploadResult{}, nil, errors.New("invalid UploadOptions: ExpireAfter must not be negative")
+	}
+	if opts.ContentLength < 0 {
+		return UploadResult{}, nil, errors.New("invalid UploadOptions: ContentLength must not be negative")
+	}
+	if opts.ContentLength > 0 && opts.CompressGzip {
+		return UploadResult{}, nil, errors.New("invalid UploadOptions: ContentLength cannot be combined with CompressGzip")
+	}
 
 	ropts = cloneRequestOptions(ropts, nil)
 	hdr := ObjectHeaders{ropts.Headers}
+	if opts.ExpireAfter > 0 {
+		hdr.DeleteAfter().Set(opts.ExpireAfter)
+	}
+	if opts.ContentLength > 0 {
+		hdr.SizeBytes().Set(uint64(opts.ContentLength))
+	}
+	if opts.IfMatch != "" {
+		hdr.Set("If-Match", opts.IfMatch)
+	}
+	if opts.IfNoneMatch != "" {
+		hdr.Set("If-None-Match", opts.IfNoneMatch)
+	}
+
+	//do not attempt to add the Etag header when we're writing a large object
+	//manifest; the header refers to the content, but we would be computing the
+	//manifest's hash instead
+	isManifestUpload := ropts.Values.Get("multipart-manifest") == "put" || hdr.IsDynamicLargeObject()
+
+	if opts.CompressGzip && !isManifestUpload {
+		level := opts.CompressionLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		compressed, err := compressGzip(content, level)
+		if err != nil {
+			return UploadResult{}, nil, err
+		}
+		//ensure that the compressing goroutine terminates even if the request
+		//fails before the content has been consumed
+		defer compressed.Close()
+		content = compressed
+		hdr.ContentEncoding().Set("gzip")
+		hdr.SizeBytes().Del()
+		hdr.Etag().Del()
+	}
+
+	if opts.SpoolToDisk && !isManifestUpload && content != nil {
+		if !isSeekable(content) {
+			file, etag, size, err := spoolToDisk(content, opts.SpoolDirectory)
+			if err != nil {
+				return UploadResult{}, nil, err
+			}
+			defer removeSpoolFile(file)
+			content = file
+			if !hdr.Etag().Exists() {
+				hdr.Etag().Set(etag)
+			}
+			if !hdr.SizeBytes().Exists() {
+				hdr.SizeBytes().Set(size)
+			}
+		}
+	}
 
 	if !hdr.SizeBytes().Exists() {
 		value := tryComputeContentLength(content)
@@ -205,10 +592,13 @@ func (o *Object) Upload(content io.Reader, opts *UploadOptions, ropts *RequestOp
 		}
 	}
 
-	//do not attempt to add the Etag header when we're writing a large object
-	//manifest; the header refers to the content, but we would be computing the
-	//manifest's hash instead
-	isManifestUpload := ropts.Values.Get("multipart-manifest") == "put" || hdr.IsDynamicLargeObject()
+	var lengthChecker *exactLengthReader
+	if opts.ContentLength > 0 && content != nil {
+		if !isSeekable(content) {
+			lengthChecker = &exactLengthReader{r: content, remaining: opts.ContentLength}
+			content = lengthChecker
+		}
+	}
 
 	var hasher hash.Hash
 	if !isManifestUpload {
@@ -216,9 +606,16 @@ func (o *Object) Upload(content io.Reader, opts *UploadOptions, ropts *RequestOp
 
 		//could not compute Etag in advance -> need to check on the fly
 		if !hdr.Etag().Exists() {
-			hasher = md5.New()
-			if content != nil {
-				content = io.TeeReader(content, hasher)
+			if o.c.a.shouldVerifyUploadChecksum() {
+				hasher = md5.New()
+				if content != nil {
+					content = io.TeeReader(content, hasher)
+				}
+			}
+		} else if opts.SendContentMD5 && hdr.Get("Content-MD5") == "" {
+			sum, err := hex.DecodeString(hdr.Etag().Get())
+			if err == nil && len(sum) == md5.Size {
+				hdr.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum))
 			}
 		}
 	}
@@ -238,9 +635,30 @@ func (o *Object) Upload(content io.Reader, opts *UploadOptions, ropts *RequestOp
 			lo = nil
 		default:
 			//unexpected error
-			return err
+			return UploadResult{}, nil, err
+		}
+	}
+
+	//when the size is not known in advance, count the bytes while they are
+	//read (this does not change how the body is sent since net/http cannot
+	//determine the Content-Length of such readers either)
+	var result UploadResult
+	progress := opts.Progress
+	if !hdr.SizeBytes().Exists() {
+		progress = func(bytesTransferred, totalBytes int64) {
+			result.BytesWritten = bytesTransferred
+			if opts.Progress != nil {
+				opts.Progress(bytesTransferred, totalBytes)
+			}
 		}
 	}
+	if progress != nil {
+		totalBytes := int64(-1)
+		if hdr.SizeBytes().Exists() {
+			totalBytes = int64(hdr.SizeBytes().Get())
+		}
+		content = trackProgress(content, progress, totalBytes)
+	}
 
 	resp, err := Request{
 		Method:            "PUT",
@@ -252,25 +670,83 @@ func (o *Object) Upload(content io.Reader, opts *UploadOptions, ropts *RequestOp
 		DrainResponseBody: true,
 	}.Do(o.c.a.backend)
 	if err != nil {
-		return err
+		//report a content length mismatch as such, instead of as whatever error
+		//the HTTP client generated from it
+		if lengthChecker != nil && lengthChecker.err != nil {
+			return UploadResult{}, nil, lengthChecker.err
+		}
+		return UploadResult{}, nil, err
 	}
 	o.Invalidate()
+	if hdr.SizeBytes().Exists() {
+		result.BytesWritten = int64(hdr.SizeBytes().Get())
+	}
+	result.Etag = normalizeEtag(resp.Header.Get("Etag"))
 
 	if hasher != nil {
 		expectedEtag := hex.EncodeToString(hasher.Sum(nil))
 		if expectedEtag != resp.Header.Get("Etag") {
-			return ErrChecksumMismatch
+			return UploadResult{}, nil, ErrChecksumMismatch
 		}
 	}
 
 	if opts.DeleteSegments && lo != nil {
-		_, _, err := lo.object.c.a.BulkDelete(lo.SegmentObjects(), nil, nil)
+		_, _, err := lo.object.c.a.BulkDelete(lo.SegmentObjects(), nil, requestOptionsWithContextOnly(ropts))
 		if err != nil {
-			return err
+			return UploadResult{}, nil, err
 		}
 	}
 
-	return nil
+	return result, resp.Header, nil
+}
+
+//exactLengthReader implements UploadOptions.ContentLength for content that
+//cannot seek. It fails with ErrContentLengthMismatch if the wrapped
+//reader does not yield exactly the expected number of bytes. Since net/http
+//never reads more than the Content-Length from the request body, excess data
+//is detected by reading ahead before the final bytes are returned.
+type exactLengthReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (r *exactLengthReader) Read(buf []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(buf)) > r.remaining {
+		buf = buf[:r.remaining]
+	}
+	n, err := r.r.Read(buf)
+	r.remaining -= int64(n)
+	switch {
+	case err == io.EOF && r.remaining > 0:
+		r.err = ErrContentLengthMismatch
+		return 0, r.err
+	case err != nil && err != io.EOF:
+		return n, err
+	case r.remaining == 0:
+		//before returning the final bytes, check that there is no excess data
+		var extra [1]byte
+		for {
+			m, err := r.r.Read(extra[:])
+			if m > 0 {
+				r.err = ErrContentLengthMismatch
+				return 0, r.err
+			}
+			if err == io.EOF {
+				return n, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
 }
 
 type readerWithLen interface {
@@ -313,14 +789,88 @@ func tryComputeEtag(content io.Reader, headers ObjectHeaders) {
 		h.Set(hex.EncodeToString(sum[:]))
 	case likeBytesReader:
 		//bytes.Reader does not have such a method, but it is an io.Seeker, so we
-		//can read the entire thing and then seek back to where we started
+		//can read the entire thing and then seek back to where we started (this
+		//must not be attempted for e.g. an *os.File that refers to a pipe, which
+		//implements io.Seeker, but cannot actually seek)
+		if !isSeekable(content) {
+			return
+		}
 		hash := md5.New()
-		n, _ := r.WriteTo(hash)
-		r.Seek(-n, io.SeekCurrent)
+		n, err := r.WriteTo(hash)
+		if err != nil {
+			return
+		}
+		_, err = r.Seek(-n, io.SeekCurrent)
+		if err != nil {
+			return
+		}
 		h.Set(hex.EncodeToString(hash.Sum(nil)))
 	}
 }
 
+//isSeekable checks whether the given reader can actually seek. Implementing
+//io.Seeker is not enough: An *os.File that refers to a pipe or terminal (e.g.
+//os.Stdin) implements io.Seeker, but its Seek() always fails.
+func isSeekable(r io.Reader) bool {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+//spoolToDisk copies the given content into a new temporary file in the given
+//directory, and returns the file (rewound to its start) along with the MD5
+//hex digest and size of the content. The caller must call removeSpoolFile()
+//when done.
+func spoolToDisk(content io.Reader, dir string) (file *os.File, etag string, size uint64, err error) {
+	file, err = ioutil.TempFile(dir, "schwift-spool-")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	hasher := md5.New()
+	n, err := io.Copy(io.MultiWriter(file, hasher), content)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeSpoolFile(file)
+		return nil, "", 0, err
+	}
+	return file, hex.EncodeToString(hasher.Sum(nil)), uint64(n), nil
+}
+
+//removeSpoolFile closes and deletes a temporary file created by spoolToDisk().
+func removeSpoolFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+//compressGzip returns a reader that yields the gzip-compressed contents of the
+//given reader. The compression runs in a separate goroutine that terminates
+//when the content has been read completely, or when the returned reader is
+//closed.
+func compressGzip(content io.Reader, level int) (io.ReadCloser, error) {
+	if content == nil {
+		content = bytes.NewReader(nil)
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	w, err := gzip.NewWriterLevel(pipeWriter, level)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		_, err := io.Copy(w, content)
+		if err == nil {
+			err = w.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	return pipeReader, nil
+}
+
 //UploadWithWriter is a variant of Upload that can be used when the object's
 //content is generated by some function or package that takes an io.Writer
 //instead of supplying an io.Reader. For example:
@@ -354,11 +904,96 @@ func (o *Object) UploadWithWriter(opts *UploadOptions, ropts *RequestOptions, ca
 	return <-errChan
 }
 
+//UploadFromFileOptions invokes advanced behavior in the Object.UploadFromFile()
+//method.
+type UploadFromFileOptions struct {
+	//These options are passed on to Object.Upload().
+	UploadOptions
+	//If set, and the request headers do not contain a Content-Type, the
+	//Content-Type is guessed from the file extension or, if the extension is
+	//not known, from the first 512 bytes of the file.
+	DetectContentType bool
+}
+
+//UploadFromFile creates the object using a PUT request, with the contents of
+//the file at the given path. The Content-Length request header is set from
+//the file size, unless it is given in ropts already. Since files are
+//seekable, the upload can be retried by a RetryPolicy.
+//
+//If DetectContentType is set, the Content-Type is guessed with
+//mime.TypeByExtension() or, if that does not know the file extension,
+//http.DetectContentType(). A Content-Type given in ropts always takes
+//precedence over the detected type.
+//
+//See Upload() for details on all other behavior.
+func (o *Object) UploadFromFile(path string, opts *UploadFromFileOptions, ropts *RequestOptions) error {
+	if opts == nil {
+		opts = &UploadFromFileOptions{}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return errors.New("cannot upload " + path + ": not a regular file")
+	}
+
+	ropts = cloneRequestOptions(ropts, nil)
+	hdr := ObjectHeaders{ropts.Headers}
+	if !hdr.SizeBytes().Exists() {
+		hdr.SizeBytes().Set(uint64(fi.Size()))
+	}
+	if opts.DetectContentType && !hdr.ContentType().Exists() {
+		contentType, err := detectContentType(path, file)
+		if err != nil {
+			return err
+		}
+		hdr.ContentType().Set(contentType)
+	}
+
+	return o.Upload(file, &opts.UploadOptions, ropts)
+}
+
+//detectContentType guesses the Content-Type of the given file. The file is
+//rewound to the start afterwards.
+func detectContentType(path string, file *os.File) (string, error) {
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType != "" {
+		return contentType, nil
+	}
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return http.DetectContentType(buf[:n]), err
+}
+
 //DeleteOptions invokes advanced behavior in the Object.Delete() method.
 type DeleteOptions struct {
 	//When deleting a large object, also delete its segments. This will cause
-	//Delete() to call into BulkDelete(), so a BulkError may be returned.
+	//Delete() to call into BulkDelete(), so a BulkError may be returned. Its
+	//ObjectErrors field lists the segments (or the manifest) that could not be
+	//deleted.
+	//
+	//The segments are discovered in the same way as by Object.AsLargeObject():
+	//For static large objects, the manifest is read with
+	//"?multipart-manifest=get". For dynamic large objects, all objects below
+	//the segment prefix from X-Object-Manifest are deleted. For objects that
+	//are not large objects, this option has no effect and a regular DELETE
+	//request is issued.
 	DeleteSegments bool
+	//If set, the deletion is conditional on the If-Match or If-None-Match
+	//header (see below).
+	IfMatch     string
+	IfNoneMatch string
 }
 
 //Delete deletes the object using a DELETE request. To add URL parameters,
@@ -366,26 +1001,42 @@ type DeleteOptions struct {
 //
 //This operation fails with http.StatusNotFound if the object does not exist.
 //
+//If IfMatch or IfNoneMatch is set, the corresponding header is sent with the
+//DELETE request. When the condition fails, Delete() returns an error with
+//status code 412 (Precondition Failed), which can be checked with Is(). Note
+//that stock Swift does not evaluate these headers on DELETE requests;
+//clusters without support for them will delete the object unconditionally.
+//When combined with DeleteSegments, the segments are only deleted after the
+//conditional DELETE of the object itself has succeeded.
+//
 //A successful DELETE request implies Invalidate().
 func (o *Object) Delete(opts *DeleteOptions, ropts *RequestOptions) error {
 	if opts == nil {
 		opts = &DeleteOptions{}
 	}
+	isConditional := opts.IfMatch != "" || opts.IfNoneMatch != ""
+
+	var lo *LargeObject
 	if opts.DeleteSegments {
 		exists, err := o.Exists()
 		if err != nil {
 			return err
 		}
 		if exists {
-			lo, err := o.AsLargeObject()
+			lo, err = o.AsLargeObject()
 			switch err {
 			case nil:
-				//is large object - delete segments and the object itself in one step
-				_, _, err := o.c.a.BulkDelete(append(lo.SegmentObjects(), o), nil, nil)
-				o.Invalidate()
-				return err
+				if !isConditional {
+					//is large object - delete segments and the object itself in one step
+					_, _, err := o.c.a.BulkDelete(append(lo.SegmentObjects(), o), nil, requestOptionsWithContextOnly(ropts))
+					o.Invalidate()
+					return err
+				}
+				//BulkDelete() cannot transport conditions - delete the object first,
+				//then its segments
 			case ErrNotLarge:
 				//not a large object - use regular DELETE request
+				lo = nil
 			default:
 				//unexpected error
 				return err
@@ -393,6 +1044,16 @@ func (o *Object) Delete(opts *DeleteOptions, ropts *RequestOptions) error {
 		}
 	}
 
+	if isConditional {
+		ropts = cloneRequestOptions(ropts, nil)
+		if opts.IfMatch != "" {
+			ropts.Headers.Set("If-Match", opts.IfMatch)
+		}
+		if opts.IfNoneMatch != "" {
+			ropts.Headers.Set("If-None-Match", opts.IfNoneMatch)
+		}
+	}
+
 	_, err := Request{
 		Method:            "DELETE",
 		ContainerName:     o.c.name,
@@ -400,10 +1061,16 @@ func (o *Object) Delete(opts *DeleteOptions, ropts *RequestOptions) error {
 		Options:           ropts,
 		ExpectStatusCodes: []int{204},
 	}.Do(o.c.a.backend)
-	if err == nil {
-		o.Invalidate()
+	if err != nil {
+		return err
 	}
-	return err
+	o.Invalidate()
+
+	if lo != nil {
+		_, _, err := o.c.a.BulkDelete(lo.SegmentObjects(), nil, requestOptionsWithContextOnly(ropts))
+		return err
+	}
+	return nil
 }
 
 //Invalidate clears the internal cache of this Object instance. The next call
@@ -413,6 +1080,192 @@ func (o *Object) Invalidate() {
 	o.symlinkHeaders = nil
 }
 
+//DownloadOptions invokes advanced behavior in the Object.DownloadWithOptions()
+//method.
+//
+//The RangeLength and RangeOffset attributes can be used to download only a part
+//of the object, using the same semantics as in type SegmentInfo:
+//
+//For .RangeLength == 0, all bytes of the object are requested, after skipping
+//the first .RangeOffset bytes. The default (.RangeOffset == 0) is to download
+//the entire object.
+//
+//For .RangeLength > 0, that many bytes are requested, again after skipping the
+//first .RangeOffset bytes.
+//
+//For .RangeOffset < 0, the last .RangeLength bytes of the object are requested.
+//(The concrete value for .RangeOffset is disregarded.) .RangeLength must be
+//non-zero in this case.
+//
+//	//bytes 1024-2047 (as in "Range: bytes=1024-2047")
+//	opts := &schwift.DownloadOptions{RangeOffset: 1024, RangeLength: 1024}
+//	//everything after the first 1024 bytes (as in "Range: bytes=1024-")
+//	opts := &schwift.DownloadOptions{RangeOffset: 1024}
+//	//the last 500 bytes (as in "Range: bytes=-500")
+//	opts := &schwift.DownloadOptions{RangeOffset: -1, RangeLength: 500}
+//
+//If the server responds to a range request with the entire object (status 200
+//instead of 206), DownloadWithOptions() fails with ErrRangeIgnored instead of
+//silently returning more data than requested. To avoid this error, callers can
+//check for range support first, and fall back to a full download:
+//
+//	hdr, err := obj.Headers()
+//	if !hdr.AcceptsRanges() {
+//		opts = nil
+//	}
+//	reader, err := obj.DownloadWithOptions(opts, nil).AsReadCloser()
+//
+//The other attributes make the download conditional by setting the If-Match,
+//If-None-Match, If-Modified-Since and If-Unmodified-Since headers (empty
+//strings and zero timestamps are ignored). When the condition of If-None-Match
+//or If-Modified-Since fails, DownloadWithOptions() returns an error with status
+//code 304 (Not Modified), which can be checked with Is():
+//
+//	str, err := obj.DownloadWithOptions(&schwift.DownloadOptions{
+//		IfNoneMatch: cachedEtag,
+//	}, nil).AsString()
+//	if schwift.Is(err, http.StatusNotModified) {
+//		//object has not changed, use cached content
+//	}
+//
+//When the condition of If-Match or If-Unmodified-Since fails, the error has
+//status code 412 (Precondition Failed) instead.
+//
+//If Progress is set, this callback will be called periodically while the
+//object's content is read from the DownloadedObject. The total size is taken
+//from the Content-Length response header.
+//
+//When the object is a symlink, DownloadWithOptions() returns the contents of
+//the target object by default, and fails with http.StatusNotFound if the target
+//object does not exist. If DoNotFollowSymlinks is set, the symlink itself is
+//downloaded instead. (Its content is empty, but its metadata can be inspected.)
+//
+//Swift follows chains of symlinks only up to a small depth (configured by the
+//operator, 2 by default), and fails with http.StatusConflict beyond that. If
+//ResolveSymlinks is set, DownloadWithOptions() instead resolves the chain on
+//the client side with ResolveSymlinks() (with MaxSymlinkDepth as the maximum
+//depth) and downloads the final target object. The response headers in
+//DownloadedObject.Response() and the header cache of the target object are then
+//filled from the target object. ErrSymlinkLoop or ErrTooManySymlinks is
+//returned if the chain cannot be resolved.
+//
+//If VerifyChecksum is set, the MD5 checksum of the object's content is
+//computed while it is read from the DownloadedObject, and the final read
+//returns ErrChecksumMismatch instead of io.EOF if the checksum does not match
+//the Etag reported by Swift. Verification is skipped silently for partial
+//downloads (see above) and for dynamic large objects, since their Etag is
+//computed from the Etags of their segments, which are listed with eventual
+//consistency. Use ObjectHeaders.IsDynamicLargeObject() to check for the
+//latter case (after the download, the headers are cached).
+//
+//For static large objects, the Etag is also computed from the Etags of their
+//segments, so VerifyChecksum causes an additional GET request for the manifest
+//(before DownloadWithOptions() returns). While the content is read, the MD5
+//checksum of each segment is compared to the segment's Etag from the manifest
+//as soon as the segment is complete, and the composite Etag recomputed from
+//these checksums is compared to the object's Etag at the end. Either mismatch
+//yields ErrChecksumMismatch. The data of segments with ranges, and of segments
+//that are themselves static large objects, cannot be verified since the
+//manifest only has the Etag of the entire segment object; for those, only the
+//length is checked, and the manifest's Etag is used for the composite Etag. If
+//the object is replaced between the two requests, DownloadWithOptions() returns
+//ErrObjectChanged.
+//
+//If DecompressGzip is set and the object has "Content-Encoding: gzip", its
+//content is decompressed while it is read from the DownloadedObject, so
+//AsReadCloser(), AsByteSlice() and AsString() yield the uncompressed data.
+//For objects with any other Content-Encoding, and for partial downloads, this
+//option does nothing. Checksum verification and progress reporting still
+//refer to the compressed data, since that is what the Etag and Content-Length
+//describe.
+//
+//Note that net/http already decompresses gzip-encoded responses on its own
+//if the client did not ask for a specific encoding via the Accept-Encoding
+//request header (see the documentation of http.Transport.DisableCompression).
+//DecompressGzip takes care not to decompress twice in this case, but checksum
+//verification is skipped since the compressed data cannot be observed.
+//
+//If ResumableDownload is set, and reading the content fails with a network
+//error midway, the DownloadedObject transparently issues a new GET request
+//with a Range header for the remaining bytes and continues reading from
+//there. The new request carries an If-Match header with the Etag of the
+//original response, so if the object was changed on the server in the
+//meantime, reading fails with ErrObjectChanged. Up to MaxResumeAttempts
+//consecutive attempts are made to resume (default: 3). Resuming is not
+//possible (and this option does nothing) if the original response has no
+//Etag, or if net/http has transparently decompressed it (see above).
+//Checksum verification, progress reporting and decompression work across
+//resumed requests as if the content had been read in one go.
+type DownloadOptions struct {
+	RangeLength         uint64
+	RangeOffset         int64
+	IfMatch             string
+	IfNoneMatch         string
+	IfModifiedSince     time.Time
+	IfUnmodifiedSince   time.Time
+	Progress            ProgressFunc
+	DoNotFollowSymlinks bool
+	VerifyChecksum      bool
+	DecompressGzip      bool
+	ResumableDownload   bool
+	MaxResumeAttempts   int
+	ResolveSymlinks     bool
+	MaxSymlinkDepth     int
+}
+
+//apply adds the headers for these DownloadOptions to the given request
+//options.
+func (opts *DownloadOptions) apply(ropts *RequestOptions) error {
+	rangeStr, err := opts.rangeHeader()
+	if err != nil {
+		return err
+	}
+	if rangeStr != "" {
+		ropts.Headers.Set("Range", rangeStr)
+	}
+	if opts.IfMatch != "" {
+		ropts.Headers.Set("If-Match", opts.IfMatch)
+	}
+	if opts.IfNoneMatch != "" {
+		ropts.Headers.Set("If-None-Match", opts.IfNoneMatch)
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		ropts.Headers.Set("If-Modified-Since", opts.IfModifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if !opts.IfUnmodifiedSince.IsZero() {
+		ropts.Headers.Set("If-Unmodified-Since", opts.IfUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if opts.DoNotFollowSymlinks {
+		ropts.Values.Set("symlink", "get")
+	}
+	return nil
+}
+
+//rangeHeader returns the value for the Range header, or "" if the entire
+//object shall be downloaded.
+func (opts *DownloadOptions) rangeHeader() (string, error) {
+	if !opts.hasRange() {
+		return "", nil
+	}
+	if opts.RangeOffset < 0 {
+		if opts.RangeLength == 0 {
+			return "", errors.New("invalid range in DownloadOptions: RangeLength must be non-zero for RangeOffset < 0")
+		}
+		return "bytes=-" + strconv.FormatUint(opts.RangeLength, 10), nil
+	}
+	firstByteStr := strconv.FormatUint(uint64(opts.RangeOffset), 10)
+	if opts.RangeLength == 0 {
+		return "bytes=" + firstByteStr + "-", nil
+	}
+	lastByteStr := strconv.FormatUint(uint64(opts.RangeOffset)+opts.RangeLength-1, 10)
+	return "bytes=" + firstByteStr + "-" + lastByteStr, nil
+}
+
+//hasRange returns whether these options request only a part of the object.
+func (opts *DownloadOptions) hasRange() bool {
+	return opts != nil && (opts.RangeOffset != 0 || opts.RangeLength != 0)
+}
+
 //Download retrieves the object's contents using a GET request. This returns a
 //helper object which allows you to select whether you want an io.ReadCloser
 //for reading the object contents progressively, or whether you want the object
@@ -424,29 +1277,96 @@ func (o *Object) Invalidate() {
 //
 //	str, err := object.Download(nil).AsString()
 //
-//See documentation on type DownloadedObject for details.
+//See documentation on type DownloadedObject for details. To add URL
+//parameters or headers, pass a non-nil *RequestOptions. For advanced behavior
+//like range requests or checksum verification, use DownloadWithOptions().
 func (o *Object) Download(opts *RequestOptions) DownloadedObject {
+	return o.DownloadWithOptions(nil, opts)
+}
+
+//DownloadWithOptions is like Download(), but takes a *DownloadOptions to
+//invoke advanced behavior. See documentation on type DownloadOptions for
+//details.
+//
+//When a range is requested through DownloadOptions, Swift answers with 206
+//Partial Content, which is treated as success. The range that was actually
+//returned can be inspected with DownloadedObject.ContentRange(). Since the
+//response headers of a partial download describe only a part of the object,
+//they do not update the cache behind Object.Headers() in this case.
+func (o *Object) DownloadWithOptions(opts *DownloadOptions, ropts *RequestOptions) DownloadedObject {
+	if opts != nil && opts.ResolveSymlinks && !opts.DoNotFollowSymlinks {
+		target, err := o.ResolveSymlinks(opts.MaxSymlinkDepth, ropts)
+		if err != nil {
+			return DownloadedObject{nil, err, nil}
+		}
+		targetOpts := *opts
+		targetOpts.ResolveSymlinks = false
+		return target.DownloadWithOptions(&targetOpts, ropts)
+	}
+	if opts != nil {
+		ropts = cloneRequestOptions(ropts, nil)
+		err := opts.apply(ropts)
+		if err != nil {
+			return DownloadedObject{nil, err, nil}
+		}
+	}
+
 	resp, err := Request{
 		Method:            "GET",
 		ContainerName:     o.c.name,
 		ObjectName:        o.name,
-		Options:           opts,
-		ExpectStatusCodes: []int{200},
+		Options:           ropts,
+		ExpectStatusCodes: []int{200, 206},
 	}.Do(o.c.a.backend)
+	//(a Range header that the caller put into ropts is not checked, just like
+	//any other header)
+	if err == nil && resp.StatusCode == http.StatusOK && opts.hasRange() {
+		resp.Body.Close()
+		err = ErrRangeIgnored
+	}
 	var body io.ReadCloser
 	if err == nil {
 		newHeaders := ObjectHeaders{headersFromHTTP(resp.Header)}
 		err = newHeaders.Validate()
-		if err == nil {
-			if opts != nil && opts.Values != nil && opts.Values.Get("symlink") == "get" {
+		if err == nil && resp.StatusCode == http.StatusOK {
+			if ropts != nil && ropts.Values != nil && ropts.Values.Get("symlink") == "get" {
 				o.symlinkHeaders = &newHeaders
 			} else {
 				o.headers = &newHeaders
 			}
 		}
 		body = resp.Body
+		if opts != nil && opts.ResumableDownload {
+			if r := newResumingReader(o, ropts, resp, opts.MaxResumeAttempts); r != nil {
+				body = r
+			}
+		}
+		if opts != nil && (opts.VerifyChecksum || opts.Progress != nil || opts.DecompressGzip) {
+			var reader io.Reader = body
+			isManifestDownload := ropts != nil && ropts.Values.Get("multipart-manifest") != ""
+			if opts.VerifyChecksum && canVerifyEtag(resp, newHeaders) {
+				reader = &etagVerifyingReader{
+					Reader:       reader,
+					Hasher:       md5.New(),
+					ExpectedEtag: normalizeEtag(newHeaders.Etag().Get()),
+				}
+			} else if opts.VerifyChecksum && !isManifestDownload && canVerifySLOEtag(resp, newHeaders) {
+				reader, err = o.newSLOVerifyingReader(reader, resp, ropts)
+				if err != nil {
+					body.Close()
+					return DownloadedObject{nil, err, nil}
+				}
+			}
+			reader = trackProgress(reader, opts.Progress, resp.ContentLength)
+			if opts.DecompressGzip && isGzipEncoded(resp, newHeaders) {
+				reader = &gzipReader{Reader: reader}
+			}
+			body = readCloser{reader, body}
+		}
+	} else {
+		resp = nil
 	}
-	return DownloadedObject{body, err}
+	return DownloadedObject{body, err, resp}
 }
 
 //CopyOptions invokes advanced behavior in the Object.Copy() method.
@@ -456,13 +1376,29 @@ type CopyOptions struct {
 	FreshMetadata bool
 	//When the source is a symlink, copy the symlink instead of the target object.
 	ShallowCopySymlinks bool
+	//When the source is a large object, copy the manifest instead of the
+	//concatenated contents of its segments. The target will then be a large
+	//object referencing the same segments as the source.
+	CopyManifest bool
 }
 
 //CopyTo copies the object on the server side using a COPY request.
 //
+//The target may be located in a different account on the same Swift cluster
+//(e.g. one obtained through Account.SwitchAccount()), in which case the
+//Destination-Account header is set. The request is always executed through
+//the source object's Backend, so its token must be authorized to read the
+//source object and to write into the target container, e.g. through the
+//target container's write ACL or a reseller admin role. Copying between
+//different Swift clusters is not possible and fails without a request being
+//made.
+//
 //A successful COPY implies target.Invalidate() since it may change the
 //target's metadata.
 func (o *Object) CopyTo(target *Object, opts *CopyOptions, ropts *RequestOptions) error {
+	if o.c.a.baseURL != target.c.a.baseURL {
+		return errors.New("cannot copy objects between different Swift clusters")
+	}
 	ropts = cloneRequestOptions(ropts, nil)
 	ropts.Headers.Set("Destination", target.FullName())
 	if o.c.a.name != target.c.a.name {
@@ -475,6 +1411,9 @@ func (o *Object) CopyTo(target *Object, opts *CopyOptions, ropts *RequestOptions
 		if opts.ShallowCopySymlinks {
 			ropts.Values.Set("symlink", "get")
 		}
+		if opts.CopyManifest {
+			ropts.Values.Set("multipart-manifest", "get")
+		}
 	}
 
 	_, err := Request{
@@ -491,6 +1430,56 @@ func (o *Object) CopyTo(target *Object, opts *CopyOptions, ropts *RequestOptions
 	return err
 }
 
+//MoveOptions invokes advanced behavior in the Object.MoveTo() method.
+type MoveOptions struct {
+	//When the source is a symlink, move the symlink instead of the target object.
+	ShallowCopySymlinks bool
+	//When the source is a large object, move the manifest instead of the
+	//concatenated contents of its segments. The target will then be a large
+	//object referencing the segments of the source, so the segments are never
+	//deleted in this mode.
+	MoveManifest bool
+	//When the source is a large object and MoveManifest is not set, delete its
+	//segments together with the source object. This will cause MoveTo() to call
+	//into BulkDelete(), so a BulkError may be returned inside a MoveError.
+	DeleteSegments bool
+}
+
+//MoveTo moves the object on the server side by copying it to the target with
+//Object.CopyTo(), and deleting the source object once the copy has succeeded.
+//The object's metadata and Content-Type are preserved. New metadata can be
+//supplied in the RequestOptions argument; these are only used for the COPY
+//request, not for the DELETE request.
+//
+//If the copy fails, the source object is left untouched and the error from
+//CopyTo() is returned. If the copy succeeds but the source object cannot be
+//deleted afterwards, a MoveError is returned. In that case, both the source
+//and the target object exist.
+func (o *Object) MoveTo(target *Object, opts *MoveOptions, ropts *RequestOptions) error {
+	if opts == nil {
+		opts = &MoveOptions{}
+	}
+	if o.name == target.name && o.c.isEqualTo(target.c) {
+		return errors.New("cannot move object onto itself")
+	}
+
+	err := o.CopyTo(target, &CopyOptions{
+		ShallowCopySymlinks: opts.ShallowCopySymlinks,
+		CopyManifest:        opts.MoveManifest,
+	}, ropts)
+	if err != nil {
+		return err
+	}
+
+	err = o.Delete(&DeleteOptions{
+		DeleteSegments: opts.DeleteSegments && !opts.MoveManifest,
+	}, requestOptionsWithContextOnly(ropts))
+	if err != nil {
+		return MoveError{Source: o, Target: target, Inner: err}
+	}
+	return nil
+}
+
 //SymlinkOptions invokes advanced behavior in the Object.SymlinkTo() method.
 type SymlinkOptions struct {
 	//When overwriting a large object, delete its segments. This will cause
@@ -526,6 +1515,55 @@ func (o *Object) SymlinkTo(target *Object, opts *SymlinkOptions, ropts *RequestO
 	return o.Upload(nil, uopts, ropts)
 }
 
+//DefaultMaxSymlinkDepth is the maximum number of symlinks that
+//Object.ResolveSymlinks() follows if no other maximum is given.
+const DefaultMaxSymlinkDepth = 10
+
+//ResolveSymlinks follows the chain of symlinks starting at this object, and
+//returns the first object in the chain that is not a symlink. If this object
+//is not a symlink, it is returned unchanged. Each symlink in the chain is
+//inspected like in InspectSymlink(), but since the chain may have changed on
+//the server, one HEAD request per symlink is issued even if the symlink's
+//headers are cached already. Only the context and timeout from opts are used
+//for these requests.
+//
+//At most maxDepth symlinks are followed (or DefaultMaxSymlinkDepth if maxDepth
+//is not positive). When the chain is longer, ErrTooManySymlinks is returned.
+//When the chain refers back to an object that was already visited,
+//ErrSymlinkLoop is returned. If a symlink in the chain points to an object
+//that does not exist, the error has status code 404.
+func (o *Object) ResolveSymlinks(maxDepth int, opts *RequestOptions) (*Object, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxSymlinkDepth
+	}
+	opts = requestOptionsWithContextOnly(opts)
+
+	visited := make(map[string]bool)
+	current := o
+	for depth := 0; ; depth++ {
+		key := current.c.a.Name() + "/" + current.FullName()
+		if visited[key] {
+			return nil, ErrSymlinkLoop
+		}
+		visited[key] = true
+
+		err := current.fetchSymlinkHeaders(opts)
+		if err != nil {
+			return nil, err
+		}
+		target, err := current.symlinkTarget()
+		switch {
+		case err == ErrNotASymlink:
+			return current, nil
+		case err != nil:
+			return nil, err
+		case depth == maxDepth:
+			return nil, ErrTooManySymlinks
+		}
+		current = target
+	}
+}
+
 //InspectSymlink returns the object that this symlink points to, and the
 //metadata of the symlink. ErrNotASymlink is returned if the object is not a
 //symlink.
@@ -533,22 +1571,42 @@ func (o *Object) SymlinkTo(target *Object, opts *SymlinkOptions, ropts *RequestO
 //This operation fails with http.StatusNotFound if the object does not exist.
 func (o *Object) InspectSymlink() (target *Object, headers ObjectHeaders, err error) {
 	if o.symlinkHeaders == nil {
-		o.symlinkHeaders, err = o.fetchHeaders(&RequestOptions{
-			Values: url.Values{"symlink": []string{"get"}},
-		})
+		err = o.fetchSymlinkHeaders(nil)
 		if err != nil {
 			return nil, ObjectHeaders{}, err
 		}
 	}
+	target, err = o.symlinkTarget()
+	if err != nil {
+		return nil, ObjectHeaders{}, err
+	}
+	return target, *o.symlinkHeaders, nil
+}
 
+//fetchSymlinkHeaders fills o.symlinkHeaders with a HEAD request that does
+//not follow the symlink.
+func (o *Object) fetchSymlinkHeaders(opts *RequestOptions) error {
+	opts = cloneRequestOptions(opts, nil)
+	opts.Values.Set("symlink", "get")
+	headers, err := o.fetchHeaders(opts)
+	if err != nil {
+		return err
+	}
+	o.symlinkHeaders = headers
+	return nil
+}
+
+//symlinkTarget returns the object that this symlink points to, according to
+//o.symlinkHeaders. ErrNotASymlink is returned if the object is not a symlink.
+func (o *Object) symlinkTarget() (*Object, error) {
 	//is this a symlink?
 	targetFullName := o.symlinkHeaders.Get("X-Symlink-Target")
 	if targetFullName == "" {
-		return nil, ObjectHeaders{}, ErrNotASymlink
+		return nil, ErrNotASymlink
 	}
 	fields := strings.SplitN(targetFullName, "/", 2)
 	if len(fields) < 2 {
-		return nil, ObjectHeaders{}, MalformedHeaderError{
+		return nil, MalformedHeaderError{
 			Key:        "X-Symlink-Target",
 			ParseError: fmt.Errorf("expected \"container/object\", got \"%s\"", targetFullName),
 		}
@@ -560,6 +1618,5 @@ func (o *Object) InspectSymlink() (target *Object, headers ObjectHeaders, err er
 	if accountName != "" && accountName != targetAccount.Name() {
 		targetAccount = targetAccount.SwitchAccount(accountName)
 	}
-	target = targetAccount.Container(fields[0]).Object(fields[1])
-	return target, *o.symlinkHeaders, nil
+	return targetAccount.Container(fields[0]).Object(fields[1]), nil
 }
@@ -28,6 +28,10 @@ import (
 //object listings. The metadata in this type is a subset of Object.Headers(),
 //but since it is returned as part of the detailed object listing, it can be
 //obtained without making additional HEAD requests on the object(s).
+//
+//The listing does not contain the objects' custom metadata, so the values in
+//an ObjectInfo are not used to fill the header cache of the respective
+//Object. Otherwise Object.Headers() would report incomplete headers.
 type ObjectInfo struct {
 	Object       *Object
 	SizeBytes    uint64
@@ -62,8 +66,21 @@ type ObjectInfo struct {
 //or object names plus some basic metadata fields (via the methods with the
 //"Detailed" suffix). See struct ObjectInfo for which metadata is returned.
 //
-//To obtain any other metadata, you can call Object.Headers() on the result
-//object, but this will issue a separate HEAD request for each object.
+//When only size, Etag, content type and modification time are needed for many
+//objects, a detailed listing is much faster than calling Object.Headers() on
+//each object, since one GET request covers up to 10000 objects (depending on
+//the server's page size limit):
+//
+//	infos, err := container.Objects().CollectDetailed()
+//	for _, info := range infos {
+//		log.Printf("%s: %d bytes, Etag %s, modified at %s",
+//			info.Object.Name(), info.SizeBytes, info.Etag, info.LastModified)
+//	}
+//
+//To obtain any other metadata (most notably custom metadata in
+//X-Object-Meta-* headers, which is not included in listings), you can call
+//Object.Headers() on the result object, but this will issue a separate HEAD
+//request for each object.
 //
 //Use the "Detailed" methods only when you use the extra metadata in struct
 //ObjectInfo; detailed GET requests are more expensive than simple ones that
@@ -81,7 +98,22 @@ type ObjectIterator struct {
 	//prefix, if any) will be condensed into pseudo-directories in the result.
 	//See documentation for Swift for details.
 	Delimiter string
+	//When Marker is set, only objects whose name sorts after this string are
+	//returned.
+	Marker string
+	//When EndMarker is set, only objects whose name sorts before this string
+	//are returned.
+	EndMarker string
+	//When PageSize is > 0, Foreach(), ForeachDetailed(), Collect() and
+	//CollectDetailed() will not request more than this many object names per
+	//GET request. Otherwise, the page size is chosen by the server.
+	PageSize int
 	//Options may contain additional headers and query parameters for the GET request.
+	//
+	//If Options.Context is set, its cancellation aborts the iteration: The
+	//context is checked before each page is fetched and before each callback
+	//invocation in Foreach() and ForeachDetailed(), and an in-flight GET request
+	//is aborted. In all these cases, the context's error is returned.
 	Options *RequestOptions
 
 	base *iteratorBase
@@ -94,6 +126,15 @@ func (i *ObjectIterator) getBase() *iteratorBase {
 	return i.base
 }
 
+//pageLimit returns the limit argument for NextPage() and NextPageDetailed()
+//as used by Foreach() and Collect().
+func (i *ObjectIterator) pageLimit() int {
+	if i.PageSize > 0 {
+		return i.PageSize
+	}
+	return -1
+}
+
 //NextPage queries Swift for the next page of object names. If limit is
 //>= 0, not more than that many object names will be returned at once. Note
 //that the server also has a limit for how many objects to list in one
@@ -116,6 +157,16 @@ func (i *ObjectIterator) NextPage(limit int) ([]*Object, error) {
 	return result, nil
 }
 
+//NextPageNames is like NextPage, but returns the object names as strings
+//instead of constructing an *Object for each of them. Like NextPage, this uses
+//the plain-text listing format, which is much cheaper to produce and parse than
+//the JSON format used by NextPageDetailed. This is useful when only the names
+//are needed, e.g. for building a set of keys to compare against, or for feeding
+//a bulk delete.
+func (i *ObjectIterator) NextPageNames(limit int) ([]string, error) {
+	return i.getBase().nextPage(limit)
+}
+
 //The symlink_path attribute looks like "/v1/AUTH_foo/containername/obje/ctna/me".
 var symlinkPathRx = regexp.MustCompile(`^/v1/([^/]+)/([^/]+)/(.+)$`)
 
@@ -182,9 +233,13 @@ func (i *ObjectIterator) NextPageDetailed(limit int) ([]ObjectInfo, error) {
 //Foreach lists the object names matching this iterator and calls the
 //callback once for every object. Iteration is aborted when a GET request fails,
 //or when the callback returns a non-nil error.
+//
+//Pages are fetched lazily as the callback consumes them, so only one page of
+//object names is held in memory at a time. Use PageSize to control how many
+//object names are fetched per request.
 func (i *ObjectIterator) Foreach(callback func(*Object) error) error {
 	for {
-		objects, err := i.NextPage(-1)
+		objects, err := i.NextPage(i.pageLimit())
 		if err != nil {
 			return err
 		}
@@ -192,6 +247,9 @@ func (i *ObjectIterator) Foreach(callback func(*Object) error) error {
 			return nil //EOF
 		}
 		for _, o := range objects {
+			if err := i.getBase().contextError(); err != nil {
+				return err
+			}
 			err := callback(o)
 			if err != nil {
 				return err
@@ -200,10 +258,33 @@ func (i *ObjectIterator) Foreach(callback func(*Object) error) error {
 	}
 }
 
+//ForeachName is like Foreach, but calls the callback with the object names as
+//strings. See NextPageNames for details.
+func (i *ObjectIterator) ForeachName(callback func(string) error) error {
+	for {
+		names, err := i.NextPageNames(i.pageLimit())
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil //EOF
+		}
+		for _, name := range names {
+			if err := i.getBase().contextError(); err != nil {
+				return err
+			}
+			err := callback(name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 //ForeachDetailed is like Foreach, but includes basic metadata.
 func (i *ObjectIterator) ForeachDetailed(callback func(ObjectInfo) error) error {
 	for {
-		infos, err := i.NextPageDetailed(-1)
+		infos, err := i.NextPageDetailed(i.pageLimit())
 		if err != nil {
 			return err
 		}
@@ -211,6 +292,9 @@ func (i *ObjectIterator) ForeachDetailed(callback func(ObjectInfo) error) error
 			return nil //EOF
 		}
 		for _, ci := range infos {
+			if err := i.getBase().contextError(); err != nil {
+				return err
+			}
 			err := callback(ci)
 			if err != nil {
 				return err
@@ -225,7 +309,7 @@ func (i *ObjectIterator) ForeachDetailed(callback func(ObjectInfo) error) error
 func (i *ObjectIterator) Collect() ([]*Object, error) {
 	var result []*Object
 	for {
-		objects, err := i.NextPage(-1)
+		objects, err := i.NextPage(i.pageLimit())
 		if err != nil {
 			return nil, err
 		}
@@ -236,11 +320,27 @@ func (i *ObjectIterator) Collect() ([]*Object, error) {
 	}
 }
 
+//CollectNames is like Collect, but returns the object names as strings. See
+//NextPageNames for details.
+func (i *ObjectIterator) CollectNames() ([]string, error) {
+	var result []string
+	for {
+		names, err := i.NextPageNames(i.pageLimit())
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return result, nil //EOF
+		}
+		result = append(result, names...)
+	}
+}
+
 //CollectDetailed is like Collect, but includes basic metadata.
 func (i *ObjectIterator) CollectDetailed() ([]ObjectInfo, error) {
 	var result []ObjectInfo
 	for {
-		infos, err := i.NextPageDetailed(-1)
+		infos, err := i.NextPageDetailed(i.pageLimit())
 		if err != nil {
 			return nil, err
 		}
@@ -250,3 +350,23 @@ func (i *ObjectIterator) CollectDetailed() ([]ObjectInfo, error) {
 		result = append(result, infos...)
 	}
 }
+
+//CollectSubdirectories lists the pseudo-directories matching this iterator,
+//and skips all actual objects. This is only useful when Delimiter is set;
+//otherwise Swift does not report any pseudo-directories, and the result will
+//be empty. For example, to list the top-level directories below "foo/":
+//
+//	iter := container.Objects()
+//	iter.Prefix = "foo/"
+//	iter.Delimiter = "/"
+//	subdirs, err := iter.CollectSubdirectories() //e.g. ["foo/bar/", "foo/baz/"]
+func (i *ObjectIterator) CollectSubdirectories() ([]string, error) {
+	var result []string
+	err := i.ForeachDetailed(func(info ObjectInfo) error {
+		if info.SubDirectory != "" {
+			result = append(result, info.SubDirectory)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,753 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadOptionsRangeHeader(t *testing.T) {
+	testCases := []struct {
+		opts   *DownloadOptions
+		ok     bool
+		header string
+	}{
+		{nil, true, ""},
+		{&DownloadOptions{}, true, ""},
+		{&DownloadOptions{RangeOffset: 0, RangeLength: 500}, true, "bytes=0-499"},
+		{&DownloadOptions{RangeOffset: 500, RangeLength: 500}, true, "bytes=500-999"},
+		{&DownloadOptions{RangeOffset: -1, RangeLength: 500}, true, "bytes=-500"},
+		{&DownloadOptions{RangeOffset: 9500}, true, "bytes=9500-"},
+		{&DownloadOptions{RangeOffset: 0, RangeLength: 1}, true, "bytes=0-0"},
+		{&DownloadOptions{RangeOffset: -1}, false, ""},
+	}
+
+	for _, tc := range testCases {
+		header, err := tc.opts.rangeHeader()
+		if tc.ok && err != nil {
+			t.Errorf("expected %#v to produce a Range header, but got error: %s", tc.opts, err.Error())
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("expected %#v to fail, but got Range header %q", tc.opts, header)
+		}
+		if header != tc.header {
+			t.Errorf("expected %#v to produce Range header %q, but got %q", tc.opts, tc.header, header)
+		}
+	}
+}
+
+func TestCompressGzip(t *testing.T) {
+	for _, content := range []string{"", "hello world"} {
+		compressed, err := compressGzip(strings.NewReader(content), gzip.BestCompression)
+		if err != nil {
+			t.Fatalf("expected success, got error %q", err.Error())
+		}
+		r, err := gzip.NewReader(compressed)
+		if err != nil {
+			t.Fatalf("expected gzip stream, got error %q", err.Error())
+		}
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Errorf("expected success, got error %q", err.Error())
+		}
+		if string(buf) != content {
+			t.Errorf("expected content %q, got %q", content, string(buf))
+		}
+	}
+
+	_, err := compressGzip(nil, 42)
+	if err == nil {
+		t.Error("expected invalid compression level to be rejected")
+	}
+
+	//closing the reader early must not leave the goroutine hanging
+	compressed, err := compressGzip(strings.NewReader(strings.Repeat("x", 1<<20)), gzip.NoCompression)
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+	compressed.Close()
+}
+
+func TestDetectContentType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schwift-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"style.css", "body {}", "text/css; charset=utf-8"},
+		{"no-extension", "<html><body></body></html>", "text/html; charset=utf-8"},
+		{"unknown.schwifty", "\x00\x01\x02", "application/octet-stream"},
+		{"empty", "", "text/plain; charset=utf-8"},
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(dir, tc.name)
+		err := ioutil.WriteFile(path, []byte(tc.content), 0644)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		actual, err := detectContentType(path, file)
+		if err != nil {
+			t.Errorf("expected success for %s, got error %q", tc.name, err.Error())
+		}
+		if actual != tc.expected {
+			t.Errorf("expected Content-Type %q for %s, got %q", tc.expected, tc.name, actual)
+		}
+		//file must be rewound for the upload
+		buf, err := ioutil.ReadAll(file)
+		if err != nil || string(buf) != tc.content {
+			t.Errorf("expected file %s to be rewound, but read %q (error: %v)", tc.name, string(buf), err)
+		}
+		file.Close()
+	}
+}
+
+//endpointServer records all requests and answers them with 201 Created.
+type endpointServer struct {
+	requests []*http.Request
+}
+
+func (s *endpointServer) handle(req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, req)
+	return testResponse(req, 201, nil, ""), nil
+}
+
+func TestSpoolToDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schwift-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	//wrap the reader to hide its io.Seeker implementation
+	content := "hello world"
+	file, etag, size, err := spoolToDisk(ioutil.NopCloser(strings.NewReader(content)), dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if etag != "5eb63bbbe01eeed093cb22bb8f5acdc3" {
+		t.Errorf("expected Etag of %q, got %q", content, etag)
+	}
+	if size != uint64(len(content)) {
+		t.Errorf("expected size %d, got %d", len(content), size)
+	}
+	buf, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(buf) != content {
+		t.Errorf("expected spooled content %q, got %q", content, string(buf))
+	}
+
+	removeSpoolFile(file)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected spool file to be removed, but found %d files", len(entries))
+	}
+
+	_, _, _, err = spoolToDisk(strings.NewReader(content), filepath.Join(dir, "does-not-exist"))
+	if err == nil {
+		t.Error("expected error for nonexistent spool directory")
+	}
+}
+
+//updateServer answers HEAD requests with a fixed Content-Type (or with 403 if
+//HeadForbidden is set), and records the headers of all POST requests.
+type updateServer struct {
+	HeadForbidden bool
+	heads         int
+	posts         []http.Header
+}
+
+func (s *updateServer) handle(req *http.Request) (*http.Response, error) {
+	if req.Method != "HEAD" {
+		s.posts = append(s.posts, req.Header)
+		return testResponse(req, 202, nil, ""), nil
+	}
+	s.heads++
+	if s.HeadForbidden {
+		return testResponse(req, 403, nil, ""), nil
+	}
+	return testResponse(req, 200, http.Header{
+		"Content-Type":      {"image/png"},
+		"X-Object-Meta-Old": {"1"},
+	}, ""), nil
+}
+
+func TestObjectUpdateContentType(t *testing.T) {
+	server := &updateServer{}
+	a := newTestAccount(t, server.handle)
+	obj := a.Container("c").Object("o")
+
+	testCases := []struct {
+		opts     *UpdateOptions
+		given    string
+		expected string
+	}{
+		{nil, "", "image/png"},
+		{&UpdateOptions{MetadataMode: MetadataMerge}, "", "image/png"},
+		{nil, "text/plain", "text/plain"},
+		{&UpdateOptions{ResetContentType: true}, "", ""},
+	}
+	for idx, tc := range testCases {
+		hdr := NewObjectHeaders()
+		hdr.Metadata().Set("New", "2")
+		if tc.given != "" {
+			hdr.ContentType().Set(tc.given)
+		}
+		err := obj.UpdateWithOptions(hdr, tc.opts, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		post := server.posts[len(server.posts)-1]
+		if actual := post.Get("Content-Type"); actual != tc.expected {
+			t.Errorf("test case %d: expected Content-Type %q, got %q", idx, tc.expected, actual)
+		}
+		if hdr.ContentType().Get() != tc.given {
+			t.Errorf("test case %d: Update() modified the given headers", idx)
+		}
+	}
+}
+
+func TestObjectUpdateContentTypeWithoutHead(t *testing.T) {
+	//when the headers are cached, no HEAD request is needed
+	server := &updateServer{}
+	a := newTestAccount(t, server.handle)
+	obj := a.Container("c").Object("o")
+	_, err := obj.Headers()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hdr := NewObjectHeaders()
+	hdr.Metadata().Set("New", "2")
+	err = obj.Update(hdr, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if server.heads != 1 {
+		t.Errorf("expected only 1 HEAD request (from Headers()), got %d", server.heads)
+	}
+	if actual := server.posts[0].Get("Content-Type"); actual != "image/png" {
+		t.Errorf("expected Content-Type %q, got %q", "image/png", actual)
+	}
+
+	//when HEAD is forbidden, a plain POST is sent
+	server = &updateServer{HeadForbidden: true}
+	a = newTestAccount(t, server.handle)
+	err = a.Container("c").Object("o").Update(hdr, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(server.posts) != 1 {
+		t.Fatalf("expected 1 POST request, got %d", len(server.posts))
+	}
+	if actual := server.posts[0].Get("Content-Type"); actual != "" {
+		t.Errorf("expected no Content-Type, got %q", actual)
+	}
+}
+
+func TestUploadSendContentMD5(t *testing.T) {
+	server := &endpointServer{}
+	a := newTestAccount(t, server.handle)
+	obj := a.Container("c").Object("o")
+
+	testCases := []struct {
+		content  io.Reader
+		expected string
+	}{
+		//Etag computed in advance -> Content-MD5 is sent
+		{strings.NewReader("hello world"), "XrY7u+Ae7tCTyyK7j1rNww=="},
+		//Etag not known in advance -> Content-MD5 cannot be sent
+		{ioutil.NopCloser(strings.NewReader("hello world")), ""},
+	}
+	for _, tc := range testCases {
+		err := obj.Upload(tc.content, &UploadOptions{SendContentMD5: true}, nil)
+		//endpointServer does not report an Etag, so on-the-fly verification fails
+		if err != nil && err != ErrChecksumMismatch {
+			t.Fatal(err.Error())
+		}
+		req := server.requests[len(server.requests)-1]
+		if actual := req.Header.Get("Content-Md5"); actual != tc.expected {
+			t.Errorf("expected Content-MD5 %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+//uploadHandler answers PUT requests like Swift would, by reading the request
+//body and reporting its MD5 checksum in the Etag header.
+func uploadHandler(req *http.Request) (*http.Response, error) {
+	hasher := md5.New()
+	if req.Body != nil {
+		_, err := io.Copy(hasher, req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return testResponse(req, 201, http.Header{"Etag": {hex.EncodeToString(hasher.Sum(nil))}}, ""), nil
+}
+
+func TestUploadReturning(t *testing.T) {
+	a := newTestAccount(t, uploadHandler)
+	obj := a.Container("c").Object("o")
+
+	testCases := []io.Reader{
+		//size known in advance
+		strings.NewReader("hello world"),
+		//size not known in advance
+		ioutil.NopCloser(strings.NewReader("hello world")),
+	}
+	for idx, content := range testCases {
+		result, err := obj.UploadReturning(content, nil, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expected := UploadResult{BytesWritten: 11, Etag: "5eb63bbbe01eeed093cb22bb8f5acdc3"}
+		if result != expected {
+			t.Errorf("test case %d: expected %#v, got %#v", idx, expected, result)
+		}
+	}
+}
+
+func TestUploadExpireAfter(t *testing.T) {
+	server := &endpointServer{}
+	a := newTestAccount(t, server.handle)
+	obj := a.Container("c").Object("o")
+
+	testCases := map[time.Duration]string{
+		7 * 24 * time.Hour:      "604800",
+		1500 * time.Millisecond: "2",
+		time.Nanosecond:         "1",
+		0:                       "",
+	}
+	for expireAfter, expected := range testCases {
+		err := obj.Upload(nil, &UploadOptions{ExpireAfter: expireAfter}, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		req := server.requests[len(server.requests)-1]
+		actual := req.Header.Get("X-Delete-After")
+		if actual != expected {
+			t.Errorf("expected X-Delete-After: %q for ExpireAfter = %s, got %q", expected, expireAfter, actual)
+		}
+	}
+
+	numRequests := len(server.requests)
+	err := obj.Upload(nil, &UploadOptions{ExpireAfter: -time.Second}, nil)
+	if err == nil {
+		t.Error("expected error for negative ExpireAfter")
+	}
+	if len(server.requests) != numRequests {
+		t.Error("expected no request for negative ExpireAfter")
+	}
+}
+
+func TestUploadContentLength(t *testing.T) {
+	//this server records how the request body was sent
+	var (
+		transferEncodings []string
+		bodies            []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		transferEncodings = append(transferEncodings, strings.Join(r.TransferEncoding, ","))
+		bodies = append(bodies, string(buf))
+		sum := md5.Sum(buf)
+		w.Header().Set("Etag", hex.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	a, err := InitializeAccount(NewTokenBackend(server.URL+"/v1/AUTH_test/", &countingTokenProvider{}, nil))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	obj := a.Container("c").Object("o")
+
+	//without ContentLength, content of unknown size is sent with chunked encoding
+	err = obj.Upload(opaqueReader{strings.NewReader("hello world")}, nil, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	//with ContentLength, it is sent as a regular request body
+	err = obj.Upload(opaqueReader{strings.NewReader("hello world")}, &UploadOptions{ContentLength: 11}, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(transferEncodings, "|") != "chunked|" {
+		t.Errorf("expected transfer encodings [chunked, none], got %q", transferEncodings)
+	}
+	if strings.Join(bodies, "|") != "hello world|hello world" {
+		t.Errorf("expected request bodies to contain \"hello world\", got %q", bodies)
+	}
+
+	//content that is shorter or longer than announced is not stored
+	bodies = nil
+	for _, length := range []int64{10, 12} {
+		err = obj.Upload(opaqueReader{strings.NewReader("hello world")}, &UploadOptions{ContentLength: length}, nil)
+		if err != ErrContentLengthMismatch {
+			t.Errorf("expected ErrContentLengthMismatch for ContentLength = %d, got %v", length, err)
+		}
+	}
+	if len(bodies) != 0 {
+		t.Errorf("expected no complete request bodies, got %q", bodies)
+	}
+
+	err = obj.Upload(nil, &UploadOptions{ContentLength: 11, CompressGzip: true}, nil)
+	if err == nil {
+		t.Error("expected error for ContentLength combined with CompressGzip")
+	}
+}
+
+func TestUploadFromPipe(t *testing.T) {
+	//this server behaves like Swift in that it rejects content that does not
+	//match the Etag request header
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, string(buf))
+		sum := md5.Sum(buf)
+		etag := hex.EncodeToString(sum[:])
+		if expected := r.Header.Get("Etag"); expected != "" && expected != etag {
+			http.Error(w, "Etag mismatch", http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Etag", etag)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	a, err := InitializeAccount(NewTokenBackend(server.URL+"/v1/AUTH_test/", &countingTokenProvider{}, nil))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	obj := a.Container("c").Object("o")
+
+	//an *os.File referring to a pipe implements io.Seeker, but cannot seek
+	for _, opts := range []*UploadOptions{nil, {SpoolToDisk: true}} {
+		bodies = nil
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		go func() {
+			writer.Write([]byte("hello world"))
+			writer.Close()
+		}()
+		err = obj.Upload(reader, opts, nil)
+		reader.Close()
+		if err != nil {
+			t.Errorf("expected Upload() with %#v to succeed, got error %q", opts, err.Error())
+		}
+		if len(bodies) != 1 || bodies[0] != "hello world" {
+			t.Errorf("expected Upload() with %#v to send \"hello world\", got %q", opts, bodies)
+		}
+	}
+}
+
+func TestUploadFromFileWithRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schwift-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hello.txt")
+	err = ioutil.WriteFile(path, []byte("hello world"), 0666)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	//unlike testBackend, a real HTTP round trip closes the request body,
+	//so this checks that the retry can still read the file
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		buf, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, string(buf))
+		if len(bodies) == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	a, err := InitializeAccount(policy.Wrap(NewTokenBackend(server.URL+"/v1/AUTH_test/", &countingTokenProvider{}, nil)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = a.Container("c").Object("o").UploadFromFile(path, nil, nil)
+	if err != nil {
+		t.Errorf("expected UploadFromFile() to succeed, got error %q", err.Error())
+	}
+	if len(bodies) != 2 || bodies[0] != "hello world" || bodies[1] != "hello world" {
+		t.Errorf("expected UploadFromFile() to send \"hello world\" twice, got %q", bodies)
+	}
+}
+
+//encryptionInfoServer is an endpointServer whose /info endpoint reports
+//that the encryption middleware is enabled.
+type encryptionInfoServer struct {
+	endpointServer
+}
+
+func (s *encryptionInfoServer) handle(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/info" {
+		return s.endpointServer.handle(req)
+	}
+	return testResponse(req, 200, nil, `{"encryption":{"enabled":true}}`), nil
+}
+
+func TestUploadChecksumVerification(t *testing.T) {
+	testCases := []struct {
+		handler      testHandler
+		verification ChecksumVerification
+		expectedErr  error
+	}{
+		{(&endpointServer{}).handle, VerifyChecksumAlways, ErrChecksumMismatch},
+		{(&endpointServer{}).handle, VerifyChecksumNever, nil},
+		//endpointServer does not answer /info with 200, so checksums are verified
+		{(&endpointServer{}).handle, VerifyChecksumAuto, ErrChecksumMismatch},
+		{(&encryptionInfoServer{}).handle, VerifyChecksumAuto, nil},
+	}
+	for idx, tc := range testCases {
+		a := newTestAccount(t, tc.handler)
+		a.SetUploadChecksumVerification(tc.verification)
+		//endpointServer does not report an Etag, so verification always fails
+		err := a.Container("c").Object("o").Upload(ioutil.NopCloser(strings.NewReader("hello")), nil, nil)
+		if err != tc.expectedErr {
+			t.Errorf("test case %d: expected error %v, got %v", idx, tc.expectedErr, err)
+		}
+	}
+
+	//a failed /info request is not repeated for every upload
+	server := &endpointServer{}
+	a := newTestAccount(t, server.handle)
+	a.SetUploadChecksumVerification(VerifyChecksumAuto)
+	for idx := 0; idx < 2; idx++ {
+		err := a.Container("c").Object("o").Upload(ioutil.NopCloser(strings.NewReader("hello")), nil, nil)
+		if err != ErrChecksumMismatch {
+			t.Errorf("expected ErrChecksumMismatch, got %v", err)
+		}
+	}
+	numInfoRequests := 0
+	for _, req := range server.requests {
+		if req.URL.Path == "/info" {
+			numInfoRequests++
+		}
+	}
+	if numInfoRequests != 1 {
+		t.Errorf("expected 1 request to /info, got %d", numInfoRequests)
+	}
+}
+
+func TestCopyAcrossAccounts(t *testing.T) {
+	server := &endpointServer{}
+	a := newTestAccount(t, server.handle)
+	source := a.Container("c1").Object("o1")
+
+	//copy to another account on the same cluster
+	target := a.SwitchAccount("AUTH_bar").Container("c2").Object("o2")
+	err := source.CopyTo(target, nil, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := server.requests[len(server.requests)-1]
+	if req.URL.String() != "https://swift.example.com/v1/AUTH_test/c1/o1" {
+		t.Errorf("unexpected COPY request URL: %s", req.URL.String())
+	}
+	if req.Header.Get("Destination") != "c2/o2" || req.Header.Get("Destination-Account") != "AUTH_bar" {
+		t.Errorf("unexpected COPY request headers: %#v", req.Header)
+	}
+
+	//copy to another cluster is rejected without a request
+	other, err := InitializeAccount(testBackend{endpointURL: "https://swift.example.org/v1/AUTH_test/"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	numRequests := len(server.requests)
+	err = source.CopyTo(other.Container("c2").Object("o2"), nil, nil)
+	if err == nil || err.Error() != "cannot copy objects between different Swift clusters" {
+		t.Errorf("expected cluster mismatch error, got %#v", err)
+	}
+	if len(server.requests) != numRequests {
+		t.Error("expected no request for copy across clusters")
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	a, err := InitializeAccount(testBackend{endpointURL: "https://swift.example.com/v1/AUTH_abc/"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	obj := a.Container("docs").Object("2018-02-10/invoice #1.pdf")
+
+	urlStr, err := obj.URL()
+	expected := "https://swift.example.com/v1/AUTH_abc/docs/2018-02-10/invoice%20%231.pdf"
+	if err != nil || urlStr != expected {
+		t.Errorf("expected URL() = %q, got %q (error: %v)", expected, urlStr, err)
+	}
+
+	for _, baseURL := range []string{"https://cdn.example.com/public", "https://cdn.example.com/public/"} {
+		urlStr, err = obj.PublicURL(baseURL)
+		expected = "https://cdn.example.com/public/docs/2018-02-10/invoice%20%231.pdf"
+		if err != nil || urlStr != expected {
+			t.Errorf("expected PublicURL(%q) = %q, got %q (error: %v)", baseURL, expected, urlStr, err)
+		}
+	}
+}
+
+//symlinkServer serves objects in the container "c". Objects listed in
+//Symlinks are symlinks to the given "container/object" target, all other
+//objects have the content "content of <name>".
+type symlinkServer struct {
+	Symlinks map[string]string
+}
+
+func (s symlinkServer) handle(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	name := strings.TrimPrefix(req.URL.Path, "/v1/AUTH_test/c/")
+	hdr := http.Header{"X-Object-Meta-Name": {name}}
+	content := "content of " + name
+	if target, ok := s.Symlinks[name]; ok {
+		hdr.Set("X-Symlink-Target", target)
+		content = ""
+	}
+	if req.Method == "HEAD" {
+		content = ""
+	}
+	return testResponse(req, 200, hdr, content), nil
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	server := symlinkServer{map[string]string{
+		"chain1": "c/chain2",
+		"chain2": "c/chain3",
+		"chain3": "c/target",
+		"loop1":  "c/loop2",
+		"loop2":  "c/loop1",
+		"self":   "c/self",
+	}}
+	a := newTestAccount(t, server.handle)
+	c := a.Container("c")
+
+	testCases := []struct {
+		name        string
+		maxDepth    int
+		expected    string
+		expectedErr error
+	}{
+		{"target", 0, "target", nil},
+		{"chain1", 0, "target", nil},
+		{"chain3", 1, "target", nil},
+		{"chain1", 3, "target", nil},
+		{"chain1", 2, "", ErrTooManySymlinks},
+		{"loop1", 0, "", ErrSymlinkLoop},
+		{"self", 0, "", ErrSymlinkLoop},
+	}
+	for _, tc := range testCases {
+		target, err := c.Object(tc.name).ResolveSymlinks(tc.maxDepth, nil)
+		if err != tc.expectedErr {
+			t.Errorf("ResolveSymlinks(%d) on %q: expected error %v, got %v", tc.maxDepth, tc.name, tc.expectedErr, err)
+		}
+		if err == nil && target.Name() != tc.expected {
+			t.Errorf("ResolveSymlinks(%d) on %q: expected %q, got %q", tc.maxDepth, tc.name, tc.expected, target.Name())
+		}
+	}
+
+	//Download() with ResolveSymlinks reports the final object's headers
+	dl := c.Object("chain1").DownloadWithOptions(&DownloadOptions{ResolveSymlinks: true}, nil)
+	str, err := dl.AsString()
+	if err != nil || str != "content of target" {
+		t.Errorf("expected to download %q, got %q (error: %v)", "content of target", str, err)
+	}
+	actual := dl.Response().Header.Get("X-Object-Meta-Name")
+	if actual != "target" {
+		t.Errorf("expected response headers of %q, got headers of %q", "target", actual)
+	}
+
+	_, err = c.Object("loop1").DownloadWithOptions(&DownloadOptions{ResolveSymlinks: true}, nil).AsString()
+	if err != ErrSymlinkLoop {
+		t.Errorf("expected ErrSymlinkLoop from Download(), got %v", err)
+	}
+
+	//the context from the RequestOptions is used for resolving the chain
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Object("chain1").DownloadWithOptions(&DownloadOptions{ResolveSymlinks: true}, &RequestOptions{Context: ctx}).AsString()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from Download(), got %v", err)
+	}
+
+	//cached symlink headers are not used for resolving the chain, since the
+	//symlink may have been changed in the meantime
+	obj := c.Object("chain1")
+	_, _, err = obj.InspectSymlink()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server.Symlinks["chain1"] = "c/target"
+	target, err := obj.ResolveSymlinks(1, nil)
+	if err != nil || target.Name() != "target" {
+		t.Errorf("expected ResolveSymlinks() to see the new symlink target, got %v (error: %v)", target, err)
+	}
+}
@@ -0,0 +1,124 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RequestEvent describes a single HTTP request that has been executed by a
+//Backend. It is passed to a RequestObserver.
+type RequestEvent struct {
+	Method string
+	URL    string
+	//StatusCode is 0 if the backend did not return a response. In that case,
+	//Err contains the error returned by the backend.
+	StatusCode int
+	Err        error
+	//BytesSent and BytesReceived are the sizes of the request body and response
+	//body, respectively, as declared in their Content-Length. They are -1 if
+	//the size is not known in advance (e.g. for chunked transfers).
+	BytesSent     int64
+	BytesReceived int64
+	//Duration is the time until the response headers have been received. Since
+	//response bodies are streamed to the caller, the time spent reading them is
+	//not included.
+	Duration time.Duration
+	//Attempt counts the attempts made by a RetryPolicy for the same request.
+	//It is 1 for the initial attempt, and larger than 1 for retries.
+	Attempt int
+}
+
+//RequestObserver is a callback that is invoked after each HTTP request, e.g.
+//to collect metrics. To apply a RequestObserver to a Backend, use its Wrap()
+//method before passing the Backend into InitializeAccount(). When using
+//Gophercloud, set the RequestObserver attribute in gopherschwift.Options
+//instead.
+//
+//	observer := schwift.RequestObserver(func(e schwift.RequestEvent) {
+//	    log.Printf("%s %s -> %d (%s)", e.Method, e.URL, e.StatusCode, e.Duration)
+//	})
+//	account, err := schwift.InitializeAccount(observer.Wrap(backend))
+//
+//When combined with a RetryPolicy, the RequestObserver should be applied
+//first, so that it sees every single attempt:
+//
+//	account, err := schwift.InitializeAccount(policy.Wrap(observer.Wrap(backend)))
+//
+//The observer may be called from multiple goroutines at once when the Account
+//is used concurrently.
+type RequestObserver func(event RequestEvent)
+
+//Wrap returns a Backend that executes requests on the given Backend and
+//reports them to this observer.
+func (o RequestObserver) Wrap(b Backend) Backend {
+	return &observingBackend{inner: b, observer: o}
+}
+
+type observingBackend struct {
+	inner    Backend
+	observer RequestObserver
+}
+
+//EndpointURL implements the Backend interface.
+func (b *observingBackend) EndpointURL() string {
+	return b.inner.EndpointURL()
+}
+
+//Clone implements the Backend interface.
+func (b *observingBackend) Clone(newEndpointURL string) Backend {
+	return &observingBackend{inner: b.inner.Clone(newEndpointURL), observer: b.observer}
+}
+
+//Do implements the Backend interface.
+func (b *observingBackend) Do(req *http.Request) (*http.Response, error) {
+	startedAt := time.Now()
+	resp, err := b.inner.Do(req)
+
+	event := RequestEvent{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Err:           err,
+		BytesSent:     requestContentLength(req),
+		BytesReceived: -1,
+		Duration:      time.Since(startedAt),
+		Attempt:       requestAttempt(req),
+	}
+	if resp != nil {
+		event.StatusCode = resp.StatusCode
+		event.BytesReceived = resp.ContentLength
+	}
+	b.observer(event)
+
+	return resp, err
+}
+
+func requestContentLength(req *http.Request) int64 {
+	if req.ContentLength > 0 || req.Body == nil || req.Body == http.NoBody {
+		return req.ContentLength
+	}
+	//Request.Do() leaves the Content-Length to the caller-supplied headers
+	value, err := strconv.ParseInt(req.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestObserver(t *testing.T) {
+	var events []RequestEvent
+	observer := RequestObserver(func(e RequestEvent) {
+		events = append(events, e)
+	})
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	server := &scriptedServer{statusCodes: []int{503, 0, 201}}
+	_, err := Request{
+		Method:        "PUT",
+		ContainerName: "foo",
+		ObjectName:    "bar",
+		Body:          strings.NewReader("hello"),
+	}.Do(policy.Wrap(observer.Wrap(testBackend{handler: server.handle})))
+	if err != nil {
+		t.Fatalf("expected success, got error %q", err.Error())
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for idx, e := range events {
+		if e.Method != "PUT" {
+			t.Errorf("event %d: expected method PUT, got %q", idx, e.Method)
+		}
+		if e.URL != "https://swift.example.com/v1/AUTH_test/foo/bar" {
+			t.Errorf("event %d: unexpected URL %q", idx, e.URL)
+		}
+		if e.Attempt != idx+1 {
+			t.Errorf("event %d: expected attempt %d, got %d", idx, idx+1, e.Attempt)
+		}
+		if e.BytesSent != 5 {
+			t.Errorf("event %d: expected 5 bytes sent, got %d", idx, e.BytesSent)
+		}
+	}
+	if events[0].StatusCode != 503 || events[0].Err != nil {
+		t.Errorf("event 0: expected status 503 without error, got %d and %v", events[0].StatusCode, events[0].Err)
+	}
+	if events[1].StatusCode != 0 || events[1].Err != errScriptedNetworkFailure {
+		t.Errorf("event 1: expected network error, got %d and %v", events[1].StatusCode, events[1].Err)
+	}
+	if events[2].StatusCode != 201 || events[2].Err != nil {
+		t.Errorf("event 2: expected status 201 without error, got %d and %v", events[2].StatusCode, events[2].Err)
+	}
+
+	//unknown body size
+	events = nil
+	server = &scriptedServer{statusCodes: []int{201}}
+	Request{
+		Method:        "PUT",
+		ContainerName: "foo",
+		ObjectName:    "bar",
+		Body:          opaqueReader{strings.NewReader("hello")},
+	}.Do(observer.Wrap(testBackend{handler: server.handle}))
+	if len(events) != 1 || events[0].BytesSent != -1 || events[0].Attempt != 1 {
+		t.Errorf("expected one event with unknown size on first attempt, got %#v", events)
+	}
+}
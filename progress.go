@@ -0,0 +1,115 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"io"
+	"sync"
+)
+
+//ProgressFunc is a callback that can be passed to Object.Upload(),
+//Object.DownloadWithOptions() and Object.UploadLarge() via UploadOptions,
+//DownloadOptions and UploadLargeOptions, respectively, to observe the
+//progress of a transfer. It is called whenever a chunk of data has been
+//transferred, with the number of bytes transferred so far and the total number
+//of bytes to transfer, or -1 if the total size is not known.
+//
+//	opts := &schwift.DownloadOptions{
+//	    Progress: func(bytesTransferred, totalBytes int64) {
+//	        log.Printf("downloaded %d of %d bytes", bytesTransferred, totalBytes)
+//	    },
+//	}
+//	_, err := obj.DownloadWithOptions(opts, nil).AsByteSlice()
+//
+//The callback is called synchronously from io.Reader.Read(), so it should
+//return quickly.
+type ProgressFunc func(bytesTransferred, totalBytes int64)
+
+//progressReader is an io.Reader that reports progress to a ProgressFunc.
+type progressReader struct {
+	Reader     io.Reader
+	Progress   ProgressFunc
+	TotalBytes int64
+	bytesRead  int64
+}
+
+func (r *progressReader) Read(buf []byte) (int, error) {
+	n, err := r.Reader.Read(buf)
+	if n > 0 {
+		r.bytesRead += int64(n)
+		r.Progress(r.bytesRead, r.TotalBytes)
+	}
+	return n, err
+}
+
+//progressReadSeeker is a progressReader for an io.ReadSeeker. This is needed
+//to allow Request.Do() to rewind the request body (e.g. for retries).
+type progressReadSeeker struct {
+	progressReader
+	startPos int64
+}
+
+func (r *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.Reader.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		r.bytesRead = pos - r.startPos
+	}
+	return pos, err
+}
+
+//trackProgress wraps the given io.Reader such that it reports progress to the
+//given ProgressFunc (if not nil).
+func trackProgress(r io.Reader, progress ProgressFunc, totalBytes int64) io.Reader {
+	if r == nil || progress == nil {
+		return r
+	}
+	pr := progressReader{Reader: r, Progress: progress, TotalBytes: totalBytes}
+	if s, ok := r.(io.Seeker); ok {
+		startPos, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			return &progressReadSeeker{pr, startPos}
+		}
+	}
+	return &pr
+}
+
+//progressAggregator combines the progress reports of multiple uploads (which
+//may run concurrently) into reports for a single ProgressFunc.
+type progressAggregator struct {
+	Callback         ProgressFunc
+	mutex            sync.Mutex
+	bytesTransferred int64
+}
+
+//Track returns a ProgressFunc for a single upload, or nil if no callback has
+//been set.
+func (p *progressAggregator) Track() ProgressFunc {
+	if p.Callback == nil {
+		return nil
+	}
+	var reported int64
+	return func(bytesTransferred, totalBytes int64) {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		//the difference may be negative when a request body has been rewound
+		p.bytesTransferred += bytesTransferred - reported
+		reported = bytesTransferred
+		p.Callback(p.bytesTransferred, -1)
+	}
+}
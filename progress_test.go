@@ -0,0 +1,109 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type progressReport struct {
+	bytesTransferred int64
+	totalBytes       int64
+}
+
+func TestTrackProgress(t *testing.T) {
+	var reports []progressReport
+	progress := func(bytesTransferred, totalBytes int64) {
+		reports = append(reports, progressReport{bytesTransferred, totalBytes})
+	}
+
+	expectReports := func(expected ...progressReport) {
+		t.Helper()
+		if len(reports) != len(expected) {
+			t.Errorf("expected progress reports %v, got %v", expected, reports)
+			return
+		}
+		for idx, r := range reports {
+			if r != expected[idx] {
+				t.Errorf("expected progress reports %v, got %v", expected, reports)
+				return
+			}
+		}
+	}
+
+	//non-seekable reader with unknown size
+	reports = nil
+	r := trackProgress(opaqueReader{strings.NewReader("hello world")}, progress, -1)
+	buf := make([]byte, 5)
+	io.ReadFull(r, buf)
+	ioutil.ReadAll(r)
+	expectReports(progressReport{5, -1}, progressReport{11, -1})
+	if _, ok := r.(io.Seeker); ok {
+		t.Error("expected opaque reader to stay non-seekable")
+	}
+
+	//seekable reader that has been partially consumed before wrapping: progress
+	//is relative to the starting position, and rewinding resets the progress
+	reports = nil
+	sr := strings.NewReader("hello world")
+	sr.Seek(6, io.SeekStart)
+	r = trackProgress(sr, progress, 5)
+	ioutil.ReadAll(r)
+	r.(io.Seeker).Seek(6, io.SeekStart)
+	ioutil.ReadAll(r)
+	expectReports(progressReport{5, 5}, progressReport{5, 5})
+
+	//no callback -> no wrapping
+	sr = strings.NewReader("hello world")
+	if trackProgress(sr, nil, -1) != io.Reader(sr) {
+		t.Error("expected trackProgress() to return the original reader when no callback is given")
+	}
+}
+
+func TestProgressAggregator(t *testing.T) {
+	var reports []progressReport
+	p := &progressAggregator{Callback: func(bytesTransferred, totalBytes int64) {
+		reports = append(reports, progressReport{bytesTransferred, totalBytes})
+	}}
+
+	first, second := p.Track(), p.Track()
+	first(3, 10)
+	second(4, 10)
+	first(10, 10)
+	second(0, 10) //rewound for a retry
+	second(10, 10)
+
+	expected := []progressReport{{3, -1}, {7, -1}, {14, -1}, {10, -1}, {20, -1}}
+	if len(reports) != len(expected) {
+		t.Fatalf("expected progress reports %v, got %v", expected, reports)
+	}
+	for idx, r := range reports {
+		if r != expected[idx] {
+			t.Fatalf("expected progress reports %v, got %v", expected, reports)
+		}
+	}
+
+	//no callback -> no tracking
+	if (&progressAggregator{}).Track() != nil {
+		t.Error("expected Track() to return nil when no callback is given")
+	}
+}
@@ -0,0 +1,142 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "strings"
+
+//Pseudodirectory is a view on the objects in a container whose names start with
+//a common prefix ending in "/". It encapsulates the Prefix and Delimiter
+//handling of ObjectIterator for applications that present a container as a
+//filesystem tree. It is constructed with the Container.Pseudodirectory()
+//method. For example:
+//
+//	dir := container.Pseudodirectory("photos/2018/")
+//	files, err := dir.Objects(nil)    //e.g. "photos/2018/cat.jpg"
+//	subdirs, err := dir.Subdirectories(nil) //e.g. "photos/2018/summer/"
+//	for _, subdir := range subdirs {
+//	    files, err := subdir.Objects(nil)
+//	    ...
+//	}
+//
+//Swift does not store pseudo-directories; they exist only by virtue of there
+//being objects whose names start with the prefix. Constructing a
+//Pseudodirectory therefore never issues any requests.
+type Pseudodirectory struct {
+	c      *Container
+	prefix string
+}
+
+//Pseudodirectory returns a view on the objects in this container whose names
+//start with the given prefix. A "/" is appended to the prefix if it does not
+//end in one already. An empty prefix refers to the root of the container.
+func (c *Container) Pseudodirectory(prefix string) *Pseudodirectory {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Pseudodirectory{c: c, prefix: prefix}
+}
+
+//Container returns the container in which this pseudo-directory is located.
+func (d *Pseudodirectory) Container() *Container {
+	return d.c
+}
+
+//Prefix returns the name prefix of this pseudo-directory, e.g. "photos/2018/".
+//It is the empty string for the root of the container.
+func (d *Pseudodirectory) Prefix() string {
+	return d.prefix
+}
+
+//Name returns the last path element of this pseudo-directory, e.g. "2018" for
+//the prefix "photos/2018/". It is the empty string for the root of the
+//container.
+func (d *Pseudodirectory) Name() string {
+	trimmed := strings.TrimSuffix(d.prefix, "/")
+	return trimmed[strings.LastIndex(trimmed, "/")+1:]
+}
+
+//Object returns a handle to the object with the given name below this
+//pseudo-directory. For example, dir.Object("cat.jpg") refers to
+//"photos/2018/cat.jpg" if the prefix of dir is "photos/2018/".
+func (d *Pseudodirectory) Object(name string) *Object {
+	return d.c.Object(d.prefix + name)
+}
+
+//Subdirectory returns the pseudo-directory with the given name below this
+//one. For example, dir.Subdirectory("summer") has the prefix
+//"photos/2018/summer/" if the prefix of dir is "photos/2018/".
+func (d *Pseudodirectory) Subdirectory(name string) *Pseudodirectory {
+	return d.c.Pseudodirectory(d.prefix + name)
+}
+
+//Parent returns the pseudo-directory containing this one, or nil if this is
+//the root of the container.
+func (d *Pseudodirectory) Parent() *Pseudodirectory {
+	if d.prefix == "" {
+		return nil
+	}
+	trimmed := strings.TrimSuffix(d.prefix, "/")
+	return d.c.Pseudodirectory(trimmed[:strings.LastIndex(trimmed, "/")+1])
+}
+
+//iterator returns an ObjectIterator for the direct children of this
+//pseudo-directory.
+func (d *Pseudodirectory) iterator(opts *RequestOptions) *ObjectIterator {
+	return &ObjectIterator{
+		Container: d.c,
+		Prefix:    d.prefix,
+		Delimiter: "/",
+		Options:   opts,
+	}
+}
+
+//Objects lists the objects located directly in this pseudo-directory, but not
+//in any of its subdirectories. If an object exists whose name is equal to the
+//prefix (as a marker for an otherwise empty directory), it is not included
+//in the result.
+//
+//If opts is not nil, it may contain additional headers and query parameters
+//for the GET requests (see ObjectIterator.Options).
+func (d *Pseudodirectory) Objects(opts *RequestOptions) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	err := d.iterator(opts).ForeachDetailed(func(info ObjectInfo) error {
+		if info.SubDirectory == "" && info.Object.Name() != d.prefix {
+			result = append(result, info)
+		}
+		return nil
+	})
+	return result, err
+}
+
+//Subdirectories lists the pseudo-directories located directly in this
+//pseudo-directory.
+//
+//If opts is not nil, it may contain additional headers and query parameters
+//for the GET requests (see ObjectIterator.Options).
+func (d *Pseudodirectory) Subdirectories(opts *RequestOptions) ([]*Pseudodirectory, error) {
+	prefixes, err := d.iterator(opts).CollectSubdirectories()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Pseudodirectory, len(prefixes))
+	for idx, prefix := range prefixes {
+		result[idx] = d.c.Pseudodirectory(prefix)
+	}
+	return result, nil
+}
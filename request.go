@@ -19,11 +19,14 @@
 package schwift
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 //RequestOptions is used to pass additional headers and values to a request.
@@ -38,9 +41,59 @@ import (
 //	hdr.Metadata().Set("color", "blue")
 //	opts := hdr.ToOpts() //type *schwift.RequestOptions
 //
+//To make a request cancellable, or to enforce a deadline on it, set the
+//Context attribute. When the context is cancelled or its deadline is exceeded
+//while the request is in flight, the HTTP request is aborted and the method
+//returns ctx.Err(). For example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	err := obj.Upload(content, nil, &schwift.RequestOptions{Context: ctx})
+//	if err == context.DeadlineExceeded {
+//	    log.Print("upload took too long")
+//	}
+//
+//When a method makes multiple requests (e.g. UploadLarge or
+//DeleteRecursively), Timeout applies to each request individually, while
+//a deadline on Context applies to the method call as a whole. Both can be
+//combined, e.g. to use a short Timeout for metadata operations:
+//
+//	err := obj.Update(hdr, &schwift.RequestOptions{Timeout: 5 * time.Second})
+//	if err == context.DeadlineExceeded {
+//	    log.Print("update took too long")
+//	}
+//
+//If Context is nil, context.Background() is used. Methods that do not accept a
+//RequestOptions argument (e.g. Object.Headers()) also use
+//context.Background(). For Headers(), use FetchHeaders() instead to pass
+//RequestOptions.
+//
+//Likewise, the Headers attribute can carry arbitrary headers that the typed
+//Headers types do not cover, e.g. headers required by forked Swift variants.
+//These are added to the request as-is (after checking that the header names
+//are valid). Headers that need to be sent with every request are better
+//configured on the Backend, e.g. via TokenBackendOptions.ExtraHeaders or
+//gopherschwift.Options.ExtraHeaders.
+//
+//The Values attribute is an escape hatch for query parameters that Schwift
+//does not model (yet), e.g. for features of newer Swift versions or of
+//deployment-specific middlewares. The values are appended to the URL of the
+//request as-is. Note that Schwift sets some query parameters on its own in
+//some methods (e.g. "format" and "marker" in iterators), which take
+//precedence over the values given here. For example:
+//
+//	opts := &schwift.RequestOptions{Values: url.Values{}}
+//	opts.Values.Set("multipart-manifest", "get")
+//	manifest, err := obj.Download(opts).AsByteSlice()
 type RequestOptions struct {
 	Headers Headers
 	Values  url.Values
+	Context context.Context
+	//If Timeout is > 0, each request made with these options is aborted when
+	//it takes longer than this duration, as if Context had a deadline that
+	//starts when the request is sent. The timeout covers reading the response
+	//body, so for downloads, it needs to account for the transfer time.
+	Timeout time.Duration
 }
 
 func cloneRequestOptions(orig *RequestOptions, additional Headers) *RequestOptions {
@@ -49,6 +102,8 @@ func cloneRequestOptions(orig *RequestOptions, additional Headers) *RequestOptio
 		Values:  make(url.Values),
 	}
 	if orig != nil {
+		result.Context = orig.Context
+		result.Timeout = orig.Timeout
 		for k, v := range orig.Headers {
 			result.Headers[k] = v
 		}
@@ -62,6 +117,17 @@ func cloneRequestOptions(orig *RequestOptions, additional Headers) *RequestOptio
 	return &result
 }
 
+//This is used when a request method needs to make additional requests (e.g.
+//for deleting large object segments) that shall be cancellable through the
+//caller's context (and subject to the caller's timeout), but shall not receive
+//the caller's headers and query parameters.
+func requestOptionsWithContextOnly(orig *RequestOptions) *RequestOptions {
+	if orig == nil || (orig.Context == nil && orig.Timeout <= 0) {
+		return nil
+	}
+	return &RequestOptions{Context: orig.Context, Timeout: orig.Timeout}
+}
+
 //Request contains the parameters that can be set in a request to the Swift API.
 type Request struct {
 	Method        string //"GET", "HEAD", "PUT", "POST" or "DELETE"
@@ -79,7 +145,13 @@ type Request struct {
 
 //URL returns the full URL for this request.
 func (r Request) URL(backend Backend, values url.Values) (string, error) {
-	uri, err := url.Parse(backend.EndpointURL())
+	return r.urlBelow(backend.EndpointURL(), values)
+}
+
+//urlBelow is like URL, but uses the given base URL instead of the backend's
+//endpoint URL.
+func (r Request) urlBelow(baseURL string, values url.Values) (string, error) {
+	uri, err := url.Parse(baseURL)
 	if err != nil {
 		return "", err
 	}
@@ -104,6 +176,57 @@ func (r Request) URL(backend Backend, values url.Values) (string, error) {
 
 //Do executes this request on the given Backend.
 func (r Request) Do(backend Backend) (*http.Response, error) {
+	ctx := context.Background()
+	if r.Options != nil && r.Options.Context != nil {
+		ctx = r.Options.Context
+	}
+	if r.Options == nil || r.Options.Timeout <= 0 {
+		return r.do(ctx, backend)
+	}
+
+	//the timeout also applies to reading the response body, so the context can
+	//only be released once the body has been closed
+	ctx, cancel := context.WithTimeout(ctx, r.Options.Timeout)
+	resp, err := r.do(ctx, backend)
+	if err != nil || r.DrainResponseBody || resp.StatusCode == 204 {
+		cancel()
+		return resp, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+//cancelOnClose wraps a response body, and releases the request's context when
+//the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+//Prepare constructs the *http.Request that Do() would send to the given
+//Backend, but does not send it. This is useful for inspecting the URL, headers
+//and body of a request, e.g. in unit tests for code using schwift. The
+//request's context is taken from r.Options.Context, but r.Options.Timeout is
+//not applied.
+//
+//If r.Body is an io.ReadSeeker, req.GetBody can be used to obtain a fresh
+//copy of the body after req.Body has been read, and closing req.Body does not
+//close r.Body. Otherwise, reading req.Body consumes r.Body.
+func (r Request) Prepare(backend Backend) (*http.Request, error) {
+	ctx := context.Background()
+	if r.Options != nil && r.Options.Context != nil {
+		ctx = r.Options.Context
+	}
+	return r.prepare(ctx, backend)
+}
+
+func (r Request) prepare(ctx context.Context, backend Backend) (*http.Request, error) {
 	//build URL
 	var values url.Values
 	if r.Options != nil {
@@ -114,23 +237,73 @@ func (r Request) Do(backend Backend) (*http.Response, error) {
 		return nil, err
 	}
 
-	//build request
-	req, err := http.NewRequest(r.Method, uri, r.Body)
+	//build request (net/http closes the body after sending it, but a seekable
+	//body like *os.File belongs to the caller and may be rewound by GetBody)
+	body := r.Body
+	if _, ok := body.(io.Closer); ok {
+		if seeker, ok := body.(io.ReadSeeker); ok {
+			body = nonClosingReadSeeker{seeker}
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, uri, body)
 	if err != nil {
 		return nil, err
 	}
 
 	if r.Options != nil {
 		for k, v := range r.Options.Headers {
+			err := validateHeaderName(k)
+			if err != nil {
+				return nil, MalformedHeaderError{k, err}
+			}
 			req.Header[k] = []string{v}
 		}
 	}
 	if r.Body != nil {
 		req.Header.Set("Expect", "100-continue")
+		//net/http only sends the Content-Length from req.ContentLength, which it
+		//can only fill by itself for some types of r.Body; without this, bodies
+		//of unknown length would be sent with chunked transfer encoding
+		if req.ContentLength == 0 && req.Header.Get("Content-Length") != "" {
+			length, err := strconv.ParseInt(req.Header.Get("Content-Length"), 10, 64)
+			if err == nil && length > 0 {
+				req.ContentLength = length
+			}
+		}
+	}
+	//allow backends to re-send the request body (e.g. for retries), if
+	//http.NewRequest() could not arrange for that already
+	if seeker, ok := r.Body.(io.ReadSeeker); ok && req.GetBody == nil {
+		startPos, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				_, err := seeker.Seek(startPos, io.SeekStart)
+				return ioutil.NopCloser(seeker), err
+			}
+		}
+	}
+	return req, nil
+}
+
+//nonClosingReadSeeker hides the Close() method of an io.ReadSeeker that is
+//used as a request body, see Request.prepare().
+type nonClosingReadSeeker struct {
+	io.ReadSeeker
+}
+
+func (r Request) do(ctx context.Context, backend Backend) (*http.Response, error) {
+	req, err := r.prepare(ctx, backend)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := backend.Do(req)
 	if err != nil {
+		//report cancellation as such, instead of as whatever error the HTTP
+		//client generated from it
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -158,6 +331,7 @@ func (r Request) Do(backend Backend) (*http.Response, error) {
 		ExpectedStatusCodes: r.ExpectStatusCodes,
 		ActualResponse:      resp,
 		ResponseBody:        buf,
+		TransID:             resp.Header.Get("X-Trans-Id"),
 	}
 }
 
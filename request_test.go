@@ -0,0 +1,125 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+//slowServer answers requests after the given delay, unless the request's
+//context is done before that. The response body fails to read once the
+//request's context is done, like a real HTTP response body would.
+type slowServer struct {
+	Delay time.Duration
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   *strings.Reader
+}
+
+func (r contextReader) Read(buf []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(buf)
+}
+
+func (s slowServer) handle(req *http.Request) (*http.Response, error) {
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-time.After(s.Delay):
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(contextReader{req.Context(), strings.NewReader("hello")}),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	a := newTestAccount(t, slowServer{Delay: 50 * time.Millisecond}.handle)
+	obj := a.Container("foo").Object("bar")
+
+	_, err := obj.Download(&RequestOptions{Timeout: 5 * time.Millisecond}).AsString()
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %#v", err)
+	}
+
+	//the timeout must not expire when Do() returns, only once the body is closed
+	str, err := obj.Download(&RequestOptions{Timeout: time.Second}).AsString()
+	if err != nil || str != "hello" {
+		t.Errorf("expected download to succeed, got %q (error: %v)", str, err)
+	}
+}
+
+func TestRequestPrepare(t *testing.T) {
+	req, err := Request{
+		Method:        "PUT",
+		ContainerName: "foo",
+		ObjectName:    "bar",
+		Options: &RequestOptions{
+			Headers: Headers{"X-Object-Meta-Color": "blue"},
+			Values:  url.Values{"multipart-manifest": {"put"}},
+		},
+		Body: strings.NewReader("hello"),
+	}.Prepare(testBackend{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if req.Method != "PUT" {
+		t.Errorf("expected method PUT, got %q", req.Method)
+	}
+	expectedURL := "https://swift.example.com/v1/AUTH_test/foo/bar?multipart-manifest=put"
+	if req.URL.String() != expectedURL {
+		t.Errorf("expected URL %q, got %q", expectedURL, req.URL.String())
+	}
+	if req.Header.Get("X-Object-Meta-Color") != "blue" {
+		t.Errorf("expected metadata header, got %#v", req.Header)
+	}
+
+	//the body can be read repeatedly through GetBody
+	for idx := 0; idx < 2; idx++ {
+		body := req.Body
+		if idx > 0 {
+			body, err = req.GetBody()
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+		buf, err := ioutil.ReadAll(body)
+		if err != nil || string(buf) != "hello" {
+			t.Errorf("expected body %q, got %q (error: %v)", "hello", string(buf), err)
+		}
+	}
+
+	_, err = Request{Method: "GET", ObjectName: "bar"}.Prepare(testBackend{})
+	if err != ErrNoContainerName {
+		t.Errorf("expected ErrNoContainerName, got %#v", err)
+	}
+}
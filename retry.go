@@ -0,0 +1,235 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RetryPolicy describes how failed requests shall be retried. To apply a
+//RetryPolicy to a Backend, use its Wrap() method before passing the Backend
+//into InitializeAccount(). When using Gophercloud, set the RetryPolicy
+//attribute in gopherschwift.Options instead.
+//
+//	policy := schwift.RetryPolicy{
+//	    MaxAttempts: 5,
+//	    BaseDelay:   200 * time.Millisecond,
+//	}
+//	account, err := schwift.InitializeAccount(policy.Wrap(backend))
+//
+//Only idempotent requests are retried: GET, HEAD and DELETE requests are
+//always eligible, PUT requests only when their body can be re-read from the
+//start. This is the case for bodies that are nil, a *bytes.Reader, a
+//*bytes.Buffer, a *strings.Reader or any other io.ReadSeeker (e.g. *os.File).
+//Other request methods (esp. POST and COPY) are never retried.
+//
+//Between attempts, the policy waits for BaseDelay, then twice as long, then
+//four times as long, and so on, but never longer than MaxDelay (if set). The
+//wait is aborted when the request's context expires.
+//
+//If the failed response carries a Retry-After header (as sent by Swift's
+//ratelimit middleware, see IsRateLimited()), the policy waits at least as long
+//as the server requested. If that is longer than MaxDelay, the request is not
+//retried, and the rate-limited response is returned to the caller instead.
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts, including the initial one.
+	//Values <= 1 disable retrying.
+	MaxAttempts int
+	//BaseDelay is the delay before the first retry. Defaults to 100 ms.
+	BaseDelay time.Duration
+	//If MaxDelay is > 0, delays between attempts will not exceed this value.
+	MaxDelay time.Duration
+	//ShouldRetry decides whether a failed attempt shall be retried. It receives
+	//exactly one non-nil argument: The response if the server returned one, or
+	//the error returned by the backend if not. The response body must not be
+	//read. Defaults to IsTransientFailure.
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+//IsTransientFailure is the default predicate for RetryPolicy.ShouldRetry. It
+//considers network errors, the status codes 500 (Internal Server Error), 502
+//(Bad Gateway), 503 (Service Unavailable) and 504 (Gateway Timeout), as well
+//as rate-limited responses (498 and 429, see IsRateLimited()) as transient.
+func IsTransientFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return isRateLimitedStatus(resp.StatusCode)
+	}
+}
+
+//Wrap returns a Backend that executes requests on the given Backend and
+//retries them according to this policy.
+func (p RetryPolicy) Wrap(b Backend) Backend {
+	return &retryBackend{inner: b, policy: p}
+}
+
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	for idx := 1; idx < retry; idx++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "DELETE":
+		return true
+	case "PUT":
+		return canResendRequest(req)
+	default:
+		return false
+	}
+}
+
+type retryBackend struct {
+	inner  Backend
+	policy RetryPolicy
+}
+
+//EndpointURL implements the Backend interface.
+func (b *retryBackend) EndpointURL() string {
+	return b.inner.EndpointURL()
+}
+
+//Clone implements the Backend interface.
+func (b *retryBackend) Clone(newEndpointURL string) Backend {
+	return &retryBackend{inner: b.inner.Clone(newEndpointURL), policy: b.policy}
+}
+
+//Do implements the Backend interface.
+func (b *retryBackend) Do(req *http.Request) (*http.Response, error) {
+	shouldRetry := b.policy.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = IsTransientFailure
+	}
+	ctx := req.Context()
+	canRetry := isRetryableRequest(req) && ctx.Value(noRetryKey{}) == nil
+
+	for attempt := 1; ; attempt++ {
+		resp, err := b.inner.Do(req)
+		if attempt >= b.policy.MaxAttempts || !canRetry || ctx.Err() != nil {
+			return resp, err
+		}
+		if err != nil {
+			resp = nil //only pass one non-nil argument to ShouldRetry
+		}
+		if !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		//honor the server's request to back off, unless it wants us to wait
+		//longer than we are willing to
+		delay := b.policy.delay(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+				if b.policy.MaxDelay > 0 && retryAfter > b.policy.MaxDelay {
+					return resp, err
+				}
+				if retryAfter > delay {
+					delay = retryAfter
+				}
+			}
+		}
+
+		//discard the failed response before trying again
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		//rewind the request body for the next attempt
+		var body io.ReadCloser
+		if req.GetBody != nil {
+			var err error
+			body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+		req = req.Clone(context.WithValue(ctx, retryAttemptKey{}, attempt+1))
+		if body != nil {
+			req.Body = body
+		}
+	}
+}
+
+//parseRetryAfter parses the Retry-After header, which contains either a
+//number of seconds or an HTTP date.
+func parseRetryAfter(hdr http.Header, now time.Time) (time.Duration, bool) {
+	value := hdr.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+//retryAttemptKey is the context key that retryBackend uses to tell inner
+//backends (esp. an observingBackend) which attempt they are looking at.
+type retryAttemptKey struct{}
+
+func requestAttempt(req *http.Request) int {
+	attempt, ok := req.Context().Value(retryAttemptKey{}).(int)
+	if !ok {
+		return 1
+	}
+	return attempt
+}
+
+//noRetryKey is the context key that tells retryBackend not to retry a
+//request, e.g. for Account.Ping().
+type noRetryKey struct{}
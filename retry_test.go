@@ -0,0 +1,375 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errScriptedNetworkFailure = errors.New("connection reset by peer")
+
+//scriptedServer answers requests with the given sequence of status codes (0
+//means a network error), and records the bodies of all requests it has seen.
+//All responses carry the given headers.
+type scriptedServer struct {
+	statusCodes []int
+	header      http.Header
+	bodies      []string
+}
+
+func (s *scriptedServer) handle(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		buf, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(buf)
+	}
+	s.bodies = append(s.bodies, body)
+
+	if len(s.statusCodes) == 0 {
+		panic("scriptedServer received more requests than expected")
+	}
+	code := s.statusCodes[0]
+	s.statusCodes = s.statusCodes[1:]
+	if code == 0 {
+		return nil, errScriptedNetworkFailure
+	}
+	header := make(http.Header)
+	for key, values := range s.header {
+		header[key] = append([]string(nil), values...)
+	}
+	return testResponse(req, code, header, ""), nil
+}
+
+//opaqueReader hides all methods of the wrapped reader except for Read().
+type opaqueReader struct {
+	r io.Reader
+}
+
+func (r opaqueReader) Read(buf []byte) (int, error) {
+	return r.r.Read(buf)
+}
+
+//seekableReader is an io.ReadSeeker that http.NewRequest() does not recognize.
+type seekableReader struct {
+	r io.ReadSeeker
+}
+
+func (r seekableReader) Read(buf []byte) (int, error) {
+	return r.r.Read(buf)
+}
+
+func (r seekableReader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	testCases := []struct {
+		method      string
+		body        io.Reader
+		statusCodes []int
+		expectCode  int //0 means expect network error
+		expectCount int
+	}{
+		//success without retry
+		{"GET", nil, []int{200}, 200, 1},
+		//transient failures are retried
+		{"GET", nil, []int{503, 0, 200}, 200, 3},
+		{"HEAD", nil, []int{502, 204}, 204, 2},
+		{"DELETE", nil, []int{0, 204}, 204, 2},
+		//until MaxAttempts is exhausted
+		{"GET", nil, []int{503, 503, 503}, 503, 3},
+		{"GET", nil, []int{0, 0, 0}, 0, 3},
+		//non-transient failures are not retried
+		{"GET", nil, []int{404}, 404, 1},
+		{"PUT", nil, []int{422}, 422, 1},
+		//non-idempotent requests are not retried
+		{"POST", nil, []int{503}, 503, 1},
+		{"COPY", nil, []int{503}, 503, 1},
+		//PUT is only retried when the body can be re-read
+		{"PUT", nil, []int{503, 201}, 201, 2},
+		{"PUT", strings.NewReader("hello"), []int{503, 201}, 201, 2},
+		{"PUT", bytes.NewReader([]byte("hello")), []int{0, 201}, 201, 2},
+		{"PUT", seekableReader{strings.NewReader("hello")}, []int{0, 503, 201}, 201, 3},
+		{"PUT", opaqueReader{strings.NewReader("hello")}, []int{503}, 503, 1},
+	}
+
+	for idx, tc := range testCases {
+		server := &scriptedServer{statusCodes: tc.statusCodes}
+		resp, err := Request{
+			Method:        tc.method,
+			ContainerName: "foo",
+			ObjectName:    "bar",
+			Body:          tc.body,
+		}.Do(policy.Wrap(testBackend{handler: server.handle}))
+
+		if tc.expectCode == 0 {
+			if err == nil || !strings.Contains(err.Error(), errScriptedNetworkFailure.Error()) {
+				t.Errorf("testcase %d: expected network error, got resp = %v, err = %v", idx, resp, err)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("testcase %d: unexpected error: %s", idx, err.Error())
+			} else if resp.StatusCode != tc.expectCode {
+				t.Errorf("testcase %d: expected status %d, got %d", idx, tc.expectCode, resp.StatusCode)
+			}
+		}
+		if len(server.bodies) != tc.expectCount {
+			t.Errorf("testcase %d: expected %d attempts, got %d", idx, tc.expectCount, len(server.bodies))
+		}
+
+		//every attempt must have sent the complete body
+		if tc.body != nil {
+			for attempt, body := range server.bodies {
+				if body != "hello" {
+					t.Errorf("testcase %d: expected attempt %d to send body %q, got %q",
+						idx, attempt+1, "hello", body)
+				}
+			}
+		}
+	}
+}
+
+func TestRetryPolicyOverHTTP(t *testing.T) {
+	//unlike scriptedServer, a real HTTP round trip closes the request body, so
+	//this checks that bodies can still be replayed after a failed attempt
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		buf, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, string(buf))
+		if len(bodies) == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	a, err := InitializeAccount(policy.Wrap(NewTokenBackend(server.URL+"/v1/AUTH_test/", &countingTokenProvider{}, nil)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	obj := a.Container("foo").Object("bar")
+
+	dir, err := ioutil.TempDir("", "schwift-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hello.txt")
+	err = ioutil.WriteFile(path, []byte("hello"), 0666)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testCases := []struct {
+		name   string
+		upload func() error
+	}{
+		{"*os.File", func() error {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return obj.Upload(file, nil, nil)
+		}},
+		{"SpoolToDisk", func() error {
+			content := opaqueReader{strings.NewReader("hello")}
+			return obj.Upload(content, &UploadOptions{SpoolToDisk: true}, nil)
+		}},
+	}
+
+	for _, tc := range testCases {
+		bodies = nil
+		err := tc.upload()
+		if err != nil {
+			t.Errorf("%s: expected upload to succeed after retry, got %q", tc.name, err.Error())
+		}
+		if len(bodies) != 2 || bodies[0] != "hello" || bodies[1] != "hello" {
+			t.Errorf("%s: expected body \"hello\" to be sent twice, got %q", tc.name, bodies)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	testCases := []struct {
+		policy RetryPolicy
+		delays []time.Duration
+	}{
+		{RetryPolicy{}, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}},
+		{RetryPolicy{BaseDelay: time.Second, MaxDelay: 3 * time.Second}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+	for _, tc := range testCases {
+		for idx, expected := range tc.delays {
+			actual := tc.policy.delay(idx + 1)
+			if actual != expected {
+				t.Errorf("expected %#v to wait %s before retry %d, but got %s", tc.policy, expected, idx+1, actual)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyWithCancelledContext(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}
+	server := &scriptedServer{statusCodes: []int{503}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := Request{
+		Method:  "GET",
+		Options: &RequestOptions{Context: ctx},
+	}.Do(policy.Wrap(testBackend{handler: server.handle}))
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(server.bodies) != 1 {
+		t.Errorf("expected 1 attempt, got %d", len(server.bodies))
+	}
+}
+
+func TestAccountPingDoesNotRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	server := &scriptedServer{statusCodes: []int{503, 204}}
+	a, err := InitializeAccount(policy.Wrap(testBackend{handler: server.handle}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = a.Ping(context.Background())
+	if !Is(err, http.StatusServiceUnavailable) {
+		t.Errorf("expected 503 from Ping(), got %v", err)
+	}
+	if len(server.bodies) != 1 {
+		t.Errorf("expected 1 attempt, got %d", len(server.bodies))
+	}
+
+	//a nil context is accepted as well
+	err = a.Ping(nil)
+	if err != nil {
+		t.Errorf("expected Ping() to succeed, got %v", err)
+	}
+}
+
+func TestRetryPolicyCustomPredicate(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		ShouldRetry: func(resp *http.Response, err error) bool {
+			return resp != nil && resp.StatusCode == http.StatusTooManyRequests
+		},
+	}
+	server := &scriptedServer{statusCodes: []int{429, 503}}
+	resp, err := Request{Method: "GET"}.Do(policy.Wrap(testBackend{handler: server.handle}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.StatusCode != 503 {
+		t.Errorf("expected status 503, got %d", resp.StatusCode)
+	}
+	if len(server.bodies) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(server.bodies))
+	}
+}
+
+func TestRetryPolicyHonorsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	server := &scriptedServer{
+		statusCodes: []int{498, 200},
+		header:      http.Header{"Retry-After": {"1"}},
+	}
+	start := time.Now()
+	resp, err := Request{Method: "GET", ExpectStatusCodes: []int{200}}.Do(policy.Wrap(testBackend{handler: server.handle}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected retry to wait for at least 1s, but only waited %s", elapsed)
+	}
+	if len(server.bodies) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(server.bodies))
+	}
+}
+
+func TestRetryPolicyGivesUpOnLongRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	server := &scriptedServer{
+		statusCodes: []int{429},
+		header:      http.Header{"Retry-After": {"60"}},
+	}
+	_, err := Request{Method: "GET", ExpectStatusCodes: []int{200}}.Do(policy.Wrap(testBackend{handler: server.handle}))
+	if !IsRateLimited(err) {
+		t.Fatalf("expected rate-limit error, got %v", err)
+	}
+	if len(server.bodies) != 1 {
+		t.Errorf("expected 1 attempt, got %d", len(server.bodies))
+	}
+	retryAfter, ok := err.(UnexpectedStatusCodeError).RetryAfter()
+	if !ok || retryAfter != time.Minute {
+		t.Errorf("expected RetryAfter() = 1m0s, true, got %s, %t", retryAfter, ok)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Value    string
+		Delay    time.Duration
+		Expected bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"Fri, 01 Jun 2018 12:00:30 GMT", 30 * time.Second, true},
+		{"Fri, 01 Jun 2018 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+		{"-3", 0, false},
+	}
+	for _, tc := range testCases {
+		delay, ok := parseRetryAfter(http.Header{"Retry-After": {tc.Value}}, now)
+		if delay != tc.Delay || ok != tc.Expected {
+			t.Errorf("expected parseRetryAfter(%q) = %s, %t, got %s, %t",
+				tc.Value, tc.Delay, tc.Expected, delay, ok)
+		}
+	}
+}
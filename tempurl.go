@@ -0,0 +1,189 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//TempURLDigest enumerates hash algorithms that Swift supports for signing
+//temporary URLs. Which algorithms are accepted by a particular Swift server is
+//reported by Account.Capabilities() in the TempURL section.
+type TempURLDigest string
+
+const (
+	//TempURLDigestSHA1 is the digest algorithm supported by all versions of
+	//Swift. It is the default if no other digest algorithm is chosen.
+	TempURLDigestSHA1 TempURLDigest = "sha1"
+	//TempURLDigestSHA256 is supported by newer versions of Swift.
+	TempURLDigestSHA256 TempURLDigest = "sha256"
+)
+
+//SetTempURLKey sets the primary temp URL key of this account using a POST
+//request. This is a shorthand for:
+//
+//	hdr := schwift.NewAccountHeaders()
+//	hdr.TempURLKey().Set(key)
+//	err := account.Update(hdr, opts)
+//
+//To rotate keys without invalidating temporary URLs that are still in use,
+//use RotateTempURLKey() instead.
+func (a *Account) SetTempURLKey(key string, opts *RequestOptions) error {
+	hdr := NewAccountHeaders()
+	hdr.TempURLKey().Set(key)
+	return a.Update(hdr, opts)
+}
+
+//RotateTempURLKey replaces the primary temp URL key of this account with the
+//given key, and moves the previous primary key into TempURLKey2() (replacing
+//the previous secondary key). Both changes are applied in a single POST
+//request. Temporary URLs signed with the previous primary key stay valid
+//until the next rotation, so callers can switch to the new key without
+//downtime.
+//
+//The current primary key is read with a fresh HEAD request (ignoring the
+//cached Headers()), and its error is returned if that request fails. If no
+//primary key is set, only the new key is set and TempURLKey2() is left
+//unchanged.
+func (a *Account) RotateTempURLKey(newKey string, opts *RequestOptions) error {
+	a.Invalidate()
+	current, err := a.Headers()
+	if err != nil {
+		return err
+	}
+
+	hdr := NewAccountHeaders()
+	if oldKey := current.TempURLKey().Get(); oldKey != "" {
+		hdr.TempURLKey2().Set(oldKey)
+	}
+	hdr.TempURLKey().Set(newKey)
+	return a.Update(hdr, opts)
+}
+
+//SetTempURLKey sets the primary temp URL key of this container using a POST
+//request. See Account.SetTempURLKey() for details.
+func (c *Container) SetTempURLKey(key string, opts *RequestOptions) error {
+	hdr := NewContainerHeaders()
+	hdr.TempURLKey().Set(key)
+	return c.Update(hdr, opts)
+}
+
+//RotateTempURLKey replaces the primary temp URL key of this container with
+//the given key, and moves the previous primary key into TempURLKey2(). See
+//Account.RotateTempURLKey() for details.
+func (c *Container) RotateTempURLKey(newKey string, opts *RequestOptions) error {
+	c.Invalidate()
+	current, err := c.Headers()
+	if err != nil {
+		return err
+	}
+
+	hdr := NewContainerHeaders()
+	if oldKey := current.TempURLKey().Get(); oldKey != "" {
+		hdr.TempURLKey2().Set(oldKey)
+	}
+	hdr.TempURLKey().Set(newKey)
+	return c.Update(hdr, opts)
+}
+
+//TempURLOptions invokes advanced behavior in the Object.TempURL() method.
+type TempURLOptions struct {
+	//Digest selects the hash algorithm for the signature. If empty,
+	//TempURLDigestSHA1 is used.
+	Digest TempURLDigest
+}
+
+//TempURL generates a temporary URL for this object, which allows anyone who
+//knows the URL to perform the given request method (usually "GET" or "PUT")
+//on this object without authenticating, until the given expiry time.
+//
+//The key must be one of the temp URL keys configured for this object's
+//account or container, that is, one of:
+//
+//	accountHeaders.TempURLKey().Get()
+//	accountHeaders.TempURLKey2().Get()
+//	containerHeaders.TempURLKey().Get()
+//	containerHeaders.TempURLKey2().Get()
+//
+//This method does not issue any HTTP requests, so it cannot check whether the
+//key is valid or whether the server supports temporary URLs at all. Errors
+//are only returned when the URL cannot be constructed, or when an unknown
+//digest is requested in the TempURLOptions.
+func (o *Object) TempURL(method, key string, expires time.Time, opts *TempURLOptions) (string, error) {
+	if opts == nil {
+		opts = &TempURLOptions{}
+	}
+	var newHash func() hash.Hash
+	switch opts.Digest {
+	case "", TempURLDigestSHA1:
+		newHash = sha1.New
+	case TempURLDigestSHA256:
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported TempURL digest: %q", opts.Digest)
+	}
+	if method == "" {
+		return "", errors.New("missing request method for TempURL")
+	}
+
+	urlStr, err := Request{
+		ContainerName: o.c.name,
+		ObjectName:    o.name,
+	}.URL(o.c.a.backend, nil)
+	if err != nil {
+		return "", err
+	}
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+
+	//The signature is computed over the unescaped path since that's what the
+	//tempurl middleware sees after the request has been decoded.
+	expiresStr := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write([]byte(method + "\n" + expiresStr + "\n" + uri.Path))
+
+	uri.RawQuery = url.Values{
+		"temp_url_sig":     []string{hex.EncodeToString(mac.Sum(nil))},
+		"temp_url_expires": []string{expiresStr},
+	}.Encode()
+	return uri.String(), nil
+}
+
+//TempURLForObject generates a temporary URL for the object with the given name
+//in the given container of this account. This is a shorthand for:
+//
+//	account.Container(containerName).Object(objectName).TempURL(method, key, expires, opts)
+//
+//It is useful when only the names of the container and object are at hand,
+//e.g. when they have been loaded from a database. See Object.TempURL() for
+//details.
+func (a *Account) TempURLForObject(containerName, objectName, method, key string, expires time.Time, opts *TempURLOptions) (string, error) {
+	return a.Container(containerName).Object(objectName).TempURL(method, key, expires, opts)
+}
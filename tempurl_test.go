@@ -0,0 +1,59 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTempURL(t *testing.T) {
+	a := newTestAccount(t, nil)
+	obj := a.Container("test").Object("a file+ä.txt")
+	expires := time.Unix(1500000000, 0)
+
+	testCases := []struct {
+		digest   TempURLDigest
+		expected string
+	}{
+		{"", "https://swift.example.com/v1/AUTH_test/test/a%20file+%C3%A4.txt?temp_url_expires=1500000000&temp_url_sig=7d6098a8b12b612c3dcb38d493b3ce17ec55f818"},
+		{TempURLDigestSHA1, "https://swift.example.com/v1/AUTH_test/test/a%20file+%C3%A4.txt?temp_url_expires=1500000000&temp_url_sig=7d6098a8b12b612c3dcb38d493b3ce17ec55f818"},
+		{TempURLDigestSHA256, "https://swift.example.com/v1/AUTH_test/test/a%20file+%C3%A4.txt?temp_url_expires=1500000000&temp_url_sig=665a580009ba0669e401730a053e6bbdba3dc541dd2b810cce541de00a21ebdb"},
+	}
+	for _, tc := range testCases {
+		actual, err := obj.TempURL("GET", "secret", expires, &TempURLOptions{Digest: tc.digest})
+		if err != nil {
+			t.Errorf("unexpected error for digest %q: %s", tc.digest, err.Error())
+		} else if actual != tc.expected {
+			t.Errorf("expected TempURL %q for digest %q, but got %q", tc.expected, tc.digest, actual)
+		}
+	}
+
+	_, err := obj.TempURL("GET", "secret", expires, &TempURLOptions{Digest: "md5"})
+	if err == nil {
+		t.Error("expected TempURL() to fail for unsupported digest, but succeeded")
+	}
+
+	actual, err := a.TempURLForObject("test", "a file+ä.txt", "GET", "secret", expires, nil)
+	if err != nil {
+		t.Errorf("unexpected error from TempURLForObject: %s", err.Error())
+	} else if actual != testCases[0].expected {
+		t.Errorf("expected TempURLForObject to return %q, but got %q", testCases[0].expected, actual)
+	}
+}
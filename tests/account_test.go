@@ -90,5 +90,25 @@ func TestAccountMetadata(t *testing.T) {
 		expectString(t, hdr.Metadata().Get("schwift-test1"), "")
 		expectString(t, hdr.Metadata().Get("schwift-test2"), "changed")
 
+		//test removing a single key without touching the others
+		hdr = schwift.NewAccountHeaders()
+		hdr.Metadata().Set("schwift-test1", "first")
+		hdr.Metadata().Set("schwift-test3", "third")
+		expectSuccess(t, a.Update(hdr, nil))
+		hdr = schwift.NewAccountHeaders()
+		hdr.Metadata().Remove("schwift-test1")
+		expectString(t, hdr.Metadata().Get("schwift-test1"), "")
+		err = a.Update(hdr, nil)
+		if !expectSuccess(t, err) {
+			t.FailNow()
+		}
+
+		hdr, err = a.Headers()
+		if !expectSuccess(t, err) {
+			t.FailNow()
+		}
+		expectString(t, hdr.Metadata().Get("schwift-test1"), "")
+		expectString(t, hdr.Metadata().Get("schwift-test2"), "changed")
+		expectString(t, hdr.Metadata().Get("schwift-test3"), "third")
 	})
 }
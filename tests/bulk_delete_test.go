@@ -83,6 +83,23 @@ func TestBulkDeleteError(t *testing.T) {
 	})
 }
 
+func TestContainerDeleteRecursively(t *testing.T) {
+	testWithAccount(t, func(a *schwift.Account) {
+		testWithAndWithoutBulkDeleteSupport(func() {
+			c, err := a.Container("schwift-test-deleterecursively").EnsureExists()
+			expectSuccess(t, err)
+			_, err = createTestObjects(c)
+			expectSuccess(t, err)
+
+			expectSuccess(t, c.DeleteRecursively(nil))
+			expectContainerExistence(t, c, false)
+
+			//deleting a container that does not exist (anymore) is not an error
+			expectSuccess(t, c.DeleteRecursively(nil))
+		})
+	})
+}
+
 func createTestObjects(c *schwift.Container) ([]*schwift.Object, error) {
 	var objs []*schwift.Object
 	for idx := 1; idx <= 5; idx++ {
@@ -21,6 +21,7 @@ package tests
 import (
 	"archive/tar"
 	"bytes"
+	"io"
 	"strings"
 	"testing"
 
@@ -133,3 +134,42 @@ func buildInvalidObjectName() string {
 	}
 	return string(buf)
 }
+
+func TestDownloadArchive(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		hdr := schwift.NewObjectHeaders()
+		hdr.ContentType().Set("text/html")
+		expectSuccess(t, c.Object("www/index.html").Upload(strings.NewReader("<html></html>"), nil, hdr.ToOpts()))
+		expectSuccess(t, c.Object("www/css/main.css").Upload(strings.NewReader("body {}"), nil, nil))
+		expectSuccess(t, c.Object("other").Upload(strings.NewReader("not included"), nil, nil))
+
+		var buf bytes.Buffer
+		n, err := c.DownloadArchive(&buf, &schwift.DownloadArchiveOptions{Prefix: "www/"}, nil)
+		expectSuccess(t, err)
+		expectInt(t, n, 2)
+
+		r := tar.NewReader(bytes.NewReader(buf.Bytes()))
+		var names []string
+		for {
+			th, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			expectSuccess(t, err)
+			names = append(names, th.Name)
+			if th.Name == "www/index.html" {
+				expectString(t, th.PAXRecords["SCHILY.xattr.user.mime_type"], "text/html")
+			}
+		}
+		expectString(t, strings.Join(names, ","), "www/css/main.css,www/index.html")
+
+		//the archive can be extracted back into the container
+		expectSuccess(t, c.Object("www/index.html").Delete(nil, nil))
+		expectSuccess(t, c.Object("www/css/main.css").Delete(nil, nil))
+		n, err = c.Account().BulkUpload(c.Name(), schwift.BulkUploadTar, bytes.NewReader(buf.Bytes()), nil)
+		expectSuccess(t, err)
+		expectInt(t, n, 2)
+		expectObjectContent(t, c.Object("www/index.html"), []byte("<html></html>"))
+		expectObjectContent(t, c.Object("www/css/main.css"), []byte("body {}"))
+	})
+}
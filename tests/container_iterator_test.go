@@ -122,6 +122,32 @@ func TestContainerIterator(t *testing.T) {
 		expectSuccess(t, err)
 		expectContainerInfos(t, cis, cname(1), cname(2), cname(3), cname(4))
 
+		//test Collect and CollectDetailed with PageSize (forces multiple GET
+		//requests with markers)
+		iter = a.Containers()
+		iter.Prefix = "schwift-test-listing"
+		iter.PageSize = 3
+		cs, err = iter.Collect()
+		expectSuccess(t, err)
+		expectContainerNames(t, cs, cname(1), cname(2), cname(3), cname(4))
+
+		iter = a.Containers()
+		iter.Prefix = "schwift-test-listing"
+		iter.PageSize = 1
+		cis, err = iter.CollectDetailed()
+		expectSuccess(t, err)
+		expectContainerInfos(t, cis, cname(1), cname(2), cname(3), cname(4))
+
+		//test Marker and EndMarker
+		iter = a.Containers()
+		iter.Prefix = "schwift-test-listing"
+		iter.Marker = cname(1)
+		iter.EndMarker = cname(4)
+		iter.PageSize = 1
+		cs, err = iter.Collect()
+		expectSuccess(t, err)
+		expectContainerNames(t, cs, cname(2), cname(3))
+
 		//cleanup
 		iter = a.Containers()
 		iter.Prefix = "schwift-test-listing"
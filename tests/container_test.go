@@ -20,7 +20,9 @@ package tests
 
 import (
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/majewsky/schwift"
 )
@@ -40,14 +42,14 @@ func TestContainerLifecycle(t *testing.T) {
 		expectBool(t, exists, false)
 
 		_, err = c.Headers()
-		expectError(t, err, "expected 204 response, got 404 instead")
+		expectStatusCodeError(t, err, "expected 204 response, got 404 instead")
 		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
 		expectBool(t, schwift.Is(err, http.StatusNoContent), false)
 
 		//DELETE should be idempotent and not return success on non-existence, but
 		//OpenStack LOVES to be inconsistent with everything (including, notably, itself)
 		err = c.Delete(nil)
-		expectError(t, err, "expected 204 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
+		expectStatusCodeError(t, err, "expected 204 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
 
 		err = c.Create(nil)
 		expectSuccess(t, err)
@@ -61,6 +63,39 @@ func TestContainerLifecycle(t *testing.T) {
 	})
 }
 
+func TestContainerCreateIfMissing(t *testing.T) {
+	testWithAccount(t, func(a *schwift.Account) {
+		c := a.Container(getRandomName())
+
+		//first call creates the container with the given headers
+		hdr := schwift.NewContainerHeaders()
+		hdr.ObjectCountQuota().Set(23)
+		created, err := c.CreateIfMissing(hdr.ToOpts())
+		expectSuccess(t, err)
+		expectBool(t, created, true)
+
+		//second call leaves the container untouched
+		hdr.ObjectCountQuota().Set(42)
+		created, err = c.CreateIfMissing(hdr.ToOpts())
+		expectSuccess(t, err)
+		expectBool(t, created, false)
+
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectUint64(t, hdr.ObjectCountQuota().Get(), 23)
+
+		//by contrast, Create() applies the headers to the existing container
+		hdr = schwift.NewContainerHeaders()
+		hdr.ObjectCountQuota().Set(42)
+		expectSuccess(t, c.Create(hdr.ToOpts()))
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectUint64(t, hdr.ObjectCountQuota().Get(), 42)
+
+		expectSuccess(t, c.Delete(nil))
+	})
+}
+
 func TestContainerUpdate(t *testing.T) {
 	testWithContainer(t, func(c *schwift.Container) {
 
@@ -84,6 +119,50 @@ func TestContainerUpdate(t *testing.T) {
 	})
 }
 
+func TestContainerStaticWebsite(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		hdr := schwift.NewContainerHeaders()
+		hdr.WebIndex().Set("index.html")
+		hdr.WebError().Set("error.html")
+		hdr.WebListings().Set(true)
+		hdr.WebListingsCSS().Set("listing.css")
+		expectSuccess(t, c.Update(hdr, nil))
+
+		hdr, err := c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.WebIndex().Get(), "index.html")
+		expectString(t, hdr.WebError().Get(), "error.html")
+		expectBool(t, hdr.WebListings().Get(), true)
+		expectString(t, hdr.WebListingsCSS().Get(), "listing.css")
+
+		hdr = schwift.NewContainerHeaders()
+		hdr.WebListings().Clear()
+		expectSuccess(t, c.Update(hdr, nil))
+
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectBool(t, hdr.WebListings().Exists(), false)
+		expectBool(t, hdr.WebListings().Get(), false)
+		expectString(t, hdr.WebIndex().Get(), "index.html")
+	})
+}
+
+func TestContainerCORS(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		hdr := schwift.NewContainerHeaders()
+		hdr.CORSAllowOrigin().Set([]string{"https://example.com", "https://example.org"})
+		hdr.CORSExposeHeaders().Set([]string{"Content-Length", "Etag"})
+		hdr.CORSMaxAge().Set(time.Hour)
+		expectSuccess(t, c.Update(hdr, nil))
+
+		hdr, err := c.Headers()
+		expectSuccess(t, err)
+		expectString(t, strings.Join(hdr.CORSAllowOrigin().Get(), ","), "https://example.com,https://example.org")
+		expectString(t, strings.Join(hdr.CORSExposeHeaders().Get(), ","), "Content-Length,Etag")
+		expectInt64(t, int64(hdr.CORSMaxAge().Get()), int64(time.Hour))
+	})
+}
+
 func expectContainerExistence(t *testing.T, c *schwift.Container, expectedExists bool) {
 	t.Helper()
 	c.Invalidate()
@@ -91,3 +170,99 @@ func expectContainerExistence(t *testing.T, c *schwift.Container, expectedExists
 	expectSuccess(t, err)
 	expectBool(t, actualExists, expectedExists)
 }
+
+func TestContainerTempURLKey(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		expectSuccess(t, c.SetTempURLKey("schwift-test-key", nil))
+		hdr, err := c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.TempURLKey().Get(), "schwift-test-key")
+
+		obj := c.Object("example")
+		expectSuccess(t, obj.Upload(strings.NewReader("hello"), nil, nil))
+		tempURL, err := obj.TempURL("GET", "schwift-test-key", time.Now().Add(time.Minute), nil)
+		expectSuccess(t, err)
+		resp, err := http.Get(tempURL)
+		expectSuccess(t, err)
+		resp.Body.Close()
+		expectInt(t, resp.StatusCode, http.StatusOK)
+
+		//after rotation, URLs signed with the old key remain valid
+		expectSuccess(t, c.RotateTempURLKey("schwift-test-key-new", nil))
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.TempURLKey().Get(), "schwift-test-key-new")
+		expectString(t, hdr.TempURLKey2().Get(), "schwift-test-key")
+		newTempURL, err := obj.TempURL("GET", "schwift-test-key-new", time.Now().Add(time.Minute), nil)
+		expectSuccess(t, err)
+		for _, url := range []string{tempURL, newTempURL} {
+			resp, err = http.Get(url)
+			expectSuccess(t, err)
+			resp.Body.Close()
+			expectInt(t, resp.StatusCode, http.StatusOK)
+		}
+	})
+}
+
+func TestContainerSync(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		//EnableSync() requires both arguments; the actual sync setup cannot be
+		//tested without a container-sync realm in the test cluster
+		expectError(t, c.EnableSync("", "secret", nil), "container sync requires a target URL and a key")
+		expectError(t, c.EnableSync("//realm/cluster/AUTH_test/target", "", nil), "container sync requires a target URL and a key")
+
+		expectSuccess(t, c.DisableSync(nil))
+		hdr, err := c.Headers()
+		expectSuccess(t, err)
+		expectBool(t, hdr.SyncTo().Exists(), false)
+		expectBool(t, hdr.SyncKey().Exists(), false)
+	})
+}
+
+func TestContainerStoragePolicy(t *testing.T) {
+	testWithAccount(t, func(a *schwift.Account) {
+		caps, err := a.Capabilities()
+		expectSuccess(t, err)
+		var defaultPolicy, otherPolicy string
+		for _, policy := range caps.Swift.Policies {
+			if policy.Default {
+				defaultPolicy = policy.Name
+			} else if otherPolicy == "" {
+				otherPolicy = policy.Name
+			}
+		}
+
+		//without explicit policy, the default policy is applied
+		c := a.Container(getRandomName())
+		expectSuccess(t, c.Create(nil))
+		hdr, err := c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.StoragePolicy().Get(), defaultPolicy)
+		expectSuccess(t, c.Delete(nil))
+
+		if otherPolicy == "" {
+			t.Log("skipping rest of test: only one storage policy available")
+			return
+		}
+
+		//explicit policy is applied
+		c = a.Container(getRandomName())
+		hdr = schwift.NewContainerHeaders()
+		hdr.StoragePolicy().Set(otherPolicy)
+		expectSuccess(t, c.Create(hdr.ToOpts()))
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.StoragePolicy().Get(), otherPolicy)
+
+		//policy cannot be changed afterwards
+		hdr = schwift.NewContainerHeaders()
+		hdr.StoragePolicy().Set(defaultPolicy)
+		expectBool(t, schwift.Is(c.Create(hdr.ToOpts()), http.StatusConflict), true)
+		expectSuccess(t, c.Update(hdr, nil))
+		hdr, err = c.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.StoragePolicy().Get(), otherPolicy)
+
+		expectSuccess(t, c.Delete(nil))
+	})
+}
@@ -21,7 +21,9 @@ package tests
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/majewsky/schwift"
 )
@@ -83,6 +85,143 @@ func TestFieldTimestamp(t *testing.T) {
 	expectBool(t, hdr.CreatedAt().Exists(), true)
 	expectBool(t, hdr.CreatedAt().Get().IsZero(), true)
 	expectError(t, hdr.Validate(), `Bad header X-Timestamp: strconv.ParseFloat: parsing "wtf": invalid syntax`)
+
+	//fractional timestamps are parsed without loss of precision
+	hdr.Headers["X-Timestamp"] = "1500000000.12345"
+	expectSuccess(t, hdr.Validate())
+	expectInt64(t, hdr.CreatedAt().Get().UnixNano(), 1500000000123450000)
+
+	//other valid numbers are accepted, too
+	hdr.Headers["X-Timestamp"] = "1.5e9"
+	expectSuccess(t, hdr.Validate())
+	expectInt64(t, hdr.CreatedAt().Get().Unix(), 1500000000)
+}
+
+func TestFieldStringList(t *testing.T) {
+	hdr := schwift.NewContainerHeaders()
+	expectBool(t, hdr.CORSAllowOrigin().Exists(), false)
+	expectInt(t, len(hdr.CORSAllowOrigin().Get()), 0)
+	expectSuccess(t, hdr.Validate())
+
+	hdr.Headers["X-Container-Meta-Access-Control-Allow-Origin"] = " https://example.com  https://example.org "
+	expectBool(t, hdr.CORSAllowOrigin().Exists(), true)
+	expectString(t, strings.Join(hdr.CORSAllowOrigin().Get(), ","), "https://example.com,https://example.org")
+	expectSuccess(t, hdr.Validate())
+
+	hdr.CORSAllowOrigin().Set([]string{"*"})
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Access-Control-Allow-Origin": "*",
+	})
+	hdr.CORSAllowOrigin().Set([]string{"https://example.com", "https://example.org"})
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Access-Control-Allow-Origin": "https://example.com https://example.org",
+	})
+	hdr.CORSAllowOrigin().Clear()
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Access-Control-Allow-Origin": "",
+	})
+	hdr.CORSAllowOrigin().Del()
+	expectHeaders(t, hdr.Headers, nil)
+}
+
+func TestFieldBool(t *testing.T) {
+	hdr := schwift.NewContainerHeaders()
+	expectBool(t, hdr.WebListings().Exists(), false)
+	expectBool(t, hdr.WebListings().Get(), false)
+	expectSuccess(t, hdr.Validate())
+
+	for _, value := range []string{"true", "True", "YES", "on", "1", "t", "y"} {
+		hdr.Headers["X-Container-Meta-Web-Listings"] = value
+		expectBool(t, hdr.WebListings().Exists(), true)
+		expectBool(t, hdr.WebListings().Get(), true)
+		expectSuccess(t, hdr.Validate())
+	}
+	for _, value := range []string{"false", "no", "0", "wtf"} {
+		hdr.Headers["X-Container-Meta-Web-Listings"] = value
+		expectBool(t, hdr.WebListings().Exists(), true)
+		expectBool(t, hdr.WebListings().Get(), false)
+		expectSuccess(t, hdr.Validate())
+	}
+
+	hdr.WebListings().Set(true)
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Web-Listings": "true",
+	})
+	hdr.WebListings().Set(false)
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Web-Listings": "false",
+	})
+	hdr.WebListings().Clear()
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Container-Meta-Web-Listings": "",
+	})
+	hdr.WebListings().Del()
+	expectHeaders(t, hdr.Headers, nil)
+}
+
+func TestFieldUnixTime(t *testing.T) {
+	hdr := schwift.NewObjectHeaders()
+	expectBool(t, hdr.ExpiresAt().Exists(), false)
+	expectBool(t, hdr.ExpiresAt().Get().IsZero(), true)
+	expectSuccess(t, hdr.Validate())
+
+	//timestamps in the past must parse just as well as those in the future
+	hdr.Headers["X-Delete-At"] = "1000000000"
+	expectBool(t, hdr.ExpiresAt().Exists(), true)
+	expectInt64(t, hdr.ExpiresAt().Get().Unix(), 1000000000)
+	expectSuccess(t, hdr.Validate())
+
+	hdr.Headers["X-Delete-At"] = "wtf"
+	expectBool(t, hdr.ExpiresAt().Exists(), true)
+	expectBool(t, hdr.ExpiresAt().Get().IsZero(), true)
+	expectError(t, hdr.Validate(), `Bad header X-Delete-At: strconv.ParseFloat: parsing "wtf": invalid syntax`)
+
+	hdr.Headers["X-Delete-At"] = "1500000000.25000_0000000000000001"
+	expectBool(t, hdr.ExpiresAt().Get().IsZero(), true)
+	expectError(t, hdr.Validate(), `Bad header X-Delete-At: unexpected offset in Unix timestamp`)
+
+	hdr.ExpiresAt().Set(time.Unix(4000000000, 0))
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Delete-At": "4000000000",
+	})
+	hdr.ExpiresAt().Clear()
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Delete-At": "",
+	})
+	hdr.ExpiresAt().Del()
+	expectHeaders(t, hdr.Headers, nil)
+}
+
+func TestFieldDuration(t *testing.T) {
+	hdr := schwift.NewObjectHeaders()
+	expectBool(t, hdr.DeleteAfter().Exists(), false)
+	expectInt64(t, int64(hdr.DeleteAfter().Get()), 0)
+	expectSuccess(t, hdr.Validate())
+
+	hdr.Headers["X-Delete-After"] = "60"
+	expectBool(t, hdr.DeleteAfter().Exists(), true)
+	expectInt64(t, int64(hdr.DeleteAfter().Get()), int64(time.Minute))
+	expectSuccess(t, hdr.Validate())
+
+	hdr.Headers["X-Delete-After"] = "-60"
+	expectBool(t, hdr.DeleteAfter().Exists(), true)
+	expectInt64(t, int64(hdr.DeleteAfter().Get()), 0)
+	expectError(t, hdr.Validate(), `Bad header X-Delete-After: strconv.ParseUint: parsing "-60": invalid syntax`)
+
+	hdr.DeleteAfter().Set(1500 * time.Millisecond)
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Delete-After": "2",
+	})
+	hdr.DeleteAfter().Set(-time.Minute)
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Delete-After": "2",
+	})
+	hdr.DeleteAfter().Clear()
+	expectHeaders(t, hdr.Headers, map[string]string{
+		"X-Delete-After": "",
+	})
+	hdr.DeleteAfter().Del()
+	expectHeaders(t, hdr.Headers, nil)
 }
 
 func TestFieldHTTPTimestamp(t *testing.T) {
@@ -102,6 +241,14 @@ func TestFieldHTTPTimestamp(t *testing.T) {
 		actual := hdr.UpdatedAt().Get()
 		expected, _ := http.ParseTime(hdr.Get("Last-Modified"))
 		expectInt64(t, actual.Unix(), expected.Unix())
+
+		//containers report Last-Modified as well
+		chdr, err := c.Headers()
+		if !expectSuccess(t, err) {
+			return
+		}
+		expectBool(t, chdr.UpdatedAt().Exists(), true)
+		expectBool(t, chdr.UpdatedAt().Get().IsZero(), false)
 	})
 
 	hdr := schwift.NewObjectHeaders()
@@ -19,7 +19,9 @@
 package tests
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/majewsky/schwift"
 )
@@ -46,4 +48,114 @@ func TestParseAccountHeadersSuccess(t *testing.T) {
 	expectString(t, headers.Metadata().Get("FOO"), "bar")
 }
 
-//TODO TestParseAccountHeadersError
+func TestParseAccountHeadersError(t *testing.T) {
+	testCases := []struct {
+		key   string
+		value string
+	}{
+		{"X-Account-Bytes-Used", "-1"},
+		{"X-Account-Object-Count", "many"},
+		{"X-Account-Container-Count", "1.5"},
+	}
+	for _, tc := range testCases {
+		headers := schwift.AccountHeaders{Headers: schwift.Headers{tc.key: tc.value}}
+		expectError(t, headers.Validate(),
+			`Bad header `+tc.key+`: strconv.ParseUint: parsing "`+tc.value+`": invalid syntax`)
+	}
+}
+
+func TestParseContainerHeaders(t *testing.T) {
+	headers := schwift.ContainerHeaders{
+		Headers: schwift.Headers{
+			"X-Container-Bytes-Used":   "1234",
+			"X-Container-Object-Count": "42",
+		},
+	}
+	expectSuccess(t, headers.Validate())
+	expectUint64(t, headers.BytesUsed().Get(), 1234)
+	expectUint64(t, headers.ObjectCount().Get(), 42)
+
+	headers.Headers["X-Container-Object-Count"] = "-42"
+	expectError(t, headers.Validate(),
+		`Bad header X-Container-Object-Count: strconv.ParseUint: parsing "-42": invalid syntax`)
+	expectUint64(t, headers.ObjectCount().Get(), 0)
+}
+
+func TestMetadataKeys(t *testing.T) {
+	hdr := schwift.NewObjectHeaders()
+	hdr.Metadata().Set("zoo", "1")
+	hdr.Metadata().Set("Foo-bar", "2")
+	hdr.Metadata().Set("FOO-BAR", "3")
+	hdr.Metadata().Set("alpha", "4")
+	hdr.ContentType().Set("text/plain")
+	hdr.Set("X-Object-Meta-", "not a metadata key")
+
+	expectString(t, hdr.Metadata().Get("foo-bar"), "3")
+	keys := hdr.Metadata().Keys()
+	expectString(t, strings.Join(keys, ","), "Alpha,Foo-Bar,Zoo")
+
+	//removal is reflected in Get() and Keys(), and can be undone with Set()
+	hdr.Metadata().Remove("zoo")
+	expectString(t, hdr.Metadata().Get("zoo"), "")
+	expectString(t, hdr.Get("X-Remove-Object-Meta-Zoo"), "1")
+	expectString(t, strings.Join(hdr.Metadata().Keys(), ","), "Alpha,Foo-Bar")
+	hdr.Metadata().Set("zoo", "5")
+	expectString(t, hdr.Get("X-Remove-Object-Meta-Zoo"), "")
+
+	chdr := schwift.NewContainerHeaders()
+	chdr.Metadata().Remove("foo")
+	expectString(t, chdr.Get("X-Remove-Container-Meta-Foo"), "1")
+}
+
+func TestMetadataTypedAccessors(t *testing.T) {
+	hdr := schwift.NewObjectHeaders()
+	ts := time.Date(2018, 4, 1, 12, 30, 0, 500000000, time.FixedZone("CEST", 7200))
+	hdr.Metadata().SetInt("Counter", -42)
+	hdr.Metadata().SetTime("Processed-At", ts)
+	expectString(t, hdr.Get("X-Object-Meta-Counter"), "-42")
+	expectString(t, hdr.Get("X-Object-Meta-Processed-At"), "2018-04-01T10:30:00.5Z")
+
+	counter, err := hdr.Metadata().GetInt("counter")
+	expectSuccess(t, err)
+	expectInt64(t, counter, -42)
+	processedAt, err := hdr.Metadata().GetTime("processed-at")
+	expectSuccess(t, err)
+	expectBool(t, processedAt.Equal(ts), true)
+
+	//missing keys yield zero values without error
+	counter, err = hdr.Metadata().GetInt("Missing")
+	expectSuccess(t, err)
+	expectInt64(t, counter, 0)
+	processedAt, err = hdr.Metadata().GetTime("Missing")
+	expectSuccess(t, err)
+	expectBool(t, processedAt.IsZero(), true)
+
+	//malformed values yield MalformedHeaderError
+	hdr.Metadata().Set("Counter", "many")
+	_, err = hdr.Metadata().GetInt("Counter")
+	expectError(t, err, `Bad header X-Object-Meta-Counter: strconv.ParseInt: parsing "many": invalid syntax`)
+	hdr.Metadata().Set("Processed-At", "yesterday")
+	_, err = hdr.Metadata().GetTime("Processed-At")
+	if _, ok := err.(schwift.MalformedHeaderError); !ok {
+		t.Errorf("expected MalformedHeaderError, got %#v", err)
+	}
+}
+
+func TestObjectHeadersAttachmentFilename(t *testing.T) {
+	hdr := schwift.NewObjectHeaders()
+	expectString(t, hdr.AttachmentFilename(), "")
+
+	testCases := map[string]string{
+		"report.pdf":      `attachment; filename=report.pdf`,
+		`my "report".pdf`: `attachment; filename="my \"report\".pdf"`,
+		"Übersicht 1.pdf": `attachment; filename*=utf-8''%C3%9Cbersicht%201.pdf`,
+	}
+	for filename, expected := range testCases {
+		hdr.SetAttachmentFilename(filename)
+		expectString(t, hdr.ContentDisposition().Get(), expected)
+		expectString(t, hdr.AttachmentFilename(), filename)
+	}
+
+	hdr.ContentDisposition().Set("inline")
+	expectString(t, hdr.AttachmentFilename(), "")
+}
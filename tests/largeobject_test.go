@@ -20,7 +20,9 @@ package tests
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -56,6 +58,11 @@ func TestLargeObjectsBasic(t *testing.T) {
 			expectSuccess(t, lo.WriteManifest(nil))
 
 			expectObjectContent(t, obj, []byte(segment1+segment2))
+
+			//checksum verification is skipped for large objects
+			str, err := obj.DownloadWithOptions(&schwift.DownloadOptions{VerifyChecksum: true}, nil).AsString()
+			expectSuccess(t, err)
+			expectString(t, str, segment1+segment2)
 			expectLargeObject(t, obj, []schwift.SegmentInfo{
 				{
 					Object:    c.Object(strategyStr + "-segments/0000000000000001"),
@@ -69,6 +76,23 @@ func TestLargeObjectsBasic(t *testing.T) {
 				},
 			})
 
+			actualStrategy, err := obj.LargeObjectStrategy()
+			expectSuccess(t, err)
+			expectInt(t, int(actualStrategy), int(strategy))
+			segments, err := obj.ManifestSegments()
+			expectSuccess(t, err)
+			expectInt(t, len(segments), 2)
+			expectUint64(t, segments[1].SizeBytes, 128)
+
+			//DLO manifest is stored as "<container>/<prefix>" without leading slash
+			hdr, err := obj.Headers()
+			expectSuccess(t, err)
+			if strategy == schwift.DynamicLargeObject {
+				expectString(t, hdr.ObjectManifest().Get(), c.Name()+"/"+strategyStr+"-segments/")
+			} else {
+				expectBool(t, hdr.ObjectManifest().Exists(), false)
+			}
+
 			//basic append example
 			lo, err = obj.AsLargeObject()
 			expectSuccess(t, err)
@@ -186,6 +210,11 @@ func TestOpenRegularObjectAsLargeObject(t *testing.T) {
 		expectSuccess(t, o.Upload(bytes.NewReader(objectExampleContent), nil, nil))
 		_, err := o.AsLargeObject()
 		expectError(t, err, schwift.ErrNotLarge.Error())
+		_, err = o.ManifestSegments()
+		expectError(t, err, schwift.ErrNotLarge.Error())
+		strategy, err := o.LargeObjectStrategy()
+		expectSuccess(t, err)
+		expectInt(t, int(strategy), 0)
 	})
 }
 
@@ -527,6 +556,286 @@ func TestAddInvalidSegments(t *testing.T) {
 	})
 }
 
+func TestUploadLarge(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		segment1 := getRandomSegmentContent(128)
+		segment2 := getRandomSegmentContent(128)
+		segment3 := getRandomSegmentContent(64)
+
+		//upload with explicit segment container
+		obj := c.Object("largeobject")
+		err := obj.UploadLarge(strings.NewReader(segment1+segment2+segment3), 128, &schwift.UploadLargeOptions{
+			SegmentingOptions: schwift.SegmentingOptions{
+				SegmentContainer: c,
+				SegmentPrefix:    "segments/",
+			},
+		}, nil)
+		expectSuccess(t, err)
+		expectObjectContent(t, obj, []byte(segment1+segment2+segment3))
+		expectLargeObject(t, obj, []schwift.SegmentInfo{
+			{
+				Object:    c.Object("segments/0000000000000001"),
+				SizeBytes: 128,
+				Etag:      etagOfString(segment1),
+			},
+			{
+				Object:    c.Object("segments/0000000000000002"),
+				SizeBytes: 128,
+				Etag:      etagOfString(segment2),
+			},
+			{
+				Object:    c.Object("segments/0000000000000003"),
+				SizeBytes: 64,
+				Etag:      etagOfString(segment3),
+			},
+		})
+
+		//upload with default segment container: the container is only created
+		//when requested
+		obj = c.Object("largeobject-default")
+		err = obj.UploadLarge(strings.NewReader(segment1+segment2), 128, nil, nil)
+		expectError(t, err, fmt.Sprintf("segment container %q does not exist", c.Name()+"_segments"))
+		expectSuccess(t, obj.UploadLarge(strings.NewReader(segment1+segment2), 128,
+			&schwift.UploadLargeOptions{CreateSegmentContainer: true}, nil))
+		expectObjectContent(t, obj, []byte(segment1+segment2))
+		lo, err := obj.AsLargeObject()
+		expectSuccess(t, err)
+		expectString(t, lo.SegmentContainer().Name(), c.Name()+"_segments")
+		expectSuccess(t, lo.Truncate(&schwift.TruncateOptions{DeleteSegments: true}))
+		expectSuccess(t, lo.SegmentContainer().Delete(nil))
+
+		//upload with custom segment container name: the container is only
+		//created when requested
+		obj = c.Object("largeobject-custom")
+		customOpts := &schwift.UploadLargeOptions{
+			SegmentingOptions:    schwift.SegmentingOptions{SegmentPrefix: "custom/"},
+			SegmentContainerName: c.Name() + "-custom-segments",
+		}
+		err = obj.UploadLarge(strings.NewReader(segment1+segment2), 128, customOpts, nil)
+		expectError(t, err, fmt.Sprintf("segment container %q does not exist", customOpts.SegmentContainerName))
+		customOpts.CreateSegmentContainer = true
+		expectSuccess(t, obj.UploadLarge(strings.NewReader(segment1+segment2), 128, customOpts, nil))
+		expectObjectContent(t, obj, []byte(segment1+segment2))
+		lo, err = obj.AsLargeObject()
+		expectSuccess(t, err)
+		expectString(t, lo.SegmentContainer().Name(), customOpts.SegmentContainerName)
+		expectString(t, lo.SegmentPrefix(), "custom/")
+		expectSuccess(t, lo.Truncate(&schwift.TruncateOptions{DeleteSegments: true}))
+		expectSuccess(t, lo.SegmentContainer().Delete(nil))
+
+		//upload with multiple segments in parallel
+		obj = c.Object("largeobject-concurrent")
+		var (
+			segments      []string
+			expectedInfos []schwift.SegmentInfo
+			reportedBytes int64
+		)
+		for idx := 1; idx <= 10; idx++ {
+			segment := getRandomSegmentContent(128)
+			segments = append(segments, segment)
+			expectedInfos = append(expectedInfos, schwift.SegmentInfo{
+				Object:    c.Object(fmt.Sprintf("concurrent-segments/%016d", idx)),
+				SizeBytes: 128,
+				Etag:      etagOfString(segment),
+			})
+		}
+		err = obj.UploadLarge(strings.NewReader(strings.Join(segments, "")), 128, &schwift.UploadLargeOptions{
+			SegmentingOptions: schwift.SegmentingOptions{
+				SegmentContainer: c,
+				SegmentPrefix:    "concurrent-segments/",
+			},
+			Concurrency: 4,
+			Progress: func(bytesTransferred, totalBytes int64) {
+				reportedBytes = bytesTransferred
+			},
+		}, nil)
+		expectSuccess(t, err)
+		expectObjectContent(t, obj, []byte(strings.Join(segments, "")))
+		expectLargeObject(t, obj, expectedInfos)
+		expectInt64(t, reportedBytes, 10*128)
+
+		//when a segment upload fails, the segments uploaded so far are cleaned up
+		obj = c.Object("largeobject-broken")
+		err = obj.UploadLarge(&failingReader{strings.NewReader(segment1 + segment2), 200}, 128,
+			&schwift.UploadLargeOptions{
+				SegmentingOptions: schwift.SegmentingOptions{
+					SegmentContainer: c,
+					SegmentPrefix:    "broken-segments/",
+				},
+			}, nil)
+		if err == nil || !strings.Contains(err.Error(), errBrokenReader.Error()) {
+			t.Errorf("expected UploadLarge to fail with %q, got %v", errBrokenReader.Error(), err)
+		}
+		expectObjectExistence(t, obj, false)
+		expectObjectExistence(t, c.Object("broken-segments/0000000000000001"), false)
+
+		//same for parallel uploads
+		err = obj.UploadLarge(&failingReader{strings.NewReader(segment1 + segment2), 200}, 128,
+			&schwift.UploadLargeOptions{
+				SegmentingOptions: schwift.SegmentingOptions{
+					SegmentContainer: c,
+					SegmentPrefix:    "broken-segments/",
+				},
+				Concurrency: 2,
+			}, nil)
+		if err == nil || !strings.Contains(err.Error(), errBrokenReader.Error()) {
+			t.Errorf("expected UploadLarge to fail with %q, got %v", errBrokenReader.Error(), err)
+		}
+		expectObjectExistence(t, obj, false)
+		expectObjectExistence(t, c.Object("broken-segments/0000000000000001"), false)
+
+		//with KeepSegmentsOnError, the segments are left behind and reported
+		err = obj.UploadLarge(&failingReader{strings.NewReader(segment1 + segment2), 200}, 128,
+			&schwift.UploadLargeOptions{
+				SegmentingOptions: schwift.SegmentingOptions{
+					SegmentContainer: c,
+					SegmentPrefix:    "broken-segments/",
+				},
+				KeepSegmentsOnError: true,
+			}, nil)
+		uerr, ok := err.(schwift.UploadLargeError)
+		if !ok || !strings.Contains(uerr.Cause.Error(), errBrokenReader.Error()) {
+			t.Fatalf("expected UploadLargeError caused by %q, got %#v", errBrokenReader.Error(), err)
+		}
+		expectInt(t, len(uerr.Segments), 2)
+		expectString(t, uerr.Segments[0].FullName(), c.Name()+"/broken-segments/0000000000000001")
+		expectObjectExistence(t, obj, false)
+		expectObjectExistence(t, uerr.Segments[0], true)
+
+		//resuming requires seekable contents
+		resumeOpts := &schwift.UploadLargeOptions{
+			SegmentingOptions: schwift.SegmentingOptions{
+				SegmentContainer: c,
+				SegmentPrefix:    "broken-segments/",
+			},
+			Resume: true,
+		}
+		err = obj.UploadLarge(&failingReader{strings.NewReader(segment1 + segment2), 1000}, 128, resumeOpts, nil)
+		expectError(t, err, "cannot resume UploadLarge() for contents that do not implement io.Seeker")
+
+		//resume the upload; the first segment is not uploaded again
+		hdr, err := uerr.Segments[0].Headers()
+		expectSuccess(t, err)
+		timestampBefore := hdr.Get("X-Timestamp")
+		expectSuccess(t, obj.UploadLarge(strings.NewReader(segment1+segment2), 128, resumeOpts, nil))
+		expectObjectContent(t, obj, []byte(segment1+segment2))
+		hdr, err = uerr.Segments[0].FetchHeaders(nil)
+		expectSuccess(t, err)
+		expectString(t, hdr.Get("X-Timestamp"), timestampBefore)
+	})
+}
+
+func TestLargeObjectCopy(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		foreachLargeObjectStrategy(func(strategy schwift.LargeObjectStrategy, strategyStr string) {
+			segment1 := getRandomSegmentContent(128)
+			segment2 := getRandomSegmentContent(128)
+			expectedSegments := []schwift.SegmentInfo{
+				{
+					Object:    c.Object(strategyStr + "-segments/0000000000000001"),
+					SizeBytes: 128,
+					Etag:      etagOfString(segment1),
+				},
+				{
+					Object:    c.Object(strategyStr + "-segments/0000000000000002"),
+					SizeBytes: 128,
+					Etag:      etagOfString(segment2),
+				},
+			}
+
+			obj := c.Object(strategyStr + "-largeobject")
+			err := obj.UploadLarge(strings.NewReader(segment1+segment2), 128, &schwift.UploadLargeOptions{
+				SegmentingOptions: schwift.SegmentingOptions{
+					Strategy:         strategy,
+					SegmentContainer: c,
+					SegmentPrefix:    strategyStr + "-segments/",
+				},
+			}, nil)
+			expectSuccess(t, err)
+			if strategy == schwift.StaticLargeObject {
+				hdr, err := obj.Headers()
+				expectSuccess(t, err)
+				expectString(t, strings.Trim(hdr.Etag().Get(), `"`),
+					schwift.ComputeSLOEtag([]string{etagOfString(segment1), etagOfString(segment2)}))
+			}
+
+			//regular copy yields a plain object with the concatenated contents
+			target := c.Object(strategyStr + "-copy-data")
+			expectSuccess(t, obj.CopyTo(target, nil, nil))
+			expectObjectContent(t, target, []byte(segment1+segment2))
+			_, err = target.AsLargeObject()
+			expectError(t, err, schwift.ErrNotLarge.Error())
+
+			//manifest copy yields another large object with the same segments
+			target = c.Object(strategyStr + "-copy-manifest")
+			expectSuccess(t, obj.CopyTo(target, &schwift.CopyOptions{CopyManifest: true}, nil))
+			expectObjectContent(t, target, []byte(segment1+segment2))
+			expectLargeObject(t, target, expectedSegments)
+		})
+	})
+}
+
+func TestLargeObjectMove(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		foreachLargeObjectStrategy(func(strategy schwift.LargeObjectStrategy, strategyStr string) {
+			segment1 := getRandomSegmentContent(128)
+			segment2 := getRandomSegmentContent(128)
+			sopts := schwift.SegmentingOptions{
+				Strategy:         strategy,
+				SegmentContainer: c,
+				SegmentPrefix:    strategyStr + "-segments/",
+			}
+
+			//moving the manifest keeps the segments alive for the target
+			obj := c.Object(strategyStr + "-largeobject")
+			err := obj.UploadLarge(strings.NewReader(segment1+segment2), 128, &schwift.UploadLargeOptions{SegmentingOptions: sopts}, nil)
+			expectSuccess(t, err)
+			lo, err := obj.AsLargeObject()
+			expectSuccess(t, err)
+			segments, err := lo.Segments()
+			expectSuccess(t, err)
+
+			target := c.Object(strategyStr + "-move-manifest")
+			expectSuccess(t, obj.MoveTo(target, &schwift.MoveOptions{MoveManifest: true, DeleteSegments: true}, nil))
+			expectObjectExistence(t, obj, false)
+			expectObjectContent(t, target, []byte(segment1+segment2))
+			expectLargeObject(t, target, segments)
+
+			//moving the data yields a plain object and optionally removes the
+			//source's segments
+			target2 := c.Object(strategyStr + "-move-data")
+			expectSuccess(t, target.MoveTo(target2, &schwift.MoveOptions{DeleteSegments: true}, nil))
+			expectObjectExistence(t, target, false)
+			expectObjectContent(t, target2, []byte(segment1+segment2))
+			_, err = target2.AsLargeObject()
+			expectError(t, err, schwift.ErrNotLarge.Error())
+			for _, segment := range segments {
+				expectObjectExistence(t, segment.Object, false)
+			}
+		})
+	})
+}
+
+var errBrokenReader = errors.New("reader is broken")
+
+//failingReader yields an error after the given number of bytes has been read.
+type failingReader struct {
+	Reader    io.Reader
+	BytesLeft int
+}
+
+func (r *failingReader) Read(buf []byte) (int, error) {
+	if r.BytesLeft <= 0 {
+		return 0, errBrokenReader
+	}
+	if len(buf) > r.BytesLeft {
+		buf = buf[:r.BytesLeft]
+	}
+	n, err := r.Reader.Read(buf)
+	r.BytesLeft -= n
+	return n, err
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // helpers
 
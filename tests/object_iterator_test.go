@@ -128,6 +128,32 @@ func TestObjectIterator(t *testing.T) {
 		ois, err = iter.CollectDetailed()
 		expectSuccess(t, err)
 		expectObjectInfos(t, ois, oname(1), oname(2), oname(3), oname(4))
+
+		//test Collect and CollectDetailed with PageSize (forces multiple GET
+		//requests with markers)
+		iter = c.Objects()
+		iter.Prefix = "schwift-test-listing"
+		iter.PageSize = 3
+		os, err = iter.Collect()
+		expectSuccess(t, err)
+		expectObjectNames(t, os, oname(1), oname(2), oname(3), oname(4))
+
+		iter = c.Objects()
+		iter.Prefix = "schwift-test-listing"
+		iter.PageSize = 1
+		ois, err = iter.CollectDetailed()
+		expectSuccess(t, err)
+		expectObjectInfos(t, ois, oname(1), oname(2), oname(3), oname(4))
+
+		//test Marker and EndMarker
+		iter = c.Objects()
+		iter.Prefix = "schwift-test-listing"
+		iter.Marker = oname(1)
+		iter.EndMarker = oname(4)
+		iter.PageSize = 1
+		os, err = iter.Collect()
+		expectSuccess(t, err)
+		expectObjectNames(t, os, oname(2), oname(3))
 	})
 }
 
@@ -177,6 +203,65 @@ func TestPseudoDirectories(t *testing.T) {
 		ois, err = iter.CollectDetailed()
 		expectSuccess(t, err)
 		expectObjectInfos(t, ois, "foo/1", "foo/2", "foo/3", "foo/bar", "subdir:foo/bar/")
+
+		//test CollectSubdirectories
+		iter = c.Objects()
+		iter.Prefix = "foo/"
+		iter.Delimiter = "/"
+		subdirs, err := iter.CollectSubdirectories()
+		expectSuccess(t, err)
+		expectString(t, strings.Join(subdirs, ","), "foo/bar/")
+
+		iter = c.Objects()
+		subdirs, err = iter.CollectSubdirectories()
+		expectSuccess(t, err)
+		expectInt(t, len(subdirs), 0)
+	})
+}
+
+func TestPseudodirectoryView(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		//create test objects that can be listed
+		objectNames := []string{
+			"foo/",
+			"foo/1",
+			"foo/2",
+			"foo/bar/1",
+			"foo/baz/1",
+		}
+		for _, name := range objectNames {
+			err := c.Object(name).Upload(bytes.NewReader(objectExampleContent), nil, nil)
+			expectSuccess(t, err)
+		}
+
+		//test root directory
+		root := c.Pseudodirectory("")
+		expectString(t, root.Name(), "")
+		expectBool(t, root.Parent() == nil, true)
+		subdirs, err := root.Subdirectories(nil)
+		expectSuccess(t, err)
+		expectInt(t, len(subdirs), 1)
+		expectString(t, subdirs[0].Prefix(), "foo/")
+
+		//test Objects (the "foo/" marker object is excluded)
+		dir := c.Pseudodirectory("foo")
+		expectString(t, dir.Prefix(), "foo/")
+		expectString(t, dir.Name(), "foo")
+		ois, err := dir.Objects(nil)
+		expectSuccess(t, err)
+		expectObjectInfos(t, ois, "foo/1", "foo/2")
+
+		//test Subdirectories and navigation
+		subdirs, err = dir.Subdirectories(nil)
+		expectSuccess(t, err)
+		expectInt(t, len(subdirs), 2)
+		expectString(t, subdirs[0].Prefix(), "foo/bar/")
+		expectString(t, subdirs[1].Prefix(), "foo/baz/")
+		ois, err = dir.Subdirectory("bar").Objects(nil)
+		expectSuccess(t, err)
+		expectObjectInfos(t, ois, "foo/bar/1")
+		expectString(t, subdirs[1].Parent().Prefix(), "foo/")
+		expectString(t, dir.Object("1").Name(), "foo/1")
 	})
 }
 
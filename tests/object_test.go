@@ -20,11 +20,18 @@ package tests
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/majewsky/schwift"
 )
@@ -42,19 +49,29 @@ func TestObjectLifecycle(t *testing.T) {
 		expectObjectExistence(t, o, false)
 
 		_, err := o.Headers()
-		expectError(t, err, "expected 200 response, got 404 instead")
+		expectStatusCodeError(t, err, "expected 200 response, got 404 instead")
 		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
 		expectBool(t, schwift.Is(err, http.StatusNoContent), false)
+		if e, ok := err.(schwift.UnexpectedStatusCodeError); ok {
+			expectBool(t, e.TransID != "", true)
+		} else {
+			t.Errorf("expected UnexpectedStatusCodeError, got %#v", err)
+		}
 
 		//DELETE should be idempotent and not return success on non-existence, but
 		//OpenStack LOVES to be inconsistent with everything (including, notably, itself)
 		err = o.Delete(nil, nil)
-		expectError(t, err, "expected 204 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
+		expectStatusCodeError(t, err, "expected 204 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
 
 		err = o.Upload(bytes.NewReader([]byte("test")), nil, nil)
 		expectSuccess(t, err)
 
 		expectObjectExistence(t, o, true)
+		exists, hdr, err := c.Object(objectName).ExistsWithHeaders()
+		expectSuccess(t, err)
+		expectBool(t, exists, true)
+		expectUint64(t, hdr.SizeBytes().Get(), 4)
+		expectBool(t, hdr.TransID() != "", true)
 
 		err = o.Delete(nil, nil)
 		expectSuccess(t, err)
@@ -88,6 +105,26 @@ func TestObjectUpload(t *testing.T) {
 		expectSuccess(t, err)
 		expectObjectContent(t, obj, objectExampleContent)
 
+		//test upload with opaque io.Reader and precomputed Etag
+		obj = c.Object("upload4a")
+		hdr := schwift.NewObjectHeaders()
+		hdr.Etag().Set(etagOf(objectExampleContent))
+		err = obj.Upload(opaqueReader{bytes.NewReader(objectExampleContent)}, nil, hdr.ToOpts())
+		expectSuccess(t, err)
+		expectObjectContent(t, obj, objectExampleContent)
+
+		//test upload with opaque io.Reader and wrong precomputed Etag
+		obj = c.Object("upload4b")
+		hdr.Etag().Set(etagOf([]byte("something else")))
+		err = obj.Upload(opaqueReader{bytes.NewReader(objectExampleContent)}, nil, hdr.ToOpts())
+		expectBool(t, schwift.Is(err, http.StatusUnprocessableEntity), true)
+		expectObjectExistence(t, obj, false)
+
+		obj = c.Object("upload4c")
+		err = obj.Upload(opaqueReader{bytes.NewReader(objectExampleContent)}, &schwift.UploadOptions{SendContentMD5: true}, hdr.ToOpts())
+		expectBool(t, schwift.IsChecksumRejected(err), true)
+		expectObjectExistence(t, obj, false)
+
 		//test upload with io.Writer
 		obj = c.Object("upload5")
 		err = obj.UploadWithWriter(nil, nil, func(w io.Writer) error {
@@ -111,6 +148,55 @@ func TestObjectUpload(t *testing.T) {
 	})
 }
 
+func TestObjectUploadFromFile(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		dir, err := ioutil.TempDir("", "schwift-test")
+		expectSuccess(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "example.txt")
+		expectSuccess(t, ioutil.WriteFile(path, objectExampleContent, 0644))
+
+		//with detected Content-Type
+		obj := c.Object("from-file")
+		opts := &schwift.UploadFromFileOptions{DetectContentType: true}
+		expectSuccess(t, obj.UploadFromFile(path, opts, nil))
+		expectObjectContent(t, obj, objectExampleContent)
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.ContentType().Get(), "text/plain; charset=utf-8")
+
+		//with overridden Content-Type
+		hdr = schwift.NewObjectHeaders()
+		hdr.ContentType().Set("application/x-schwift-test")
+		expectSuccess(t, obj.UploadFromFile(path, opts, hdr.ToOpts()))
+		hdr, err = obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.ContentType().Get(), "application/x-schwift-test")
+
+		//directories are rejected
+		expectError(t, obj.UploadFromFile(dir, nil, nil), "cannot upload "+dir+": not a regular file")
+	})
+}
+
+func TestObjectConditionalWrite(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("conditional")
+		uopts := &schwift.UploadOptions{IfNoneMatch: "*"}
+
+		//first create succeeds, second create conflicts
+		err := obj.Upload(bytes.NewReader(objectExampleContent), uopts, nil)
+		expectSuccess(t, err)
+		err = obj.Upload(strings.NewReader("something else"), uopts, nil)
+		expectBool(t, schwift.Is(err, http.StatusPreconditionFailed), true)
+		expectObjectContent(t, obj, objectExampleContent)
+
+		//delete with the current Etag
+		err = obj.Delete(&schwift.DeleteOptions{IfMatch: etagOf(objectExampleContent)}, nil)
+		expectSuccess(t, err)
+		expectObjectExistence(t, obj, false)
+	})
+}
+
 type eofReader struct{}
 
 func (r eofReader) Read([]byte) (int, error) {
@@ -155,6 +241,241 @@ func TestObjectDownload(t *testing.T) {
 		buf, err = ioutil.ReadAll(reader)
 		expectSuccess(t, err)
 		expectString(t, string(buf), string(objectExampleContent[8:]))
+
+		//test access to raw response before reading the body
+		downloaded := obj.Download(nil)
+		resp := downloaded.Response()
+		expectBool(t, resp != nil, true)
+		expectBool(t, resp.Header.Get("X-Trans-Id") != "", true)
+		str, err = downloaded.AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent))
+
+		//no response when the request fails
+		downloaded = c.Object("does-not-exist").Download(nil)
+		expectBool(t, downloaded.Response() == nil, true)
+	})
+}
+
+func TestObjectDownloadRange(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), nil, nil)
+		expectSuccess(t, err)
+		size := len(objectExampleContent)
+
+		testCases := []struct {
+			opts         schwift.DownloadOptions
+			content      []byte
+			contentRange string
+		}{
+			{schwift.DownloadOptions{RangeOffset: 2, RangeLength: 7}, objectExampleContent[2:9], fmt.Sprintf("bytes 2-8/%d", size)},
+			{schwift.DownloadOptions{RangeOffset: 10}, objectExampleContent[10:], fmt.Sprintf("bytes 10-%d/%d", size-1, size)},
+			{schwift.DownloadOptions{RangeOffset: -1, RangeLength: 3}, objectExampleContent[size-3:], fmt.Sprintf("bytes %d-%d/%d", size-3, size-1, size)},
+		}
+		for _, tc := range testCases {
+			obj.Invalidate()
+			downloaded := obj.DownloadWithOptions(&tc.opts, nil)
+			expectString(t, downloaded.ContentRange(), tc.contentRange)
+			str, err := downloaded.AsString()
+			expectSuccess(t, err)
+			expectString(t, str, string(tc.content))
+		}
+
+		//a full download does not report a Content-Range
+		downloaded := obj.Download(nil)
+		expectString(t, downloaded.ContentRange(), "")
+		_, err = downloaded.AsByteSlice()
+		expectSuccess(t, err)
+
+		//partial downloads do not pollute the header cache
+		obj.Invalidate()
+		_, err = obj.DownloadWithOptions(&schwift.DownloadOptions{RangeLength: 2}, nil).AsByteSlice()
+		expectSuccess(t, err)
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectUint64(t, hdr.SizeBytes().Get(), uint64(size))
+	})
+}
+
+func TestObjectDownloadConditional(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), nil, nil)
+		expectSuccess(t, err)
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		etag := hdr.Etag().Get()
+		lastModified := hdr.UpdatedAt().Get()
+
+		//conditions that hold
+		for _, opts := range []schwift.DownloadOptions{
+			{IfMatch: etag},
+			{IfNoneMatch: "definitely-not-the-etag"},
+			{IfModifiedSince: lastModified.Add(-time.Hour)},
+			{IfUnmodifiedSince: lastModified.Add(time.Hour)},
+		} {
+			str, err := obj.DownloadWithOptions(&opts, nil).AsString()
+			expectSuccess(t, err)
+			expectString(t, str, string(objectExampleContent))
+		}
+
+		//conditions that fail
+		for _, opts := range []schwift.DownloadOptions{
+			{IfNoneMatch: etag},
+			{IfModifiedSince: lastModified.Add(time.Hour)},
+		} {
+			_, err := obj.DownloadWithOptions(&opts, nil).AsString()
+			expectBool(t, schwift.Is(err, http.StatusNotModified), true)
+		}
+		for _, opts := range []schwift.DownloadOptions{
+			{IfMatch: "definitely-not-the-etag"},
+			{IfUnmodifiedSince: lastModified.Add(-time.Hour)},
+		} {
+			_, err := obj.DownloadWithOptions(&opts, nil).AsString()
+			expectBool(t, schwift.Is(err, http.StatusPreconditionFailed), true)
+		}
+	})
+}
+
+func TestObjectDownloadVerifyChecksum(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), nil, nil)
+		expectSuccess(t, err)
+
+		opts := schwift.DownloadOptions{VerifyChecksum: true}
+		str, err := obj.DownloadWithOptions(&opts, nil).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent))
+
+		//partial downloads are not verified
+		opts.RangeLength = 5
+		str, err = obj.DownloadWithOptions(&opts, nil).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent[:5]))
+	})
+}
+
+func TestObjectDownloadDecompressGzip(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(objectExampleContent)
+		expectSuccess(t, err)
+		expectSuccess(t, w.Close())
+
+		obj := c.Object("compressed")
+		hdr := schwift.NewObjectHeaders()
+		hdr.ContentEncoding().Set("gzip")
+		err = obj.Upload(bytes.NewReader(buf.Bytes()), nil, hdr.ToOpts())
+		expectSuccess(t, err)
+
+		//with and without net/http's transparent decompression
+		for _, acceptEncoding := range []string{"", "identity"} {
+			ropts := &schwift.RequestOptions{Headers: make(schwift.Headers)}
+			if acceptEncoding != "" {
+				ropts.Headers.Set("Accept-Encoding", acceptEncoding)
+			}
+			opts := schwift.DownloadOptions{DecompressGzip: true, VerifyChecksum: true}
+			str, err := obj.DownloadWithOptions(&opts, ropts).AsString()
+			expectSuccess(t, err)
+			expectString(t, str, string(objectExampleContent))
+		}
+
+		//DecompressGzip is a no-op for uncompressed objects
+		obj = c.Object("uncompressed")
+		err = obj.Upload(bytes.NewReader(objectExampleContent), nil, nil)
+		expectSuccess(t, err)
+		str, err := obj.DownloadWithOptions(&schwift.DownloadOptions{DecompressGzip: true}, nil).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent))
+	})
+}
+
+func TestObjectUploadCompressGzip(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("compressed")
+		uopts := &schwift.UploadOptions{CompressGzip: true, CompressionLevel: gzip.BestCompression}
+		err := obj.Upload(bytes.NewReader(objectExampleContent), uopts, nil)
+		expectSuccess(t, err)
+
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.ContentEncoding().Get(), "gzip")
+
+		//the stored data is compressed...
+		ropts := &schwift.RequestOptions{Headers: make(schwift.Headers)}
+		ropts.Headers.Set("Accept-Encoding", "identity")
+		opts := schwift.DownloadOptions{VerifyChecksum: true}
+		reader, err := obj.DownloadWithOptions(&opts, ropts).AsReadCloser()
+		expectSuccess(t, err)
+		gz, err := gzip.NewReader(reader)
+		expectSuccess(t, err)
+		buf, err := ioutil.ReadAll(gz)
+		expectSuccess(t, err)
+		expectString(t, string(buf), string(objectExampleContent))
+		expectSuccess(t, reader.Close())
+
+		//...and can be decompressed transparently
+		opts.DecompressGzip = true
+		str, err := obj.DownloadWithOptions(&opts, ropts).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent))
+	})
+}
+
+func TestObjectTransferProgress(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		size := int64(len(objectExampleContent))
+		var lastTransferred, lastTotal int64
+		progress := func(bytesTransferred, totalBytes int64) {
+			lastTransferred, lastTotal = bytesTransferred, totalBytes
+		}
+
+		//upload with known size
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), &schwift.UploadOptions{Progress: progress}, nil)
+		expectSuccess(t, err)
+		expectInt64(t, lastTransferred, size)
+		expectInt64(t, lastTotal, size)
+
+		//upload with unknown size
+		lastTransferred, lastTotal = 0, 0
+		err = obj.Upload(opaqueReader{bytes.NewReader(objectExampleContent)}, &schwift.UploadOptions{Progress: progress}, nil)
+		expectSuccess(t, err)
+		expectInt64(t, lastTransferred, size)
+		expectInt64(t, lastTotal, -1)
+
+		//download
+		lastTransferred, lastTotal = 0, 0
+		str, err := obj.DownloadWithOptions(&schwift.DownloadOptions{Progress: progress}, nil).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, string(objectExampleContent))
+		expectInt64(t, lastTransferred, size)
+		expectInt64(t, lastTotal, size)
+	})
+}
+
+func TestObjectWithCancelledContext(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		opts := &schwift.RequestOptions{Context: ctx}
+
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), nil, opts)
+		expectError(t, err, context.Canceled.Error())
+		expectObjectExistence(t, obj, false)
+
+		expectSuccess(t, obj.Upload(bytes.NewReader(objectExampleContent), nil, nil))
+		_, err = obj.Download(opts).AsByteSlice()
+		expectError(t, err, context.Canceled.Error())
+		err = obj.Delete(nil, opts)
+		expectError(t, err, context.Canceled.Error())
+		_, err = obj.FetchHeaders(opts)
+		expectError(t, err, context.Canceled.Error())
+		expectObjectExistence(t, obj, true)
 	})
 }
 
@@ -167,7 +488,7 @@ func TestObjectUpdate(t *testing.T) {
 		newHeaders.ContentType().Set("application/json")
 		err := obj.Update(newHeaders, nil)
 		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
-		expectError(t, err, "expected 202 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
+		expectStatusCodeError(t, err, "expected 202 response, got 404 instead: <html><h1>Not Found</h1><p>The resource could not be found.</p></html>")
 
 		//create object
 		err = obj.Upload(nil, nil, nil)
@@ -184,6 +505,105 @@ func TestObjectUpdate(t *testing.T) {
 		hdr, err = obj.Headers()
 		expectSuccess(t, err)
 		expectString(t, hdr.ContentType().Get(), "application/json")
+
+		//metadata-only update preserves the Content-Type
+		newHeaders = schwift.NewObjectHeaders()
+		newHeaders.Metadata().Set("Foo", "bar")
+		expectSuccess(t, obj.Update(newHeaders, nil))
+		hdr, err = obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.ContentType().Get(), "application/json")
+		expectString(t, hdr.Metadata().Get("Foo"), "bar")
+	})
+}
+
+func TestObjectUpdateInvalidMetadataKey(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		err := obj.Upload(bytes.NewReader(objectExampleContent), nil, nil)
+		expectSuccess(t, err)
+
+		for _, key := range []string{"with space", "Ümlaut", "colon:"} {
+			hdr := schwift.NewObjectHeaders()
+			hdr.Metadata().Set(key, "value")
+			err = obj.Update(hdr, nil)
+			if _, ok := err.(schwift.MalformedHeaderError); !ok {
+				t.Errorf("expected MalformedHeaderError for metadata key %q, got %#v", key, err)
+			}
+		}
+	})
+}
+
+func TestObjectUpdateMetadataMode(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		hdr := schwift.NewObjectHeaders()
+		hdr.Metadata().Set("A", "1")
+		hdr.Metadata().Set("B", "2")
+		hdr.Metadata().Set("C", "3")
+		hdr.Metadata().Set("D", "4")
+		expectSuccess(t, obj.Upload(nil, nil, hdr.ToOpts()))
+
+		//merge: unmentioned keys are preserved, cleared or removed keys are deleted
+		hdr = schwift.NewObjectHeaders()
+		hdr.Metadata().Set("B", "20")
+		hdr.Metadata().Clear("C")
+		hdr.Set("X-Remove-Object-Meta-D", "yes")
+		expectSuccess(t, obj.UpdateWithOptions(hdr, &schwift.UpdateOptions{MetadataMode: schwift.MetadataMerge}, nil))
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.Metadata().Get("A"), "1")
+		expectString(t, hdr.Metadata().Get("B"), "20")
+		expectBool(t, hdr.Headers.Get("X-Object-Meta-C") == "", true)
+		expectBool(t, hdr.Headers.Get("X-Object-Meta-D") == "", true)
+
+		//replace: unmentioned keys are deleted
+		hdr = schwift.NewObjectHeaders()
+		hdr.Metadata().Set("E", "5")
+		expectSuccess(t, obj.UpdateWithOptions(hdr, &schwift.UpdateOptions{MetadataMode: schwift.MetadataReplace}, nil))
+		hdr, err = obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.Metadata().Get("A"), "")
+		expectString(t, hdr.Metadata().Get("B"), "")
+		expectString(t, hdr.Metadata().Get("E"), "5")
+
+		//merge fails for non-existing object
+		err = c.Object("missing").UpdateWithOptions(hdr, &schwift.UpdateOptions{MetadataMode: schwift.MetadataMerge}, nil)
+		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
+	})
+}
+
+func TestObjectContentHeaders(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		hdr := schwift.NewObjectHeaders()
+		hdr.SetAttachmentFilename("Übersicht.txt")
+		hdr.ContentEncoding().Set("gzip")
+		expectSuccess(t, obj.Upload(bytes.NewReader(objectExampleContent), nil, hdr.ToOpts()))
+
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.AttachmentFilename(), "Übersicht.txt")
+		expectString(t, hdr.ContentEncoding().Get(), "gzip")
+	})
+}
+
+func TestObjectExpiration(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj := c.Object("example")
+		hdr := schwift.NewObjectHeaders()
+		hdr.DeleteAfter().Set(time.Hour)
+		expectSuccess(t, obj.Upload(nil, nil, hdr.ToOpts()))
+
+		//Swift converts X-Delete-After into X-Delete-At
+		hdr, err := obj.Headers()
+		expectSuccess(t, err)
+		expectBool(t, hdr.DeleteAfter().Exists(), false)
+		expectBool(t, hdr.ExpiresAt().Exists(), true)
+		remaining := time.Until(hdr.ExpiresAt().Get())
+		if remaining < 59*time.Minute || remaining > time.Hour {
+			t.Errorf("expected object to expire in about an hour, but ExpiresAt = %s", hdr.ExpiresAt().Get())
+		}
 	})
 }
 
@@ -202,6 +622,37 @@ func TestObjectCopy(t *testing.T) {
 	})
 }
 
+func TestObjectMove(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		obj1 := c.Object("location1")
+		hdr := schwift.NewObjectHeaders()
+		hdr.ContentType().Set("text/plain")
+		hdr.Metadata().Set("foo", "bar")
+		err := obj1.Upload(bytes.NewReader(objectExampleContent), nil, hdr.ToOpts())
+		expectSuccess(t, err)
+
+		//moving onto itself is rejected before any request is made
+		expectError(t, obj1.MoveTo(c.Object("location1"), nil, nil), "cannot move object onto itself")
+		expectObjectExistence(t, obj1, true)
+
+		//move preserves content, metadata and content type
+		obj2 := c.Object("location2")
+		expectSuccess(t, obj1.MoveTo(obj2, nil, nil))
+		expectObjectExistence(t, obj1, false)
+		expectObjectExistence(t, obj2, true)
+		expectObjectContent(t, obj2, objectExampleContent)
+		hdr, err = obj2.Headers()
+		expectSuccess(t, err)
+		expectString(t, hdr.ContentType().Get(), "text/plain")
+		expectString(t, hdr.Metadata().Get("foo"), "bar")
+
+		//failed copy leaves the source untouched
+		err = obj2.MoveTo(c.Account().Container(getRandomName()).Object("location3"), nil, nil)
+		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
+		expectObjectExistence(t, obj2, true)
+	})
+}
+
 func TestSymlinkOperations(t *testing.T) {
 	testWithContainer(t, func(c *schwift.Container) {
 		//create a test object that we can link to
@@ -230,6 +681,11 @@ func TestSymlinkOperations(t *testing.T) {
 		expectObjectSymlink(t, obj2, obj1)
 		expectObjectContent(t, obj2, objectExampleContent)
 
+		//download symlink itself
+		str, err := obj2.DownloadWithOptions(&schwift.DownloadOptions{DoNotFollowSymlinks: true}, nil).AsString()
+		expectSuccess(t, err)
+		expectString(t, str, "")
+
 		//deep-copy symlink
 		obj3 := c.Object("copy")
 		expectSuccess(t, obj2.CopyTo(obj3, nil, nil))
@@ -245,6 +701,25 @@ func TestSymlinkOperations(t *testing.T) {
 		expectObjectSymlink(t, obj3, obj1)
 		expectObjectContent(t, obj3, objectExampleContent)
 
+		//dangling symlink
+		obj4 := c.Object("dangling")
+		expectSuccess(t, obj4.SymlinkTo(c.Object("does-not-exist"), nil, nil))
+		_, err = obj4.Download(nil).AsString()
+		expectBool(t, schwift.Is(err, http.StatusNotFound), true)
+		_, err = obj4.DownloadWithOptions(&schwift.DownloadOptions{DoNotFollowSymlinks: true}, nil).AsString()
+		expectSuccess(t, err)
+		expectObjectSymlink(t, obj4, c.Object("does-not-exist"))
+
+		//custom query parameters can be passed to all operations, incl. HEAD
+		symlinkOpts := &schwift.RequestOptions{Values: url.Values{"symlink": {"get"}}}
+		hdr, err := obj2.FetchHeaders(symlinkOpts)
+		expectSuccess(t, err)
+		expectString(t, hdr.SymlinkTarget().Get(), obj1.FullName())
+		hdr, err = obj2.FetchHeaders(nil)
+		expectSuccess(t, err)
+		expectBool(t, hdr.SymlinkTarget().Exists(), false)
+		expectUint64(t, hdr.SizeBytes().Get(), uint64(len(objectExampleContent)))
+
 		//delete symlink
 		expectSuccess(t, obj2.Delete(nil, nil))
 		expectObjectExistence(t, obj2, false)
@@ -24,6 +24,7 @@ import (
 	"encoding/hex"
 	"math"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/gophercloud/gophercloud"
@@ -189,6 +190,25 @@ func expectError(t *testing.T, actual error, expected string) (ok bool) {
 	return true
 }
 
+var transIDRx = regexp.MustCompile(` \(txn: [^)]*\)`)
+
+//expectStatusCodeError is like expectError, but removes the transaction ID
+//from the error message of an UnexpectedStatusCodeError before comparing,
+//since it differs for every request.
+func expectStatusCodeError(t *testing.T, actual error, expected string) (ok bool) {
+	t.Helper()
+	if _, isStatusCodeError := actual.(schwift.UnexpectedStatusCodeError); !isStatusCodeError {
+		t.Errorf("expected UnexpectedStatusCodeError %q, got %#v instead\n", expected, actual)
+		return false
+	}
+	msg := transIDRx.ReplaceAllString(actual.Error(), "")
+	if expected != msg {
+		t.Errorf("expected error %q, got %q instead\n", expected, actual.Error())
+		return false
+	}
+	return true
+}
+
 func expectSuccess(t *testing.T, actual error) (ok bool) {
 	t.Helper()
 	if actual != nil {
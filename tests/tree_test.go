@@ -0,0 +1,176 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package tests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/majewsky/schwift"
+)
+
+func TestContainerUploadTree(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		dir, err := ioutil.TempDir("", "schwift-test")
+		expectSuccess(t, err)
+		defer os.RemoveAll(dir)
+
+		files := map[string]string{
+			"index.html":       "<html></html>",
+			"css/main.css":     "body {}",
+			"css/print/a.css":  "@media print {}",
+			"images/empty.png": "",
+		}
+		for relPath, content := range files {
+			path := filepath.Join(dir, relPath)
+			expectSuccess(t, os.MkdirAll(filepath.Dir(path), 0755))
+			expectSuccess(t, ioutil.WriteFile(path, []byte(content), 0644))
+		}
+		expectSuccess(t, os.Symlink("index.html", filepath.Join(dir, "link.html")))
+
+		//first upload: all regular files are uploaded, the symlink is skipped
+		results, err := c.UploadTree(dir, "www", &schwift.UploadTreeOptions{Concurrency: 2}, nil)
+		expectSuccess(t, err)
+		expectTreeActions(t, results, map[string]schwift.TreeAction{
+			"www/css/main.css":     schwift.TreeActionUpload,
+			"www/css/print/a.css":  schwift.TreeActionUpload,
+			"www/images/empty.png": schwift.TreeActionUpload,
+			"www/index.html":       schwift.TreeActionUpload,
+			"www/link.html":        schwift.TreeActionSkip,
+		})
+		for relPath, content := range files {
+			expectObjectContent(t, c.Object("www/"+relPath), []byte(content))
+		}
+		expectObjectExistence(t, c.Object("www/link.html"), false)
+
+		//second upload: unchanged files are kept, changed files and followed
+		//symlinks are uploaded
+		expectSuccess(t, ioutil.WriteFile(filepath.Join(dir, "css/main.css"), []byte("body { color: red }"), 0644))
+		results, err = c.UploadTree(dir, "www/", &schwift.UploadTreeOptions{
+			SkipUnchanged:  true,
+			FollowSymlinks: true,
+		}, nil)
+		expectSuccess(t, err)
+		expectTreeActions(t, results, map[string]schwift.TreeAction{
+			"www/css/main.css":     schwift.TreeActionUpload,
+			"www/css/print/a.css":  schwift.TreeActionKeep,
+			"www/images/empty.png": schwift.TreeActionKeep,
+			"www/index.html":       schwift.TreeActionKeep,
+			"www/link.html":        schwift.TreeActionUpload,
+		})
+		expectObjectContent(t, c.Object("www/css/main.css"), []byte("body { color: red }"))
+		expectObjectContent(t, c.Object("www/link.html"), []byte(files["index.html"]))
+	})
+}
+
+func TestContainerMirrorTree(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		dir, err := ioutil.TempDir("", "schwift-test")
+		expectSuccess(t, err)
+		defer os.RemoveAll(dir)
+		expectSuccess(t, ioutil.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0644))
+		expectSuccess(t, ioutil.WriteFile(filepath.Join(dir, "same.txt"), []byte("same"), 0644))
+
+		expectSuccess(t, c.Object("www/same.txt").Upload(bytes.NewReader([]byte("same")), nil, nil))
+		expectSuccess(t, c.Object("www/old.txt").Upload(bytes.NewReader([]byte("old")), nil, nil))
+		expectSuccess(t, c.Object("www/sub/old.txt").Upload(bytes.NewReader([]byte("old")), nil, nil))
+		expectSuccess(t, c.Object("other/old.txt").Upload(bytes.NewReader([]byte("old")), nil, nil))
+
+		expected := map[string]schwift.TreeAction{
+			"www/new.txt":     schwift.TreeActionUpload,
+			"www/same.txt":    schwift.TreeActionKeep,
+			"www/old.txt":     schwift.TreeActionDelete,
+			"www/sub/old.txt": schwift.TreeActionDelete,
+		}
+		opts := &schwift.UploadTreeOptions{
+			SkipUnchanged:    true,
+			DeleteExtraneous: true,
+			DryRun:           true,
+		}
+
+		//dry run: actions are planned, but nothing changes
+		results, err := c.UploadTree(dir, "www", opts, nil)
+		expectSuccess(t, err)
+		expectTreeActions(t, results, expected)
+		expectObjectExistence(t, c.Object("www/new.txt"), false)
+		expectObjectExistence(t, c.Object("www/old.txt"), true)
+
+		//actual run: extraneous objects below the prefix are deleted
+		opts.DryRun = false
+		results, err = c.UploadTree(dir, "www", opts, nil)
+		expectSuccess(t, err)
+		expectTreeActions(t, results, expected)
+		expectObjectContent(t, c.Object("www/new.txt"), []byte("new"))
+		expectObjectExistence(t, c.Object("www/old.txt"), false)
+		expectObjectExistence(t, c.Object("www/sub/old.txt"), false)
+		expectObjectExistence(t, c.Object("other/old.txt"), true)
+	})
+}
+
+func TestContainerUploadTreeContentTypes(t *testing.T) {
+	testWithContainer(t, func(c *schwift.Container) {
+		dir, err := ioutil.TempDir("", "schwift-test")
+		expectSuccess(t, err)
+		defer os.RemoveAll(dir)
+		expectSuccess(t, ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("alert(1);"), 0644))
+		expectSuccess(t, ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0644))
+
+		_, err = c.UploadTree(dir, "", &schwift.UploadTreeOptions{
+			ContentTypeResolver: func(path string) string {
+				if strings.HasSuffix(path, ".js") {
+					return "text/javascript"
+				}
+				return ""
+			},
+		}, nil)
+		expectSuccess(t, err)
+
+		expected := map[string]string{
+			"app.js":     "text/javascript",
+			"index.html": "text/html; charset=utf-8",
+		}
+		for name, contentType := range expected {
+			hdr, err := c.Object(name).Headers()
+			expectSuccess(t, err)
+			expectString(t, hdr.ContentType().Get(), contentType)
+		}
+	})
+}
+
+func expectTreeActions(t *testing.T, results []schwift.TreeResult, expected map[string]schwift.TreeAction) {
+	t.Helper()
+	if len(results) != len(expected) {
+		t.Errorf("expected %d results, got %d", len(expected), len(results))
+	}
+	for _, r := range results {
+		expectSuccess(t, r.Err)
+		action, exists := expected[r.Object.Name()]
+		if !exists {
+			t.Errorf("unexpected result for %s", r.Object.Name())
+			continue
+		}
+		if r.Action != action {
+			t.Errorf("expected action %s for %s, got %s", action, r.Object.Name(), r.Action)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/majewsky/schwift"
+)
+
+func TestObjectVersioning(t *testing.T) {
+	testWithContainer(t, func(archive *schwift.Container) {
+		testWithContainer(t, func(c *schwift.Container) {
+			expectSuccess(t, c.EnableVersioning(archive, schwift.VersioningHistory, nil))
+			hdr, err := c.Headers()
+			expectSuccess(t, err)
+			expectString(t, hdr.HistoryLocation().Get(), archive.Name())
+			expectBool(t, hdr.VersionsLocation().Exists(), false)
+
+			//without archived versions
+			obj := c.Object("example")
+			versions, err := obj.Versions()
+			expectSuccess(t, err)
+			expectInt(t, len(versions), 0)
+
+			//create some versions
+			contents := []string{"version 1", "version 2", "version 3"}
+			for _, content := range contents {
+				expectSuccess(t, obj.Upload(bytes.NewReader([]byte(content)), nil, nil))
+			}
+			versions, err = obj.Versions()
+			expectSuccess(t, err)
+			expectInt(t, len(versions), 2)
+			for idx, v := range versions {
+				expectString(t, v.Object.Container().Name(), archive.Name())
+				expectObjectContent(t, v.Object, []byte(contents[idx]))
+				if v.CreatedAt.IsZero() {
+					t.Errorf("expected version %d to have a timestamp", idx)
+				}
+				if idx > 0 && !versions[idx-1].CreatedAt.Before(v.CreatedAt) {
+					t.Errorf("expected versions to be in chronological order, but got %s after %s",
+						v.CreatedAt, versions[idx-1].CreatedAt)
+				}
+			}
+
+			//restore the oldest version (this archives "version 3")
+			expectSuccess(t, obj.RestoreVersion(versions[0], nil))
+			expectObjectContent(t, obj, []byte(contents[0]))
+			versions, err = obj.Versions()
+			expectSuccess(t, err)
+			expectInt(t, len(versions), 3)
+			expectObjectContent(t, versions[2].Object, []byte(contents[2]))
+
+			//in history mode, deleting the object leaves a delete marker
+			expectSuccess(t, obj.Delete(nil, nil))
+			versions, err = obj.Versions()
+			expectSuccess(t, err)
+			expectInt(t, len(versions), 5)
+			expectBool(t, versions[3].IsDeleteMarker, false)
+			expectBool(t, versions[4].IsDeleteMarker, true)
+			if obj.RestoreVersion(versions[4], nil) == nil {
+				t.Error("expected restoring a delete marker to fail")
+			}
+			if c.Object("other").RestoreVersion(versions[0], nil) == nil {
+				t.Error("expected restoring a version of another object to fail")
+			}
+
+			//switch to legacy versioning mode
+			expectSuccess(t, c.EnableVersioning(archive, schwift.VersioningStack, nil))
+			hdr, err = c.Headers()
+			expectSuccess(t, err)
+			expectString(t, hdr.VersionsLocation().Get(), archive.Name())
+			expectBool(t, hdr.HistoryLocation().Exists(), false)
+
+			expectSuccess(t, c.DisableVersioning(nil))
+			hdr, err = c.Headers()
+			expectSuccess(t, err)
+			expectBool(t, hdr.VersionsLocation().Exists(), false)
+			expectBool(t, hdr.HistoryLocation().Exists(), false)
+			versions, err = obj.Versions()
+			expectSuccess(t, err)
+			expectInt(t, len(versions), 0)
+		})
+	})
+}
@@ -0,0 +1,195 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+//TokenProvider is the interface that NewTokenBackend() uses to obtain auth
+//tokens from an authentication library of the caller's choice.
+type TokenProvider interface {
+	//GetToken returns an auth token for the Swift account. It is called
+	//before the first request, and again whenever Swift rejects the current
+	//token with 401 (Unauthorized), so it should obtain a fresh token on every
+	//call.
+	GetToken() (string, error)
+}
+
+//TokenBackendOptions contains additional options that can be passed to
+//NewTokenBackend().
+type TokenBackendOptions struct {
+	//If set, requests are executed with this HTTP client instead of
+	//http.DefaultClient. This can be used to configure timeouts, TLS settings
+	//or proxies. Note that http.Client.Timeout also covers reading the response
+	//body, so it will abort long-running transfers; prefer the timeouts on the
+	//http.Transport for bounding the time until the server starts responding.
+	HTTPClient *http.Client
+	//If set, this User-Agent will be reported in HTTP requests instead of
+	//schwift.DefaultUserAgent.
+	UserAgent string
+	//If set, these headers are added to every request, e.g. for
+	//deployment-specific headers that a gateway in front of Swift requires.
+	//Headers that are already set on a request (e.g. through
+	//RequestOptions.Headers) are not overwritten. X-Auth-Token and User-Agent
+	//cannot be set this way.
+	ExtraHeaders http.Header
+}
+
+//NewTokenBackend creates a Backend that talks to the Swift account at the
+//given endpoint URL (e.g. "https://swift.example.com/v1/AUTH_projectid/"),
+//using auth tokens from the given TokenProvider. This is useful when
+//authentication is handled by something other than Gophercloud:
+//
+//	backend := schwift.NewTokenBackend(storageURL, tokenProvider, nil)
+//	account, err := schwift.InitializeAccount(backend)
+//
+//When Swift responds with 401 (Unauthorized), the backend obtains a new token
+//from the TokenProvider and sends the request once more. This is not possible
+//for requests whose body cannot be re-read from the start (see documentation
+//on type RetryPolicy for which bodies can be re-read); for those, the 401
+//response is returned to the caller.
+func NewTokenBackend(endpointURL string, tokens TokenProvider, opts *TokenBackendOptions) Backend {
+	b := &tokenBackend{
+		endpointURL: endpointURL,
+		client:      http.DefaultClient,
+		userAgent:   DefaultUserAgent,
+		tokens:      &tokenCache{provider: tokens},
+	}
+	if opts != nil && opts.HTTPClient != nil {
+		b.client = opts.HTTPClient
+	}
+	if opts != nil && opts.UserAgent != "" {
+		b.userAgent = opts.UserAgent
+	}
+	if opts != nil {
+		b.extraHeaders = opts.ExtraHeaders
+	}
+	return b
+}
+
+type tokenBackend struct {
+	endpointURL  string
+	client       *http.Client
+	userAgent    string
+	extraHeaders http.Header
+	tokens       *tokenCache
+}
+
+//tokenCache is shared between a tokenBackend and its clones.
+type tokenCache struct {
+	provider TokenProvider
+	mutex    sync.Mutex
+	token    string
+}
+
+//Get returns the cached token. A new token is obtained if there is no cached
+//token yet, or if the cached token is the one that the caller saw rejected.
+//(If another goroutine already replaced the rejected token, its replacement
+//is returned without asking the TokenProvider again.)
+func (c *tokenCache) Get(rejectedToken string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token == "" || c.token == rejectedToken {
+		token, err := c.provider.GetToken()
+		if err != nil {
+			return "", err
+		}
+		c.token = token
+	}
+	return c.token, nil
+}
+
+//EndpointURL implements the Backend interface.
+func (b *tokenBackend) EndpointURL() string {
+	return b.endpointURL
+}
+
+//Clone implements the Backend interface.
+func (b *tokenBackend) Clone(newEndpointURL string) Backend {
+	cloned := *b
+	cloned.endpointURL = newEndpointURL
+	return &cloned
+}
+
+//Do implements the Backend interface.
+func (b *tokenBackend) Do(req *http.Request) (*http.Response, error) {
+	token, err := b.tokens.Get("")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.do(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !canResendRequest(req) {
+		return resp, err
+	}
+
+	//token has probably expired -> get a new one and restart the request
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	token, err = b.tokens.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	req, err = rewindRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return b.do(req, token)
+}
+
+func (b *tokenBackend) do(req *http.Request, token string) (*http.Response, error) {
+	for key, values := range b.extraHeaders {
+		if req.Header.Get(key) == "" {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	req.Header.Set("X-Auth-Token", token)
+	req.Header.Set("User-Agent", b.userAgent)
+	return b.client.Do(req)
+}
+
+//canResendRequest checks whether the request body (if any) can be re-read
+//from the start with rewindRequest().
+func canResendRequest(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+//rewindRequest prepares a request that has already been sent for sending it
+//again, by cloning it with a fresh body from req.GetBody().
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
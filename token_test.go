@@ -0,0 +1,141 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//countingTokenProvider returns "token1", "token2", etc.
+type countingTokenProvider struct {
+	count int
+}
+
+func (p *countingTokenProvider) GetToken() (string, error) {
+	p.count++
+	return "token" + strconv.Itoa(p.count), nil
+}
+
+func TestTokenBackend(t *testing.T) {
+	//this server only accepts the second token
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(buf))
+		if r.Header.Get("X-Auth-Token") != "token2" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("User-Agent") != DefaultUserAgent {
+			http.Error(w, "unexpected User-Agent", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	provider := &countingTokenProvider{}
+	backend := NewTokenBackend(server.URL+"/v1/AUTH_test/", provider, nil)
+	account, err := InitializeAccount(backend)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	//the first request gets 401 with token1, and is restarted with token2
+	err = account.Container("foo").Object("bar").Upload(strings.NewReader("hello"), nil, nil)
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+	//the second request uses the cached token2
+	err = account.Container("foo").Object("baz").Upload(strings.NewReader("world"), nil, nil)
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+
+	if provider.count != 2 {
+		t.Errorf("expected 2 calls to GetToken(), got %d", provider.count)
+	}
+	expectedBodies := []string{"hello", "hello", "world"}
+	if strings.Join(bodies, ",") != strings.Join(expectedBodies, ",") {
+		t.Errorf("expected request bodies %v, got %v", expectedBodies, bodies)
+	}
+
+	//requests with bodies that cannot be rewound are not restarted
+	bodies = nil
+	provider.count = 0
+	backend = NewTokenBackend(server.URL+"/v1/AUTH_test/", provider, nil)
+	account, err = InitializeAccount(backend)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = account.Container("foo").Object("bar").Upload(opaqueReader{strings.NewReader("hello")}, nil, nil)
+	if !Is(err, http.StatusUnauthorized) {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+	if len(bodies) != 1 {
+		t.Errorf("expected 1 request, got %d", len(bodies))
+	}
+}
+
+func TestTokenBackendExtraHeaders(t *testing.T) {
+	var flags []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags = append(flags, strings.Join(r.Header["X-Feature-Flag"], "+"))
+		if r.Header.Get("X-Auth-Token") != "token1" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	backend := NewTokenBackend(server.URL+"/v1/AUTH_test/", &countingTokenProvider{}, &TokenBackendOptions{
+		ExtraHeaders: http.Header{
+			"x-feature-flag": {"foo", "bar"},
+			"X-Auth-Token":   {"not-the-token"},
+		},
+	})
+	account, err := InitializeAccount(backend)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	//extra headers are added to every request, but do not overwrite headers
+	//set on the request itself
+	obj := account.Container("foo").Object("bar")
+	err = obj.Upload(strings.NewReader("hello"), nil, nil)
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+	hdr := make(Headers)
+	hdr.Set("X-Feature-Flag", "qux")
+	err = obj.Upload(strings.NewReader("hello"), nil, &RequestOptions{Headers: hdr})
+	if err != nil {
+		t.Errorf("expected success, got error %q", err.Error())
+	}
+	expectedFlags := []string{"foo+bar", "qux"}
+	if strings.Join(flags, ",") != strings.Join(expectedFlags, ",") {
+		t.Errorf("expected X-Feature-Flag headers %v, got %v", expectedFlags, flags)
+	}
+}
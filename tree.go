@@ -0,0 +1,381 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//TreeAction describes what Container.UploadTree() does with a single file.
+type TreeAction int
+
+const (
+	//TreeActionUpload means that the local file is uploaded into an object.
+	TreeActionUpload TreeAction = iota
+	//TreeActionKeep means that the local file is not uploaded because the
+	//existing object has the same content (only with
+	//UploadTreeOptions.SkipUnchanged).
+	TreeActionKeep
+	//TreeActionSkip means that the local file is not uploaded because it is
+	//not a regular file, e.g. a symlink (unless
+	//UploadTreeOptions.FollowSymlinks is set), a device or a socket.
+	TreeActionSkip
+	//TreeActionDelete means that the object is deleted because there is no
+	//corresponding local file (only with UploadTreeOptions.DeleteExtraneous).
+	TreeActionDelete
+)
+
+//String returns a human-readable representation of this TreeAction.
+func (a TreeAction) String() string {
+	switch a {
+	case TreeActionUpload:
+		return "upload"
+	case TreeActionKeep:
+		return "keep"
+	case TreeActionSkip:
+		return "skip"
+	case TreeActionDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("TreeAction(%d)", int(a))
+	}
+}
+
+//TreeResult is returned by Container.UploadTree() for each local file, and
+//for each object that is deleted in mirror mode.
+type TreeResult struct {
+	//The path of the local file, including the localDir argument of
+	//UploadTree(). Empty for TreeActionDelete.
+	LocalPath string
+	//The object corresponding to the local file.
+	Object *Object
+	Action TreeAction
+	//If Action is TreeActionUpload or TreeActionDelete, this contains the
+	//error returned by the upload or deletion (or nil on success). Always nil
+	//in dry-run mode.
+	Err error
+}
+
+//UploadTreeOptions invokes advanced behavior in the Container.UploadTree()
+//method.
+type UploadTreeOptions struct {
+	//If larger than 1, up to that many files will be uploaded in parallel.
+	Concurrency int
+	//If set, the MD5 checksum of each local file is compared to the Etag of the
+	//existing object with the same name, and the file is not uploaded if they
+	//match. The Etags are obtained from one object listing, so this does not
+	//require a HEAD request per file.
+	SkipUnchanged bool
+	//If set, symlinks to regular files are followed and the target's contents
+	//are uploaded. Symlinks to directories are never followed.
+	FollowSymlinks bool
+	//If set, objects below the prefix that do not correspond to any local file
+	//are deleted afterwards, like with "rsync --delete". Objects corresponding
+	//to skipped local files (see TreeActionSkip) are not deleted.
+	DeleteExtraneous bool
+	//If set, UploadTree() only computes the planned actions and returns them
+	//without uploading or deleting anything. It is highly recommended to use
+	//this to review the planned deletions before using DeleteExtraneous.
+	DryRun bool
+	//These options are passed to Object.UploadFromFile() for each file.
+	FileOptions *UploadFromFileOptions
+	//If set, this callback is called with the local path of each file that is
+	//uploaded, and returns the Content-Type for the respective object (see
+	//below). With Concurrency > 1, it may be called from multiple goroutines
+	//at once.
+	ContentTypeResolver func(path string) string
+}
+
+//UploadTree uploads all regular files below the given local directory into
+//this container. For each file, the object name is the path of the file
+//relative to localDir (with slashes as path separators), prepended by the
+//given prefix and a slash (unless the prefix is empty or already ends with a
+//slash). For example:
+//
+//	//uploads "./site/index.html" into "www/index.html",
+//	//"./site/css/main.css" into "www/css/main.css", and so on
+//	results, err := container.UploadTree("./site", "www", nil, nil)
+//
+//Symlinks and special files like devices or sockets are skipped by default.
+//Use FollowSymlinks to upload the targets of symlinks instead.
+//
+//With DeleteExtraneous, UploadTree() mirrors the local directory: After all
+//uploads have succeeded, all objects whose name starts with the prefix, but
+//which do not correspond to a local file, are deleted using BulkDelete(). Be
+//careful when the prefix is empty or contains other data, e.g. segments of
+//large objects: everything below the prefix that is not present locally will
+//be deleted. The deletion is skipped entirely if any upload failed. Use DryRun
+//to review the planned actions first:
+//
+//	opts := &schwift.UploadTreeOptions{DeleteExtraneous: true, DryRun: true}
+//	results, err := container.UploadTree("./site", "www", opts, nil)
+//	for _, r := range results {
+//		fmt.Printf("%s %s\n", r.Action, r.Object.Name())
+//	}
+//
+//If ContentTypeResolver is set, the Content-Type of each uploaded object is
+//chosen by it, even if ropts contain a Content-Type already. When it returns
+//an empty string for a file, the Content-Type is detected automatically as
+//described for UploadFromFileOptions.DetectContentType. This allows to
+//override the detection for some file types only:
+//
+//	opts := &schwift.UploadTreeOptions{
+//		ContentTypeResolver: func(path string) string {
+//			if strings.HasSuffix(path, ".js") {
+//				return "text/javascript"
+//			}
+//			return "" //detect automatically
+//		},
+//	}
+//
+//The returned slice contains one TreeResult for each file that was found, in
+//lexical order of the local paths, followed by one TreeResult for each object
+//to be deleted, in lexical order of the object names. If some uploads fail,
+//UploadTree() still attempts to upload all other files, and returns an error
+//summarizing the failures in addition to the results; the individual errors can
+//be found in the results. If localDir cannot be read, or the object listing for
+//SkipUnchanged or DeleteExtraneous fails, nothing is uploaded and only an error
+//is returned.
+//
+//The given ropts are used for each upload request.
+func (c *Container) UploadTree(localDir, prefix string, opts *UploadTreeOptions, ropts *RequestOptions) ([]TreeResult, error) {
+	if opts == nil {
+		opts = &UploadTreeOptions{}
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	results, err := c.planTreeUpload(localDir, prefix, opts, ropts)
+	if err != nil || opts.DryRun {
+		return results, err
+	}
+	err = c.executeTreeUpload(results, opts, ropts)
+	if err != nil {
+		return results, err
+	}
+	return results, c.executeTreeDeletion(results, ropts)
+}
+
+//planTreeUpload walks the local directory and decides what to do with each
+//file.
+func (c *Container) planTreeUpload(localDir, prefix string, opts *UploadTreeOptions, ropts *RequestOptions) ([]TreeResult, error) {
+	var remoteEtags map[string]string
+	if opts.SkipUnchanged || opts.DeleteExtraneous {
+		remoteEtags = make(map[string]string)
+		iter := ObjectIterator{
+			Container: c,
+			Prefix:    prefix,
+			Options:   requestOptionsWithContextOnly(ropts),
+		}
+		err := iter.ForeachDetailed(func(info ObjectInfo) error {
+			if info.Object != nil {
+				remoteEtags[info.Object.Name()] = normalizeEtag(info.Etag)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var results []TreeResult
+	err := filepath.Walk(localDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		result := TreeResult{
+			LocalPath: path,
+			Object:    c.Object(prefix + filepath.ToSlash(relPath)),
+			Action:    TreeActionUpload,
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 && opts.FollowSymlinks {
+			fi, err = os.Stat(path)
+			if err != nil {
+				return err
+			}
+		}
+		etag, exists := remoteEtags[result.Object.Name()]
+		delete(remoteEtags, result.Object.Name())
+		if !fi.Mode().IsRegular() {
+			result.Action = TreeActionSkip
+		} else if exists && opts.SkipUnchanged {
+			localEtag, err := md5OfFile(path)
+			if err != nil {
+				return err
+			}
+			if localEtag == etag {
+				result.Action = TreeActionKeep
+			}
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//filepath.Walk() already visits files in lexical order
+
+	//all remaining remote objects do not have a local counterpart
+	if opts.DeleteExtraneous {
+		names := make([]string, 0, len(remoteEtags))
+		for name := range remoteEtags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			results = append(results, TreeResult{
+				Object: c.Object(name),
+				Action: TreeActionDelete,
+			})
+		}
+	}
+	return results, nil
+}
+
+//executeTreeUpload uploads all files with TreeActionUpload, and fills the Err
+//fields of the results.
+func (c *Container) executeTreeUpload(results []TreeResult, opts *UploadTreeOptions, ropts *RequestOptions) error {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	//when a ContentTypeResolver is set, files for which it does not choose a
+	//Content-Type fall back to auto-detection
+	fileOpts := opts.FileOptions
+	if opts.ContentTypeResolver != nil {
+		fileOpts = &UploadFromFileOptions{DetectContentType: true}
+		if opts.FileOptions != nil {
+			fileOpts.UploadOptions = opts.FileOptions.UploadOptions
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				r := &results[idx]
+				fileRopts := ropts
+				if opts.ContentTypeResolver != nil {
+					if contentType := opts.ContentTypeResolver(r.LocalPath); contentType != "" {
+						hdr := NewObjectHeaders()
+						hdr.ContentType().Set(contentType)
+						fileRopts = cloneRequestOptions(ropts, hdr.Headers)
+					}
+				}
+				r.Err = r.Object.UploadFromFile(r.LocalPath, fileOpts, fileRopts)
+			}
+		}()
+	}
+	for idx, r := range results {
+		if r.Action == TreeActionUpload {
+			indexes <- idx
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var (
+		failed   int
+		firstErr error
+	)
+	for _, r := range results {
+		if r.Err != nil {
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not upload %d files (first error: %s)", failed, firstErr.Error())
+	}
+	return nil
+}
+
+//executeTreeDeletion deletes all objects with TreeActionDelete, and fills the
+//Err fields of the results.
+func (c *Container) executeTreeDeletion(results []TreeResult, ropts *RequestOptions) error {
+	var objects []*Object
+	for _, r := range results {
+		if r.Action == TreeActionDelete {
+			objects = append(objects, r.Object)
+		}
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+
+	_, _, err := c.a.BulkDelete(objects, nil, requestOptionsWithContextOnly(ropts))
+	if err == nil {
+		return nil
+	}
+	bulkErr, isBulkErr := err.(BulkError)
+	for idx := range results {
+		r := &results[idx]
+		if r.Action != TreeActionDelete {
+			continue
+		}
+		if !isBulkErr {
+			r.Err = err
+			continue
+		}
+		for _, objErr := range bulkErr.ObjectErrors {
+			if objErr.ContainerName == c.name && objErr.ObjectName == r.Object.name {
+				r.Err = objErr
+			}
+		}
+	}
+	return err
+}
+
+//md5OfFile returns the MD5 checksum of the contents of the given file.
+func md5OfFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := md5.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,231 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//VersioningMode enumerates the modes of object versioning supported by Swift,
+//see Container.EnableVersioning().
+type VersioningMode int
+
+const (
+	//VersioningHistory is the versioning mode enabled by the X-History-Location
+	//header. When an object is overwritten or deleted, its previous version is
+	//moved into the archive container. Deleting an object does not restore
+	//earlier versions.
+	VersioningHistory VersioningMode = iota
+	//VersioningStack is the legacy versioning mode enabled by the
+	//X-Versions-Location header. When an object is overwritten, its previous
+	//version is moved into the archive container. When the object is deleted,
+	//the most recent archived version is restored.
+	VersioningStack
+)
+
+//EnableVersioning enables object versioning on this container using a POST
+//request. Previous versions of objects will be archived in the given
+//container, which must exist and be located in the same account (otherwise
+//ErrAccountMismatch is returned). If the given mode is not one of the
+//VersioningMode constants, an error is returned without sending a request.
+//
+//A successful POST request implies Invalidate() since it may change metadata.
+func (c *Container) EnableVersioning(archive *Container, mode VersioningMode, opts *RequestOptions) error {
+	if !archive.a.isEqualTo(c.a) {
+		return ErrAccountMismatch
+	}
+
+	hdr := NewContainerHeaders()
+	switch mode {
+	case VersioningHistory:
+		hdr.HistoryLocation().Set(archive.name)
+		hdr.VersionsLocation().Clear()
+	case VersioningStack:
+		hdr.VersionsLocation().Set(archive.name)
+		hdr.HistoryLocation().Clear()
+	default:
+		return fmt.Errorf("no such versioning mode: %d", mode)
+	}
+	return c.Update(hdr, opts)
+}
+
+//DisableVersioning disables object versioning on this container using a POST
+//request. Archived versions of objects are not deleted by this call.
+//
+//A successful POST request implies Invalidate() since it may change metadata.
+func (c *Container) DisableVersioning(opts *RequestOptions) error {
+	hdr := NewContainerHeaders()
+	hdr.HistoryLocation().Clear()
+	hdr.VersionsLocation().Clear()
+	return c.Update(hdr, opts)
+}
+
+//ObjectVersion describes an archived version of an object. This type is
+//returned by Object.Versions().
+type ObjectVersion struct {
+	//Object is the location of the archived version in the archive container.
+	Object *Object
+	//CreatedAt is the time when this version of the object was created (i.e.
+	//the X-Timestamp of the original object).
+	CreatedAt time.Time
+	//Version is the timestamp from which CreatedAt was parsed, in the format
+	//that Swift uses in the name of the archived object (e.g.
+	//"1500000000.12345"). It identifies the version uniquely.
+	Version string
+	//IsDeleteMarker is true if this is not an actual version of the object,
+	//but a marker that Swift archived when the object was deleted. Delete
+	//markers have no content and cannot be restored.
+	IsDeleteMarker bool
+}
+
+//deleteMarkerContentType is the Content-Type of the objects that Swift puts
+//into the archive container to record the deletion of an object.
+const deleteMarkerContentType = "application/x-deleted;swift_versions_deleted=1"
+
+//Versions lists the archived versions of this object, in chronological order
+//(oldest first). If versioning is not enabled on the object's container, an
+//empty list is returned.
+//
+//The archive container is found by inspecting the headers of the object's
+//container. Archived versions are recognized by the naming scheme that Swift
+//uses in the archive container, "<length><name>/<timestamp>", where <length>
+//is the length of the object name as a three-digit hexadecimal number.
+//
+//Both versioning modes use this naming scheme, but they differ in what ends
+//up in the archive container. In VersioningStack mode, only overwritten
+//versions are archived, and deleting the object moves the most recent
+//archived version back into place. In VersioningHistory mode, deleting the
+//object archives its current version and then adds a delete marker, so the
+//archive contains the full history of the object, including deletions. Delete
+//markers are included in the result with IsDeleteMarker = true.
+func (o *Object) Versions() ([]ObjectVersion, error) {
+	archive, err := o.archiveContainer()
+	if err != nil || archive == nil {
+		return nil, err
+	}
+
+	iter := archive.Objects()
+	iter.Prefix = fmt.Sprintf("%03x%s/", len(o.name), o.name)
+	var result []ObjectVersion
+	err = iter.ForeachDetailed(func(info ObjectInfo) error {
+		_, version, ok := parseArchivedObjectName(info.Object.name)
+		createdAt, err := parseSwiftTimestamp(version)
+		if !ok || err != nil {
+			//this error is sufficiently obscure that we don't need to expose a type for it
+			return fmt.Errorf("Bad archived object name %q", info.Object.FullName())
+		}
+		result = append(result, ObjectVersion{
+			Object:         info.Object,
+			CreatedAt:      createdAt,
+			Version:        version,
+			IsDeleteMarker: info.ContentType == deleteMarkerContentType,
+		})
+		return nil
+	})
+	return result, err
+}
+
+//RestoreVersion makes the given archived version the current version of this
+//object, by copying it from the archive container with a COPY request. The
+//version must have been obtained from o.Versions(). Since versioning is still
+//enabled, Swift archives the current version of the object (if any) before
+//it is overwritten, so restoring a version does not lose any data.
+//
+//Restoring a delete marker is not possible. To get the same effect, delete
+//the object instead.
+//
+//A successful COPY implies Invalidate() since it may change metadata.
+func (o *Object) RestoreVersion(version ObjectVersion, ropts *RequestOptions) error {
+	name, _, ok := parseArchivedObjectName(version.Object.name)
+	if !ok || name != o.name || !version.Object.c.a.isEqualTo(o.c.a) {
+		return fmt.Errorf("%q is not an archived version of %q", version.Object.FullName(), o.FullName())
+	}
+	if version.IsDeleteMarker {
+		return errors.New("cannot restore a delete marker")
+	}
+	return version.Object.CopyTo(o, nil, ropts)
+}
+
+//archiveContainer returns the container where archived versions of this
+//object are stored, or nil if versioning is not enabled.
+func (o *Object) archiveContainer() (*Container, error) {
+	hdr, err := o.c.Headers()
+	if err != nil {
+		return nil, err
+	}
+	archiveName := hdr.HistoryLocation().Get()
+	if archiveName == "" {
+		archiveName = hdr.VersionsLocation().Get()
+	}
+	if archiveName == "" {
+		return nil, nil
+	}
+	return o.c.a.Container(archiveName), nil
+}
+
+//parseArchivedObjectName splits the name of an archived object version, e.g.
+//"007example/1500000000.12345", into the name of the original object
+//("example") and the version ("1500000000.12345").
+func parseArchivedObjectName(name string) (objectName, version string, ok bool) {
+	if len(name) < 3 {
+		return "", "", false
+	}
+	length, err := strconv.ParseUint(name[:3], 16, 16)
+	if err != nil {
+		return "", "", false
+	}
+	rest := name[3:]
+	if uint64(len(rest)) <= length || rest[length] != '/' {
+		return "", "", false
+	}
+	return rest[:length], rest[length+1:], true
+}
+
+//parseSwiftTimestamp parses timestamps in Swift's internal format, e.g.
+//"1500000000.12345" or "1500000000.12345_0000000000000001" (with offset,
+//which is ignored).
+func parseSwiftTimestamp(str string) (time.Time, error) {
+	if idx := strings.IndexByte(str, '_'); idx >= 0 {
+		str = str[:idx]
+	}
+	//parse seconds and fractional part separately to avoid rounding errors
+	secStr, fracStr := str, ""
+	if idx := strings.IndexByte(str, '.'); idx >= 0 {
+		secStr, fracStr = str[:idx], str[idx+1:]
+	}
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec uint64
+	if fracStr != "" {
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		nsec, err = strconv.ParseUint(fracStr+strings.Repeat("0", 9-len(fracStr)), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(sec, int64(nsec)), nil
+}
@@ -0,0 +1,84 @@
+/******************************************************************************
+*
+*  Copyright 2018 Stefan Majewsky <majewsky@gmx.net>
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+*
+******************************************************************************/
+
+package schwift
+
+import "testing"
+
+func TestParseSwiftTimestamp(t *testing.T) {
+	testCases := []struct {
+		input string
+		ok    bool
+		nanos int64
+	}{
+		{"1500000000.00000", true, 1500000000000000000},
+		{"1500000000.25000", true, 1500000000250000000},
+		{"1500000000.25000_0000000000000001", true, 1500000000250000000},
+		{"1500000000", true, 1500000000000000000},
+		{"wtf", false, 0},
+		{"1500000000.-5", false, 0},
+		{"", false, 0},
+	}
+
+	for _, tc := range testCases {
+		ts, err := parseSwiftTimestamp(tc.input)
+		if tc.ok && err != nil {
+			t.Errorf("expected %q to parse, but got error: %s", tc.input, err.Error())
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("expected %q to fail, but parsed into %s", tc.input, ts)
+		}
+		if tc.ok && ts.UnixNano() != tc.nanos {
+			t.Errorf("expected %q to parse into %d, but got %d", tc.input, tc.nanos, ts.UnixNano())
+		}
+	}
+}
+
+func TestParseArchivedObjectName(t *testing.T) {
+	testCases := []struct {
+		input   string
+		name    string
+		version string
+		ok      bool
+	}{
+		{"007example/1500000000.12345", "example", "1500000000.12345", true},
+		{"00bfoo/bar/baz/1500000000.12345", "foo/bar/baz", "1500000000.12345", true},
+		{"007example1500000000.12345", "", "", false},
+		{"010example/1500000000.12345", "", "", false},
+		{"xyzexample/1500000000.12345", "", "", false},
+		{"00", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		name, version, ok := parseArchivedObjectName(tc.input)
+		if name != tc.name || version != tc.version || ok != tc.ok {
+			t.Errorf("expected parseArchivedObjectName(%q) = (%q, %q, %t), got (%q, %q, %t)",
+				tc.input, tc.name, tc.version, tc.ok, name, version, ok)
+		}
+	}
+}
+
+func TestEnableVersioningInvalidMode(t *testing.T) {
+	//without a handler, the backend panics on any request, so this also checks
+	//that no request is sent
+	a := newTestAccount(t, nil)
+	err := a.Container("foo").EnableVersioning(a.Container("archive"), VersioningMode(42), nil)
+	if err == nil || err.Error() != "no such versioning mode: 42" {
+		t.Errorf("expected error for invalid versioning mode, got %v", err)
+	}
+}